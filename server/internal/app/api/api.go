@@ -4,35 +4,56 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
 	"mime"
 	"net/http"
 	"path/filepath"
+	"server/internal/app/auth"
 	"server/internal/app/config"
+	"server/internal/app/forwarding"
 	"server/internal/app/helper"
+	"server/internal/app/hub"
+	"server/internal/app/lease"
 	"server/internal/app/middlewares"
 	"server/internal/app/models"
+	"server/internal/app/otp"
+	"server/internal/app/presence"
+	"server/internal/app/retention"
 	"server/internal/app/storage"
+	"server/internal/app/telemetry"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
 type Server struct {
-	config  *config.Config
-	logger  *logrus.Logger
-	router  *mux.Router
-	storage *storage.Storage
+	config    *config.Config
+	logger    *logrus.Logger
+	router    *mux.Router
+	storage   storage.Storage
+	leases    *lease.Manager
+	keys      *auth.KeySet
+	otp       *otp.Parser
+	hub       *hub.Hub
+	forwarder *forwarding.Dispatcher
+	retention *retention.Purger
+	presence  *presence.Checker
+	telemetry *telemetry.Recorder
 }
 
-func New(config *config.Config) *Server {
+func New(config *config.Config, storage storage.Storage) *Server {
 	return &Server{
-		config: config,
-		logger: logrus.New(),
-		router: mux.NewRouter(),
+		config:    config,
+		logger:    logrus.New(),
+		router:    mux.NewRouter(),
+		storage:   storage,
+		leases:    lease.NewManager(config.Lease, storage),
+		hub:       hub.New(streamHistory),
+		forwarder: forwarding.NewDispatcher(config.Forwarding, storage.Forwarding()),
+		retention: retention.NewPurger(config.Retention, storage.Notification()),
+		presence:  presence.NewChecker(config.Presence, storage.Phone(), storage.Event()),
 	}
 }
 
@@ -41,12 +62,29 @@ func (s *Server) Start() error {
 		return err
 	}
 
-	s.configureRouter()
+	if err := s.configureAuth(); err != nil {
+		return err
+	}
+
+	if err := s.configureOtp(); err != nil {
+		return err
+	}
 
-	if err := s.configureStorage(); err != nil {
+	if err := s.configureTelemetry(); err != nil {
 		return err
 	}
 
+	s.configureRouter()
+
+	go s.sweepLeases()
+	go s.forwardNotifications()
+	if s.config.Retention.Interval > 0 {
+		go s.purgeNotifications()
+	}
+	if s.config.Presence.CheckInterval > 0 {
+		go s.checkPresence()
+	}
+
 	s.logger.Info("Starting server...")
 
 	return http.ListenAndServe(s.config.BindAddr, s.router)
@@ -65,20 +103,80 @@ func (s *Server) configureLogger() error {
 
 func (s *Server) configureRouter() {
 	api := s.router.PathPrefix("/api").Subrouter()
+	isAuthorized := middlewares.IsAuthorized(s.keys, s.storage.Session())
+	can := middlewares.HasPermission(isAuthorized, s.storage.User())
+	isDevice := middlewares.IsEnrolledDevice(s.storage.Enrollment())
 
 	api.HandleFunc("/test", s.handleTest())
-	api.HandleFunc("/phone_info", s.handlePhoneInfo()).Methods("POST", "OPTIONS")
-	api.HandleFunc("/devices", middlewares.IsAuthorized(s.handleDevices())).Methods("GET", "OPTIONS")
-	api.HandleFunc("/phone", middlewares.IsAuthorized(s.handleDeletePhone())).Methods("DELETE", "OPTIONS")
-	api.HandleFunc("/user", middlewares.IsAuthorized(s.handleUser())).Methods("GET", "OPTIONS")
-	api.HandleFunc("/user", middlewares.IsAuthorized(s.handleDeleteUser())).Methods("DELETE", "OPTIONS")
-	api.HandleFunc("/users_phones", middlewares.IsAuthorized(s.handleUserPhoneList())).Methods("GET", "OPTIONS")
-	api.HandleFunc("/notifications", middlewares.IsAuthorized(s.handleNotifications())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/phone_info", isDevice(s.handlePhoneInfo())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/heartbeat", isDevice(s.handleHeartbeat())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/devices", can(auth.PermDevicesRead, s.handleDevices())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/phone", can(auth.PermPhonesDelete, s.handleDeletePhone())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}", can(auth.PermDevicesRead, s.handlePhone())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}", can(auth.PermDevicesManage, s.handleUpdatePhone())).Methods("PATCH", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}/slot", can(auth.PermDevicesManage, s.handleAssignSlot())).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}/telemetry", can(auth.PermDevicesRead, s.handleTelemetry())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}/history", can(auth.PermDevicesRead, s.handlePhoneHistory())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}/checkout", can(auth.PermPhonesReserve, s.handleCheckout())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}/checkin", can(auth.PermPhonesReserve, s.handleCheckin())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/phones/{id:[0-9]+}/reservations", can(auth.PermDevicesRead, s.handlePhoneReservations())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/reservations/{id:[0-9]+}", can(auth.PermPhonesReserve, s.handleCancelReservation())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/leases", can(auth.PermLeasesUse, s.handleAcquireLease())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/leases/{token:[0-9a-f]+}", can(auth.PermLeasesUse, s.handleLease())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/leases/{token:[0-9a-f]+}/heartbeat", can(auth.PermLeasesUse, s.handleLeaseHeartbeat())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/leases/{token:[0-9a-f]+}", can(auth.PermLeasesUse, s.handleReleaseLease())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/sims", can(auth.PermDevicesRead, s.handleSims())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/sims", can(auth.PermDevicesManage, s.handleRegisterSim())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/sims/{id:[0-9]+}", can(auth.PermDevicesRead, s.handleSim())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/sims/{id:[0-9]+}", can(auth.PermDevicesManage, s.handleUpdateSim())).Methods("PATCH", "OPTIONS")
+	api.HandleFunc("/sims/{id:[0-9]+}", can(auth.PermDevicesManage, s.handleDeleteSim())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/sd_cards", can(auth.PermDevicesRead, s.handleSdCards())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/sd_cards/{id:[0-9]+}", can(auth.PermDevicesRead, s.handleSdCard())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/sd_cards/{id:[0-9]+}/usage", can(auth.PermDevicesRead, s.handleSdCardUsage())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/sd_cards/{id:[0-9]+}", can(auth.PermDevicesManage, s.handleDeleteSdCard())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/user", isAuthorized(s.handleUser())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/user", can(auth.PermUsersDelete, s.handleDeleteUser())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/locations", can(auth.PermDevicesRead, s.handleLocations())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/locations", can(auth.PermDevicesManage, s.handleCreateLocation())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/locations/{id:[0-9]+}", can(auth.PermDevicesRead, s.handleLocation())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/locations/{id:[0-9]+}", can(auth.PermDevicesManage, s.handleUpdateLocation())).Methods("PATCH", "OPTIONS")
+	api.HandleFunc("/locations/{id:[0-9]+}", can(auth.PermDevicesManage, s.handleDeleteLocation())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/assets/{tag}", can(auth.PermDevicesRead, s.handleAsset())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/assets/{tag}/label", can(auth.PermDevicesRead, s.handleAssetLabel())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/labels", can(auth.PermDevicesRead, s.handleLabels())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/audits", can(auth.PermDevicesRead, s.handleAudits())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/audits", can(auth.PermDevicesManage, s.handleStartAudit())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/audits/{id:[0-9]+}", can(auth.PermDevicesRead, s.handleAudit())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/audits/{id:[0-9]+}/scans", can(auth.PermDevicesManage, s.handleAuditScan())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/audits/{id:[0-9]+}/finish", can(auth.PermDevicesManage, s.handleFinishAudit())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/audits/{id:[0-9]+}/report", can(auth.PermDevicesRead, s.handleAuditReport())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/events", can(auth.PermDevicesRead, s.handleEvents())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/users_phones", can(auth.PermDevicesRead, s.handleUserPhoneList())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/notifications", can(auth.PermNotificationsRead, s.handleNotifications())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/notifications/stream", middlewares.TokenFromCookie(can(auth.PermNotificationsRead, s.handleNotificationStream()))).Methods("GET", "OPTIONS")
+	api.HandleFunc("/notifications/ws", middlewares.TokenFromCookie(can(auth.PermNotificationsRead, s.handleNotificationSocket()))).Methods("GET", "OPTIONS")
+	api.HandleFunc("/forwarding/rules", can(auth.PermForwardingManage, s.handleForwardingRules())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/forwarding/rules", can(auth.PermForwardingManage, s.handleCreateForwardingRule())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/forwarding/rules/{id:[0-9]+}", can(auth.PermForwardingManage, s.handleUpdateForwardingRule())).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/forwarding/rules/{id:[0-9]+}", can(auth.PermForwardingManage, s.handleDeleteForwardingRule())).Methods("DELETE", "OPTIONS")
+	api.HandleFunc("/forwarding/deliveries", can(auth.PermForwardingManage, s.handleDeliveries())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/retention", can(auth.PermNotificationsPurge, s.handleRetention())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/retention/purge", can(auth.PermNotificationsPurge, s.handlePurge())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/otp", can(auth.PermNotificationsRead, s.handleOtp())).Methods("GET", "OPTIONS")
 	api.HandleFunc("/login", s.handleLogin()).Methods("POST", "OPTIONS")
 	api.HandleFunc("/logout", s.handleLogout()).Methods("POST", "OPTIONS")
+	api.HandleFunc("/refresh", s.handleRefresh()).Methods("POST", "OPTIONS")
+	api.HandleFunc("/sessions", isAuthorized(s.handleSessions())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/sessions/{id:[0-9]+}/revoke", isAuthorized(s.handleRevokeSession())).Methods("POST", "OPTIONS")
 	api.HandleFunc("/register", s.handleRegister()).Methods("POST", "OPTIONS")
-	api.HandleFunc("/new_notification", s.handleNewNotification()).Methods("POST", "OPTIONS")
-	api.HandleFunc("/users", middlewares.IsAuthorized(s.handleUsers())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/enrollment_tokens", can(auth.PermDevicesEnroll, s.handleCreateEnrollmentToken())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/enroll", s.handleEnroll()).Methods("POST", "OPTIONS")
+	api.HandleFunc("/device_credentials", isAuthorized(s.handleDeviceCredentials())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/device_credentials/{id:[0-9]+}/revoke", isAuthorized(s.handleRevokeDeviceCredential())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/new_notification", isDevice(s.handleNewNotification())).Methods("POST", "OPTIONS")
+	api.HandleFunc("/users", can(auth.PermUsersRead, s.handleUsers())).Methods("GET", "OPTIONS")
+	api.HandleFunc("/users/{id:[0-9]+}/role", can(auth.PermUsersManageRoles, s.handleUpdateUserRole())).Methods("PUT", "OPTIONS")
+	api.HandleFunc("/roles", isAuthorized(s.handleRoles())).Methods("GET", "OPTIONS")
 
 	fs := http.FileServer(http.Dir("./static/dist"))
 
@@ -90,13 +188,108 @@ func (s *Server) configureRouter() {
 	s.router.Use(corsMiddleware)
 }
 
+func (s *Server) configureAuth() error {
+	keys, err := auth.NewKeySet(s.config.Auth)
+	if err != nil {
+		return err
+	}
+
+	s.keys = keys
+
+	return nil
+}
+
+func (s *Server) configureOtp() error {
+	parser, err := otp.NewParser(s.config.Otp)
+	if err != nil {
+		return err
+	}
+
+	s.otp = parser
+
+	return nil
+}
+
+func (s *Server) configureTelemetry() error {
+	recorder, err := telemetry.NewRecorder(s.config.Telemetry, s.storage.Telemetry(), s.storage.Event())
+	if err != nil {
+		return err
+	}
+
+	s.telemetry = recorder
+
+	return nil
+}
+
+func (s *Server) sweepLeases() {
+	ticker := time.NewTicker(s.config.Lease.SweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.leases.Sweep(); err != nil {
+			s.logger.Info(`[Leases] Error while sweeping leases`)
+			s.logger.Error(err)
+		}
+	}
+}
+
+func (s *Server) forwardNotifications() {
+	ticker := time.NewTicker(s.config.Forwarding.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.forwarder.Wake():
+		}
+
+		if err := s.forwarder.Process(); err != nil {
+			s.logger.Info(`[Forwarding] Error while delivering notifications`)
+			s.logger.Error(err)
+		}
+	}
+}
+
+func (s *Server) purgeNotifications() {
+	ticker := time.NewTicker(s.config.Retention.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		res, err := s.retention.Run(false)
+		if err != nil {
+			s.logger.Info(`[Retention] Error while purging notifications`)
+			s.logger.Error(err)
+			continue
+		}
+		if res.Removed > 0 {
+			s.logger.Info(fmt.Sprintf(`[Retention] Purged %d notifications`, res.Removed))
+		}
+	}
+}
+
+func (s *Server) checkPresence() {
+	ticker := time.NewTicker(s.config.Presence.CheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		events, err := s.presence.Check()
+		for _, e := range events {
+			s.logger.Info(fmt.Sprintf(`[Presence] %s`, e.Message))
+		}
+		if err != nil {
+			s.logger.Info(`[Presence] Error while checking for silent devices`)
+			s.logger.Error(err)
+		}
+	}
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:9111")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, DELETE, PUT")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, DELETE, PUT, PATCH")
 		w.Header().Set("Access-Control-Allow-Headers", "X-Requested-With, X-HTTP-Method-Override, Content-Type, Accept, Set-Cookie, Authorization")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Expose-Headers", "Set-Cookie")
+		w.Header().Set("Access-Control-Expose-Headers", "Set-Cookie, X-Next-Cursor")
 
 		if r.Method == http.MethodOptions {
 			return
@@ -106,17 +299,6 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func (s *Server) configureStorage() error {
-	st := storage.New(s.config.Storage)
-	if err := st.Open(); err != nil {
-		return err
-	}
-
-	s.storage = st
-
-	return nil
-}
-
 func staticHandler(fs http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
@@ -150,13 +332,6 @@ func (s *Server) handleTest() http.HandlerFunc {
 
 func (s *Server) handlePhoneInfo() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		type Info struct {
-			Phone   models.Phone     `json:"phone_info"`
-			SimInfo []models.SimInfo `json:"sim_info"`
-			SdInfo  []models.SdInfo  `json:"sd_info"`
-			AuthID  int              `json:"authorization_id"`
-		}
-
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
 			s.logger.Info(`[Phone info] Error when reading request body`)
@@ -166,8 +341,8 @@ func (s *Server) handlePhoneInfo() http.HandlerFunc {
 		}
 		r.Body.Close()
 
-		var resp *Info
-		err = json.Unmarshal(body, &resp)
+		var report *models.DeviceReport
+		err = json.Unmarshal(body, &report)
 		if err != nil {
 			s.logger.Info(`[Phone info] Error when unmarshalling request body`)
 			s.logger.Error(err)
@@ -175,37 +350,42 @@ func (s *Server) handlePhoneInfo() http.HandlerFunc {
 			return
 		}
 
-		resp.Phone.ModelTag, err = helper.ConvertModelTagToMarketingName(resp.Phone.ModelTag)
-		if err != nil {
-			s.logger.Info(`[Phone info] Error when translating model tag`)
-			s.logger.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+		// A credential belongs to the phone that first reported with it.
+		c := deviceCredential(r)
+		if c.PhoneId != nil {
+			bound, err := s.storage.Phone().SelectById(*c.PhoneId)
+			if err == nil && bound.ModelNumber != report.Phone.ModelNumber {
+				s.logger.Info(fmt.Sprintf(`[Phone info] Credential %d is bound to %s, not %s`, c.Id, bound.ModelNumber, report.Phone.ModelNumber))
+				http.Error(w, "Credential is bound to another phone", http.StatusForbidden)
+				return
+			}
 		}
-		resp.Phone.SimSlots = len(resp.SimInfo)
-		resp.Phone.SdSlots = len(resp.SdInfo)
+		report.UserId = c.UserId
+		report.CredentialId = c.Id
+		// Unlike heartbeats, reports without an address don't fall back to
+		// the one they connect from: agents that send it in heartbeats only
+		// would seem to switch networks.
+		report.Ip = strings.TrimSpace(report.Ip)
 
-		phone, err := s.storage.Phone().Create(&resp.Phone)
+		report.Phone.ModelTag, err = helper.ConvertModelTagToMarketingName(report.Phone.ModelTag)
 		if err != nil {
-			s.logger.Info(`[Phone info] Error when creating phone`)
+			s.logger.Info(`[Phone info] Error when translating model tag`)
 			s.logger.Error(err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-
-		s.storage.Sim().RemovePhoneId(phone.Id)
-		for _, sim := range resp.SimInfo {
-			_, err := s.storage.Sim().Create(&sim, phone)
-			if err != nil {
-				s.logger.Info(`[Phone info] Error while creating sim`)
-				s.logger.Error(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		report.Phone.SimSlots = len(report.SimInfo)
+		for i := range report.SimInfo {
+			slot := i
+			report.SimInfo[i].Slot = &slot
 		}
+		report.Phone.SdSlots = len(report.SdInfo)
 
-		s.storage.SdCard().RemovePhoneId(phone.Id)
-		for _, sd := range resp.SdInfo {
+		for i := range report.SdInfo {
+			sd := &report.SdInfo[i]
+			if helper.IsEmptySdSlot(*sd) {
+				continue
+			}
 			sd.SdManufacturerId, err = helper.ConvertManufacturerIdToCompanyName(sd.SdManufacturerId)
 			if err != nil {
 				s.logger.Info(`[Phone info] Error while translating sd info`)
@@ -213,35 +393,45 @@ func (s *Server) handlePhoneInfo() http.HandlerFunc {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
-			_, err := s.storage.SdCard().Create(&sd, phone)
-			if err != nil {
-				s.logger.Info(`[Phone info] Error while creating sd card`)
-				s.logger.Error(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
 		}
 
-		user, err := s.storage.User().SelectByCode(resp.AuthID)
-		if err != nil {
-			s.logger.Info(`[Phone info] Error while finding user by code`)
-			s.logger.Error(err)
+		result, err := s.storage.DeviceReport().Save(report)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[Phone info] Error while finding credential owner`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusNotFound))
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
-		user.Password = ""
-
-		err = s.storage.UserPhone().CreateRelation(user.Id, phone.Id)
+		if err == storage.ErrPhoneBound {
+			s.logger.Info(fmt.Sprintf(`[Phone info] Credential %d reported %s, which another device is enrolled as`, c.Id, report.Phone.ModelNumber))
+			http.Error(w, "Phone is bound to another credential", http.StatusForbidden)
+			return
+		}
 		if err != nil {
-			s.logger.Info(`[Phone info] Error while creating relation`)
+			s.logger.Info(`[Phone info] Error while saving device report`)
 			s.logger.Error(err)
 			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
+		s.recordSubnetMove(result.Phone.Id, result.PreviousIp, report.Ip)
+		if report.Battery != nil {
+			events, err := s.telemetry.Record(result.Phone, *report.Battery, time.Now())
+			for _, e := range events {
+				s.logger.Info(fmt.Sprintf(`[Phone info] %s`, e.Message))
+			}
+			if err != nil {
+				s.logger.Info(`[Phone info] Error while recording battery telemetry`)
+				s.logger.Error(err)
+			}
+		}
+		s.logger.Debug(fmt.Sprintf(`[Phone info] %s: created=%t updated=%t sims=%t sd=%t owner=%t`,
+			result.Phone.ModelNumber, result.PhoneCreated, result.PhoneUpdated,
+			result.SimsChanged, result.SdCardsChanged, result.OwnerChanged))
 
 		query := r.URL.Query().Get("user_info_needed")
 		if query == "true" {
 			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(user); err != nil {
+			if err := json.NewEncoder(w).Encode(result.User); err != nil {
 				s.logger.Info(`[Phone info] Error while encoding json`)
 				s.logger.Error(err)
 				w.WriteHeader(http.StatusInternalServerError)
@@ -255,7 +445,7 @@ func (s *Server) handlePhoneInfo() http.HandlerFunc {
 func (s *Server) handleDevices() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		type Response struct {
-			Phones   []models.Phone   `json:"phones"`
+			Phones   []device         `json:"phones"`
 			SimCards []models.SimInfo `json:"simCards"`
 			SdCards  []models.SdInfo  `json:"sdCards"`
 		}
@@ -281,9 +471,30 @@ func (s *Server) handleDevices() http.HandlerFunc {
 			http.Error(w, "Failed fetch sdcards", http.StatusInternalServerError)
 			return
 		}
+		heartbeats, err := s.storage.Heartbeat().SelectAll()
+		if err != nil {
+			s.logger.Info(`[Devices info] Error while fetching heartbeats`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "Failed fetch heartbeats", http.StatusInternalServerError)
+			return
+		}
+		locations, err := s.storage.Location().SelectAll()
+		if err != nil {
+			s.logger.Info(`[Devices info] Error while fetching locations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "Failed fetch locations", http.StatusInternalServerError)
+			return
+		}
+		reservations, err := s.storage.Reservation().SelectActive(time.Now())
+		if err != nil {
+			s.logger.Info(`[Devices info] Error while fetching reservations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "Failed fetch reservations", http.StatusInternalServerError)
+			return
+		}
 
 		response := Response{
-			Phones:   phones,
+			Phones:   s.devices(phones, heartbeats, locations, reservations),
 			SimCards: simCards,
 			SdCards:  sdCards,
 		}
@@ -293,37 +504,68 @@ func (s *Server) handleDevices() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleNotifications() http.HandlerFunc {
+func (s *Server) handlePhoneHistory() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		modelNumber := r.URL.Query().Get("model_number")
-		if modelNumber == "" {
-			s.logger.Info(`[Notifications] There was no parameter in request`)
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[PhoneHistory] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		notificationList, err := s.storage.Notification().SelectByModelTag(modelNumber)
-		if err != nil {
-			s.logger.Info(`[Notifications] Error while fetching notifications by tag`)
+		var from, to time.Time
+		if v := r.URL.Query().Get("from"); v != "" {
+			if from, err = time.Parse(time.RFC3339, v); err != nil {
+				s.logger.Info(`[PhoneHistory] Can't parse from`)
+				http.Error(w, "from must be RFC3339", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := r.URL.Query().Get("to"); v != "" {
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				s.logger.Info(`[PhoneHistory] Can't parse to`)
+				http.Error(w, "to must be RFC3339", http.StatusBadRequest)
+				return
+			}
+		}
+		field := r.URL.Query().Get("field")
+
+		if _, err := s.storage.Phone().SelectById(id); err != nil {
+			s.logger.Info(`[PhoneHistory] Error while fetching phone`)
 			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusNotFound))
-			w.WriteHeader(http.StatusNotFound)
+			http.Error(w, "Phone not found", http.StatusNotFound)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(notificationList); err != nil {
-			s.logger.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
+		history, err := s.storage.Phone().SelectHistory(id)
+		if err != nil {
+			s.logger.Info(`[PhoneHistory] Error while fetching history`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "Failed fetch history", http.StatusInternalServerError)
+			return
+		}
+
+		changes := []models.PhoneChange{}
+		for _, c := range history {
+			if field != "" && c.Field != field {
+				continue
+			}
+			if !from.IsZero() && c.ChangedAt.Before(from) {
+				continue
+			}
+			if !to.IsZero() && c.ChangedAt.After(to) {
+				continue
+			}
+			changes = append(changes, c)
 		}
 
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(changes)
 		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
 	}
 }
 
-// TODO: заменить на что-то адекватное
-var jwtKey = []byte("very_secret_key")
-
 func (s *Server) handleLogin() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var user models.User
@@ -351,42 +593,36 @@ func (s *Server) handleLogin() http.HandlerFunc {
 			return
 		}
 
-		expirationTime := time.Now().Add(24 * time.Hour)
-
-		claims := &models.Claims{
-			Role: existingUser.Role,
-			StandardClaims: jwt.StandardClaims{
-				Subject:   existingUser.Email,
-				ExpiresAt: expirationTime.Unix(),
-			},
+		session, refreshToken, err := s.createSession(existingUser, r)
+		if err != nil {
+			s.logger.Info(`[Login] Error while creating session`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "could not create session", http.StatusInternalServerError)
+			return
 		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-		tokenString, err := token.SignedString(jwtKey)
-		if err != nil {
+		if err := s.writeTokens(w, existingUser, session, refreshToken); err != nil {
 			s.logger.Info(`[Login] Error while generating jwt`)
 			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
 			http.Error(w, "could not generate token", http.StatusInternalServerError)
 			return
 		}
-
-		cookie := &http.Cookie{
-			Name:    "token",
-			Value:   tokenString,
-			Expires: expirationTime,
-			Path:    "/",
-		}
-		http.SetCookie(w, cookie)
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
 		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
 	}
 }
 
 func (s *Server) handleLogout() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie(refreshCookieName); err == nil {
+			session, err := s.storage.Session().SelectByTokenHash(auth.HashToken(c.Value))
+			if err == nil {
+				if err := s.storage.Session().Revoke(session.Id, time.Now()); err != nil {
+					s.logger.Info(`[Logout] Error while revoking session`)
+					s.logger.Error(err)
+				}
+			}
+		}
+
 		cookie := &http.Cookie{
 			Name:    "token",
 			Value:   "",
@@ -394,6 +630,13 @@ func (s *Server) handleLogout() http.HandlerFunc {
 			Path:    "/",
 		}
 		http.SetCookie(w, cookie)
+		http.SetCookie(w, &http.Cookie{
+			Name:     refreshCookieName,
+			Value:    "",
+			Expires:  time.Unix(0, 0),
+			Path:     "/api",
+			HttpOnly: true,
+		})
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -410,7 +653,7 @@ func (s *Server) handleRegister() http.HandlerFunc {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		user.Role = "user"
+		user.Role = auth.DefaultRole
 		_, err := s.storage.User().SelectByEmail(user.Email)
 		if err == nil {
 			s.logger.Info(`[Register] Error while checking for user existance`)
@@ -426,16 +669,6 @@ func (s *Server) handleRegister() http.HandlerFunc {
 			return
 		}
 
-		random := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-		var userCode int
-		for {
-			userCode = random.Intn(99999-10000) + 10000
-			if !s.storage.User().CheckCodeExists(userCode) {
-				break
-			}
-		}
-		user.Code = userCode
 		_, err = s.storage.User().Create(&user)
 		if err != nil {
 			s.logger.Info(`[Register] Error while creating user`)
@@ -481,35 +714,167 @@ func (s *Server) handleUser() http.HandlerFunc {
 
 func (s *Server) handleNewNotification() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			models.Notification
+			// SimSlot or PhoneNumber identify the receiving SIM. Phones with a
+			// single SIM may omit both.
+			SimSlot *int `json:"sim_slot"`
+		}
+		type Response struct {
+			NotificationId int  `json:"notification_id"`
+			Duplicate      bool `json:"duplicate"`
+		}
+
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
 			s.logger.Info(`[NewNotification] Error while reading body`)
-			s.logger.Error(err)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 		r.Body.Close()
 
-		var resp *models.Notification
-		err = json.Unmarshal(body, &resp)
+		var req Request
+		err = json.Unmarshal(body, &req)
 		if err != nil {
 			s.logger.Info(`[NewNotification] Error while decoding json`)
-			s.logger.Error(err)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c := deviceCredential(r)
+		if c.PhoneId == nil {
+			s.logger.Info(`[NewNotification] Device has not reported phone info yet`)
+			http.Error(w, "Device has not reported phone info yet", http.StatusConflict)
 			return
 		}
+		phone, err := s.storage.Phone().SelectById(*c.PhoneId)
+		if err != nil {
+			s.logger.Info(`[NewNotification] Error while fetching phone`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusConflict))
+			http.Error(w, "Device has not reported phone info yet", http.StatusConflict)
+			return
+		}
+		if req.ModelNumber != "" && req.ModelNumber != phone.ModelNumber {
+			s.logger.Info(fmt.Sprintf(`[NewNotification] Credential %d is bound to %s, not %s`, c.Id, phone.ModelNumber, req.ModelNumber))
+			http.Error(w, "Credential is bound to another phone", http.StatusForbidden)
+			return
+		}
+
+		sim, err := s.resolveSim(phone.Id, req.SimSlot, req.PhoneNumber)
+		if err != nil {
+			s.logger.Info(`[NewNotification] Error while resolving sim card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		n := req.Notification
+		n.ModelNumber = phone.ModelNumber
+		n.PhoneId = &phone.Id
+		n.PhoneNumber = ""
+		n.SimCardId = nil
+		if sim != nil {
+			n.SimCardId = &sim.Id
+			n.PhoneNumber = sim.PhoneNumber
+		} else {
+			// The message is kept even if the SIM is not known yet, it is
+			// still found by device.
+			s.logger.Info(fmt.Sprintf(`[NewNotification] Can't resolve sim card of %s`, phone.ModelNumber))
+		}
+
+		if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+			n.IdempotencyKey = key
+		}
+		// Without a timestamp the window can't tell a repost from a new
+		// notification with the same text.
+		if n.Timestamp != 0 {
+			n.Fingerprint = fingerprint(&n)
+		}
 
-		_, err = s.storage.Notification().Create(resp)
+		dup, err := s.storage.Notification().SelectDuplicate(&n, s.config.DedupWindow)
+		if err == nil {
+			s.logger.Info(fmt.Sprintf(`[NewNotification] Duplicate of notification %d`, dup.Id))
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(Response{NotificationId: dup.Id, Duplicate: true})
+			return
+		}
+		if err != storage.ErrRecordNotFound {
+			s.logger.Info(`[NewNotification] Error while looking for duplicates`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		s.otp.Parse(&n)
+		n.ReceivedAt = time.Now()
+
+		_, err = s.storage.Notification().Create(&n)
+		if err == storage.ErrRecordExists {
+			// A retry raced with the original request.
+			if dup, err := s.storage.Notification().SelectDuplicate(&n, s.config.DedupWindow); err == nil {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(Response{NotificationId: dup.Id, Duplicate: true})
+				return
+			}
+		}
 		if err != nil {
 			s.logger.Info(`[NewNotification] Error while creating notification`)
 			s.logger.Error(err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		s.hub.Publish(n)
+		if err := s.forwarder.Enqueue(&n); err != nil {
+			s.logger.Info(`[NewNotification] Error while queueing forwarding`)
+			s.logger.Error(err)
+		}
 
-		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Response{NotificationId: n.Id})
 		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
 	}
 }
 
+// resolveSim finds the SIM of the phone a notification was received on. It
+// returns nil if the SIM can't be determined.
+func (s *Server) resolveSim(phoneId int, slot *int, phoneNumber string) (*models.SimInfo, error) {
+	if phoneNumber != "" {
+		sim, err := s.storage.Sim().SelectByPhoneNumber(phoneNumber)
+		if err == storage.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sim.PhoneId == nil || *sim.PhoneId != phoneId {
+			return nil, nil
+		}
+		return sim, nil
+	}
+
+	sims, err := s.storage.Sim().SelectByPhoneId(phoneId)
+	if err != nil {
+		return nil, err
+	}
+
+	if slot == nil {
+		if len(sims) == 1 {
+			return &sims[0], nil
+		}
+		return nil, nil
+	}
+
+	for i := range sims {
+		if sims[i].Slot != nil && *sims[i].Slot == *slot {
+			return &sims[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (s *Server) handleUserPhoneList() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -528,14 +893,6 @@ func (s *Server) handleUserPhoneList() http.HandlerFunc {
 
 func (s *Server) handleUsers() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		role := r.Context().Value("role")
-		if role != "admin" {
-			s.logger.Info(`[Users] Current user have not permission`)
-			s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-
 		users, err := s.storage.User().SelectAll()
 		if err != nil {
 			s.logger.Info(`[Users] Error while selecting users`)
@@ -551,14 +908,6 @@ func (s *Server) handleUsers() http.HandlerFunc {
 
 func (s *Server) handleDeleteUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		role := r.Context().Value("role")
-		if role != "admin" {
-			s.logger.Info(`[DeleteUser] Current user have not permission`)
-			s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-
 		id, err := strconv.Atoi(r.URL.Query().Get("id"))
 		if err != nil {
 			s.logger.Info(`[DeleteUser] Can't parse user id`)
@@ -582,14 +931,6 @@ func (s *Server) handleDeleteUser() http.HandlerFunc {
 
 func (s *Server) handleDeletePhone() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		role := r.Context().Value("role")
-		if role != "admin" {
-			s.logger.Info(`[DeletePhone] Current user have not permission`)
-			s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-
 		id, err := strconv.Atoi(r.URL.Query().Get("id"))
 		if err != nil {
 			s.logger.Info(`[DeletePhone] Can't parse phone id`)
@@ -1,17 +1,61 @@
 package api
 
 import (
+	"encoding/json"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"server/internal/app/config"
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"strconv"
 	"testing"
 )
 
 func TestApi_HandleTest(t *testing.T) {
-	s := New(config.NewConfig())
+	s := New(config.NewConfig(), memstorage.New())
 	rec := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
 	s.handleTest().ServeHTTP(rec, req)
 	assert.Equal(t, rec.Body.String(), "Just test")
 }
+
+func TestApi_HandleDevices(t *testing.T) {
+	st := memstorage.New()
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS"}, p)
+
+	s := New(config.NewConfig(), st)
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/devices", nil)
+	s.handleDevices().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"model_number":"SM-G973F/DS"`)
+	assert.Contains(t, rec.Body.String(), `"phone_number":"79889484608"`)
+}
+
+func TestApi_HandlePhoneHistory(t *testing.T) {
+	st := memstorage.New()
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS", Firmware: "G9773FXXSGHWA1"})
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS", Firmware: "G9773FXXSHHWB2"})
+
+	s := New(config.NewConfig(), st)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/phones/1/history?field=firmware", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(p.Id)})
+	s.handlePhoneHistory().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var changes []models.PhoneChange
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&changes))
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "G9773FXXSHHWB2", changes[1].NewValue)
+
+	rec = httptest.NewRecorder()
+	req = mux.SetURLVars(req, map[string]string{"id": "42"})
+	s.handlePhoneHistory().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
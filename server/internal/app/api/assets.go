@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/audit"
+	"server/internal/app/labels"
+	"server/internal/app/models"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// findAsset resolves a scanned code, like an asset tag or a SIM phone
+// number, to an item and writes the error response if it can't. tag
+// prefixes the log messages.
+func (s *Server) findAsset(w http.ResponseWriter, tag, code string) (*audit.Inventory, *models.AuditItem) {
+	inventory, err := audit.Load(s.storage)
+	if err != nil {
+		s.logger.Info(fmt.Sprintf(`[%s] Error while fetching inventory`, tag))
+		s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, nil
+	}
+
+	item, ok := inventory.Match(code, "")
+	if !ok {
+		s.logger.Info(fmt.Sprintf(`[%s] Asset not found`, tag))
+		http.Error(w, "Asset not found", http.StatusNotFound)
+		return nil, nil
+	}
+
+	return inventory, &item
+}
+
+func (s *Server) label(item *models.AuditItem) labels.Label {
+	return labels.Label{
+		Tag:  item.AssetTag,
+		Name: item.Name,
+		Link: s.config.Labels.Link(item.AssetTag),
+	}
+}
+
+// handleAsset looks up what a scanned code stands for.
+func (s *Server) handleAsset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Response struct {
+			models.AuditItem
+			Link    string          `json:"link"`
+			Phone   *models.Phone   `json:"phone,omitempty"`
+			SimCard *models.SimInfo `json:"sim_card,omitempty"`
+			SdCard  *models.SdInfo  `json:"sd_card,omitempty"`
+		}
+
+		inventory, item := s.findAsset(w, "Asset", mux.Vars(r)["tag"])
+		if item == nil {
+			return
+		}
+
+		response := Response{AuditItem: *item, Link: s.config.Labels.Link(item.AssetTag)}
+		switch item.Kind {
+		case models.AssetPhone:
+			for i := range inventory.Phones {
+				if inventory.Phones[i].Id == item.Id {
+					response.Phone = &inventory.Phones[i]
+				}
+			}
+		case models.AssetSim:
+			for i := range inventory.Sims {
+				if inventory.Sims[i].Id == item.Id {
+					response.SimCard = &inventory.Sims[i]
+				}
+			}
+		case models.AssetSdCard:
+			for i := range inventory.SdCards {
+				if inventory.SdCards[i].Id == item.Id {
+					response.SdCard = &inventory.SdCards[i]
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// handleAssetLabel renders the label of an asset as a PNG image.
+func (s *Server) handleAssetLabel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, item := s.findAsset(w, "AssetLabel", mux.Vars(r)["tag"])
+		if item == nil {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := labels.WritePNG(&buf, s.label(item)); err != nil {
+			s.logger.Info(`[AssetLabel] Error while rendering label`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(buf.Bytes())
+	}
+}
+
+// handleLabels renders a PDF sheet with the labels of the assets named in
+// tags, comma separated, or else of every asset of kind, or of every asset.
+func (s *Server) handleLabels() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind := r.URL.Query().Get("kind")
+		if kind != "" && !models.IsAssetKind(kind) {
+			s.logger.Info(`[Labels] Unknown kind`)
+			http.Error(w, "kind must be one of phone, sim_card, sd_card", http.StatusBadRequest)
+			return
+		}
+
+		inventory, err := audit.Load(s.storage)
+		if err != nil {
+			s.logger.Info(`[Labels] Error while fetching inventory`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var items []models.AuditItem
+		if tags := r.URL.Query().Get("tags"); tags != "" {
+			for _, code := range strings.Split(tags, ",") {
+				item, ok := inventory.Match(code, kind)
+				if !ok {
+					s.logger.Info(`[Labels] Asset not found`)
+					http.Error(w, fmt.Sprintf("Asset %q not found", strings.TrimSpace(code)), http.StatusNotFound)
+					return
+				}
+				items = append(items, item)
+			}
+		} else {
+			items = inventory.Items(kind)
+		}
+
+		sheet := make([]labels.Label, 0, len(items))
+		for i := range items {
+			sheet = append(sheet, s.label(&items[i]))
+		}
+
+		var buf bytes.Buffer
+		if err := labels.WritePDF(&buf, sheet); err != nil {
+			s.logger.Info(`[Labels] Error while rendering labels`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+		w.Write(buf.Bytes())
+	}
+}
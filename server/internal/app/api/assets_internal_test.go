@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Assets(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "viewer@example.org", auth.RoleViewer)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS"}, p)
+	st.SdCard().Create(&models.SdInfo{SerialNo: "0x1a8ed52f"}, p)
+	s.config.Labels.BaseUrl = "https://lab.example.org/"
+
+	tk := login(t, s, "viewer@example.org")
+
+	lookup := func(code string) map[string]any {
+		t.Helper()
+		rec := serve(s, http.MethodGet, "/api/assets/"+code, "", tk.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var asset map[string]any
+		json.NewDecoder(rec.Body).Decode(&asset)
+		return asset
+	}
+	asset := lookup("ph-000001")
+	assert.Equal(t, "phone", asset["kind"])
+	assert.Equal(t, "PH-000001", asset["asset_tag"])
+	assert.Equal(t, "https://lab.example.org/assets/PH-000001", asset["link"])
+	assert.Equal(t, "SM-G973F/DS", asset["phone"].(map[string]any)["model_number"])
+	assert.Nil(t, asset["sim_card"])
+	// A SIM is also found by the number printed on it.
+	asset = lookup("79889484608")
+	assert.Equal(t, "SIM-000001", asset["asset_tag"])
+	assert.NotNil(t, asset["sim_card"])
+	assert.Equal(t, "SD-000001", lookup("0x1a8ed52f")["asset_tag"])
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/assets/PH-000042", "", tk.AccessToken).Code)
+
+	rec := serve(s, http.MethodGet, "/api/assets/SIM-000001/label", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(rec.Body.Bytes(), []byte("\x89PNG")))
+
+	rec = serve(s, http.MethodGet, "/api/labels?kind=sd_card", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "(SD-000001)")
+	assert.NotContains(t, rec.Body.String(), "(PH-000001)")
+	rec = serve(s, http.MethodGet, "/api/labels?tags=PH-000001,79889484608", "", tk.AccessToken)
+	assert.Contains(t, rec.Body.String(), "(PH-000001)")
+	assert.Contains(t, rec.Body.String(), "(SIM-000001)")
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/labels?tags=PH-000001,QA-9999", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodGet, "/api/labels?kind=charger", "", tk.AccessToken).Code)
+
+	rec = serve(s, http.MethodGet, "/api/phones/1", "", tk.AccessToken)
+	assert.Contains(t, rec.Body.String(), `"asset_tag":"PH-000001"`)
+}
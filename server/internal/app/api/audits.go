@@ -0,0 +1,306 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/audit"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleAudits() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		audits, err := s.storage.Audit().SelectAll()
+		if err != nil {
+			s.logger.Info(`[Audits] Error while fetching audits`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if audits == nil {
+			audits = []models.Audit{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(audits)
+	}
+}
+
+func (s *Server) handleStartAudit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			Name string `json:"name"`
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[StartAudit] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[StartAudit] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		a, err := s.storage.Audit().Create(&models.Audit{
+			Name:      strings.TrimSpace(req.Name),
+			StartedBy: user.Id,
+		})
+		if err != nil {
+			s.logger.Info(`[StartAudit] Error while creating audit`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(a)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+// auditById fetches the audit named by the path and writes the error
+// response if it can't. tag prefixes the log messages.
+func (s *Server) auditById(w http.ResponseWriter, r *http.Request, tag string) *models.Audit {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.logger.Info(fmt.Sprintf(`[%s] Can't parse audit id`, tag))
+		s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+		w.WriteHeader(http.StatusBadRequest)
+		return nil
+	}
+
+	a, err := s.storage.Audit().SelectById(id)
+	if err == storage.ErrRecordNotFound {
+		s.logger.Info(fmt.Sprintf(`[%s] Audit not found`, tag))
+		http.Error(w, "Audit not found", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		s.logger.Info(fmt.Sprintf(`[%s] Error while fetching audit`, tag))
+		s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil
+	}
+
+	return a
+}
+
+func (s *Server) handleAudit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Response struct {
+			*models.Audit
+			Scans []models.AuditScan `json:"scans"`
+		}
+
+		a := s.auditById(w, r, "Audit")
+		if a == nil {
+			return
+		}
+
+		scans, err := s.storage.Audit().SelectScans(a.Id)
+		if err != nil {
+			s.logger.Info(`[Audit] Error while fetching scans`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if scans == nil {
+			scans = []models.AuditScan{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Response{Audit: a, Scans: scans})
+	}
+}
+
+// handleAuditScan marks the item behind a scanned asset tag, phone number or
+// serial number as found. Codes that match nothing are kept too: they are
+// the unexpected items of the report.
+func (s *Server) handleAuditScan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			Code    string `json:"code"`
+			Kind    string `json:"kind"`
+			SlotId  *int   `json:"slot_id"`
+			PhoneId *int   `json:"phone_id"`
+		}
+		type Response struct {
+			models.AuditScan
+			Item *models.AuditItem `json:"item"`
+		}
+
+		a := s.auditById(w, r, "AuditScan")
+		if a == nil {
+			return
+		}
+		if a.FinishedAt != nil {
+			s.logger.Info(`[AuditScan] Audit is finished`)
+			http.Error(w, "Audit is finished", http.StatusConflict)
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[AuditScan] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		code := strings.TrimSpace(req.Code)
+		if code == "" {
+			s.logger.Info(`[AuditScan] Code is missing`)
+			http.Error(w, "code is required", http.StatusBadRequest)
+			return
+		}
+		if req.Kind != "" && !models.IsAssetKind(req.Kind) {
+			s.logger.Info(`[AuditScan] Unknown kind`)
+			http.Error(w, "kind must be one of phone, sim_card, sd_card", http.StatusBadRequest)
+			return
+		}
+
+		if req.SlotId != nil {
+			slot, err := s.storage.Location().SelectById(*req.SlotId)
+			if err != nil && err != storage.ErrRecordNotFound {
+				s.logger.Info(`[AuditScan] Error while fetching slot`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if slot == nil || slot.Kind != models.LocationSlot {
+				s.logger.Info(`[AuditScan] Not a slot`)
+				http.Error(w, "slot_id must be a slot location", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.PhoneId != nil {
+			_, err := s.storage.Phone().SelectById(*req.PhoneId)
+			if err == storage.ErrRecordNotFound {
+				s.logger.Info(`[AuditScan] Phone not found`)
+				http.Error(w, "phone_id is not a known phone", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				s.logger.Info(`[AuditScan] Error while fetching phone`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[AuditScan] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		inventory, err := audit.Load(s.storage)
+		if err != nil {
+			s.logger.Info(`[AuditScan] Error while fetching inventory`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		scan := &models.AuditScan{
+			AuditId:   a.Id,
+			Code:      code,
+			SlotId:    req.SlotId,
+			PhoneId:   req.PhoneId,
+			ScannedBy: user.Id,
+		}
+		item, ok := inventory.Match(code, req.Kind)
+		if ok {
+			scan.Kind = item.Kind
+			scan.ItemId = &item.Id
+		}
+
+		scan, err = s.storage.Audit().CreateScan(scan)
+		if err != nil {
+			s.logger.Info(`[AuditScan] Error while saving scan`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := Response{AuditScan: *scan}
+		if ok {
+			response.Item = &item
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+func (s *Server) handleFinishAudit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := s.auditById(w, r, "FinishAudit")
+		if a == nil {
+			return
+		}
+
+		now := time.Now()
+		err := s.storage.Audit().Finish(a.Id, now)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[FinishAudit] Audit is finished`)
+			http.Error(w, "Audit is finished", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[FinishAudit] Error while finishing audit`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		a.FinishedAt = &now
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(a)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+// handleAuditReport reconciles the scans of an audit with the current
+// records. It works on unfinished audits too, to show the progress.
+func (s *Server) handleAuditReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := s.auditById(w, r, "AuditReport")
+		if a == nil {
+			return
+		}
+
+		scans, err := s.storage.Audit().SelectScans(a.Id)
+		if err != nil {
+			s.logger.Info(`[AuditReport] Error while fetching scans`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		inventory, err := audit.Load(s.storage)
+		if err != nil {
+			s.logger.Info(`[AuditReport] Error while fetching inventory`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(inventory.Reconcile(*a, scans))
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Audit(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "viewer@example.org", auth.RoleViewer)
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	p.InventoryTag = "QA-0001"
+	st.Phone().Update(p)
+	other, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS"}, p)
+
+	viewer := login(t, s, "viewer@example.org")
+	manager := login(t, s, "manager@example.org")
+
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost, "/api/audits", `{"name":"Q3"}`, viewer.AccessToken).Code)
+	rec := serve(s, http.MethodPost, "/api/audits", `{"name":"Q3"}`, manager.AccessToken)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var a models.Audit
+	json.NewDecoder(rec.Body).Decode(&a)
+	assert.Equal(t, "Q3", a.Name)
+
+	scan := func(body string) (int, map[string]any) {
+		t.Helper()
+		rec := serve(s, http.MethodPost, fmt.Sprintf("/api/audits/%d/scans", a.Id), body, manager.AccessToken)
+		var response map[string]any
+		json.NewDecoder(rec.Body).Decode(&response)
+		return rec.Code, response
+	}
+	code, response := scan(`{"code":"qa-0001"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "phone", response["kind"])
+	assert.Equal(t, "SM-G973F/DS", response["item"].(map[string]any)["name"])
+	// The SIM turned up in the other phone.
+	code, _ = scan(fmt.Sprintf(`{"code":"79889484608","phone_id":%d}`, other.Id))
+	assert.Equal(t, http.StatusCreated, code)
+	code, response = scan(`{"code":"QA-9999"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Nil(t, response["item"])
+	code, _ = scan(`{"code":" "}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = scan(`{"code":"QA-0001","kind":"charger"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = scan(`{"code":"QA-0001","slot_id":42}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	target := fmt.Sprintf("/api/audits/%d", a.Id)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPost, target+"/finish", "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodPost, target+"/finish", "", manager.AccessToken).Code)
+	code, _ = scan(`{"code":"QA-0001"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/audits/42/report", "", viewer.AccessToken).Code)
+
+	rec = serve(s, http.MethodGet, target+"/report", "", viewer.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var report models.AuditReport
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	assert.NotNil(t, report.FinishedAt)
+	assert.Equal(t, 3, report.Expected)
+	assert.Len(t, report.Found, 2)
+	if assert.Len(t, report.Missing, 1) {
+		assert.Equal(t, other.Id, report.Missing[0].Id)
+	}
+	if assert.Len(t, report.Unexpected, 1) {
+		assert.Equal(t, "QA-9999", report.Unexpected[0].Code)
+	}
+	if assert.Len(t, report.Relocated, 1) {
+		assert.Equal(t, models.AssetSim, report.Relocated[0].Kind)
+		assert.Equal(t, p.Id, *report.Relocated[0].ExpectedPhoneId)
+		assert.Equal(t, other.Id, *report.Relocated[0].FoundPhoneId)
+	}
+
+	rec = serve(s, http.MethodGet, target, "", viewer.AccessToken)
+	assert.Contains(t, rec.Body.String(), `"scans":[{`)
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// deviceCredential returns the credential checked by IsEnrolledDevice.
+func deviceCredential(r *http.Request) *models.DeviceCredential {
+	c, _ := r.Context().Value("device_credential").(*models.DeviceCredential)
+	return c
+}
+
+func (s *Server) handleCreateEnrollmentToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Response struct {
+			EnrollmentToken string    `json:"enrollment_token"`
+			ExpiresAt       time.Time `json:"expires_at"`
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[EnrollmentToken] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		token, hash, err := auth.NewEnrollmentToken()
+		if err != nil {
+			s.logger.Info(`[EnrollmentToken] Error while generating token`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		t, err := s.storage.Enrollment().CreateToken(&models.EnrollmentToken{
+			UserId:    user.Id,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(s.config.Auth.EnrollmentTokenTtl),
+		})
+		if err != nil {
+			s.logger.Info(`[EnrollmentToken] Error while saving token`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Response{EnrollmentToken: token, ExpiresAt: t.ExpiresAt})
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+func (s *Server) handleEnroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			EnrollmentToken string `json:"enrollment_token"`
+		}
+		type Response struct {
+			DeviceCredentialId int    `json:"device_credential_id"`
+			DeviceToken        string `json:"device_token"`
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[Enroll] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		token, hash, err := auth.NewDeviceToken()
+		if err != nil {
+			s.logger.Info(`[Enroll] Error while generating device token`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		c, err := s.storage.Enrollment().Enroll(auth.HashToken(req.EnrollmentToken),
+			&models.DeviceCredential{TokenHash: hash}, time.Now())
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[Enroll] Invalid, used or expired enrollment token`)
+			http.Error(w, "Invalid enrollment token", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[Enroll] Error while enrolling device`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Response{DeviceCredentialId: c.Id, DeviceToken: token})
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+func (s *Server) handleDeviceCredentials() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[DeviceCredentials] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		userId := user.Id
+		if v := r.URL.Query().Get("user_id"); v != "" {
+			if !auth.HasPermission(user.Role, auth.PermDevicesManage) {
+				s.logger.Info(`[DeviceCredentials] Current user have not permission`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if userId, err = strconv.Atoi(v); err != nil {
+				s.logger.Info(`[DeviceCredentials] Can't parse user id`)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		credentials, err := s.storage.Enrollment().SelectCredentialsByUserId(userId)
+		if err != nil {
+			s.logger.Info(`[DeviceCredentials] Error while fetching credentials`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if credentials == nil {
+			credentials = []models.DeviceCredential{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(credentials)
+	}
+}
+
+func (s *Server) handleRevokeDeviceCredential() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[RevokeDeviceCredential] Can't parse credential id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[RevokeDeviceCredential] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		c, err := s.storage.Enrollment().SelectCredentialById(id)
+		if err != nil {
+			s.logger.Info(`[RevokeDeviceCredential] Error while fetching credential`)
+			http.Error(w, "Device credential not found", http.StatusNotFound)
+			return
+		}
+
+		if c.UserId != user.Id && !auth.HasPermission(user.Role, auth.PermDevicesManage) {
+			s.logger.Info(`[RevokeDeviceCredential] Current user have not permission`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if err := s.storage.Enrollment().RevokeCredential(id, time.Now()); err != nil {
+			s.logger.Info(`[RevokeDeviceCredential] Error while revoking credential`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func enroll(t *testing.T, s *Server, accessToken string) (int, string) {
+	t.Helper()
+
+	rec := serve(s, http.MethodPost, "/api/enrollment_tokens", "", accessToken)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("enrollment token failed: %d %s", rec.Code, rec.Body.String())
+	}
+	var et struct {
+		EnrollmentToken string `json:"enrollment_token"`
+	}
+	json.NewDecoder(rec.Body).Decode(&et)
+
+	rec = serve(s, http.MethodPost, "/api/enroll", `{"enrollment_token":"`+et.EnrollmentToken+`"}`, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("enroll failed: %d %s", rec.Code, rec.Body.String())
+	}
+	var dc struct {
+		DeviceCredentialId int    `json:"device_credential_id"`
+		DeviceToken        string `json:"device_token"`
+	}
+	json.NewDecoder(rec.Body).Decode(&dc)
+
+	// A token can only be redeemed once.
+	rec = serve(s, http.MethodPost, "/api/enroll", `{"enrollment_token":"`+et.EnrollmentToken+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	return dc.DeviceCredentialId, dc.DeviceToken
+}
+
+func serveDevice(s *Server, target, body, deviceToken string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if deviceToken != "" {
+		req.Header.Set("Authorization", "Device "+deviceToken)
+	}
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestApi_Enrollment(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	createTestUser(t, st, "viewer@example.org", auth.RoleViewer)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+
+	tk := login(t, s, "user@example.org")
+	viewer := login(t, s, "viewer@example.org")
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost, "/api/enrollment_tokens", "", viewer.AccessToken).Code)
+
+	id, deviceToken := enroll(t, s, tk.AccessToken)
+	notification := `{"model_number":"SM-G973F/DS","notification_source":"sms","sender":"900","body":"Code: 1234","timestamp":1681665083}`
+
+	assert.Equal(t, http.StatusUnauthorized, serveDevice(s, "/api/new_notification", notification, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveDevice(s, "/api/new_notification", notification, "nope").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveDevice(s, "/api/phone_info", `{}`, "").Code)
+	// JWTs are not device credentials.
+	assert.Equal(t, http.StatusUnauthorized, serveDevice(s, "/api/new_notification", notification, tk.AccessToken).Code)
+	// The credential is bound to a phone by its first phone_info report.
+	assert.Equal(t, http.StatusConflict, serveDevice(s, "/api/new_notification", notification, deviceToken).Code)
+	assert.NoError(t, st.Enrollment().BindPhone(id, p.Id))
+	assert.Equal(t, http.StatusOK, serveDevice(s, "/api/new_notification", notification, deviceToken).Code)
+
+	// Once bound, the credential can't report for another phone.
+	rec := serveDevice(s, "/api/phone_info", `{"phone_info":{"model_number":"SM-A525F"}}`, deviceToken)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serve(s, http.MethodGet, "/api/device_credentials", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var credentials []models.DeviceCredential
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&credentials))
+	assert.Len(t, credentials, 1)
+	assert.Equal(t, p.Id, *credentials[0].PhoneId)
+
+	target := "/api/device_credentials/" + strconv.Itoa(id) + "/revoke"
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost, target, "", viewer.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPost, target, "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveDevice(s, "/api/new_notification", notification, deviceToken).Code)
+}
+
+func TestApi_PhoneInfoBoundPhone(t *testing.T) {
+	// Reports are enriched from the data directory of the server root.
+	wd, _ := os.Getwd()
+	if err := os.Chdir("../../.."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	s, st := testServer(t)
+	owner := createTestUser(t, st, "owner@example.org", auth.RoleTester)
+	createTestUser(t, st, "other@example.org", auth.RoleTester)
+
+	_, deviceToken := enroll(t, s, login(t, s, "owner@example.org").AccessToken)
+	report := `{"phone_info":{"model_number":"SM-G973F/DS"},"sim_info":[{"phone_number":"79889484608","operator":"MTS"}]}`
+	assert.Equal(t, http.StatusOK, serveDevice(s, "/api/phone_info", report, deviceToken).Code)
+
+	// A new credential can't report as a phone another device is enrolled as.
+	otherId, otherToken := enroll(t, s, login(t, s, "other@example.org").AccessToken)
+	assert.Equal(t, http.StatusForbidden, serveDevice(s, "/api/phone_info", `{"phone_info":{"model_number":"SM-G973F/DS"}}`, otherToken).Code)
+
+	stored, _ := st.Phone().SelectByModelNumber("SM-G973F/DS")
+	o, _ := st.UserPhone().SelectOwner(stored.Id)
+	assert.Equal(t, owner.Id, o.Id)
+	sims, _ := st.Sim().SelectAll()
+	assert.Equal(t, stored.Id, *sims[0].PhoneId)
+	c, _ := st.Enrollment().SelectCredentialById(otherId)
+	assert.Nil(t, c.PhoneId)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/models"
+	"strconv"
+)
+
+const (
+	defaultEventLimit = 100
+	maxEventLimit     = 1000
+)
+
+func (s *Server) handleEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		filter := models.EventFilter{Type: q.Get("type"), Limit: defaultEventLimit}
+
+		if v := q.Get("phone_id"); v != "" {
+			phoneId, err := strconv.Atoi(v)
+			if err != nil {
+				s.logger.Info(`[Events] Can't parse phone id`)
+				http.Error(w, "phone_id must be a number", http.StatusBadRequest)
+				return
+			}
+			filter.PhoneId = &phoneId
+		}
+		if v := q.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit < 1 || limit > maxEventLimit {
+				s.logger.Info(`[Events] Invalid limit`)
+				http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxEventLimit), http.StatusBadRequest)
+				return
+			}
+			filter.Limit = limit
+		}
+
+		events, err := s.storage.Event().Select(filter)
+		if err != nil {
+			s.logger.Info(`[Events] Error while fetching events`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if events == nil {
+			events = []models.Event{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(events)
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/forwarding"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ruleRequest is the body of rule create and update requests. Unset Enabled
+// means enabled, unset Secret keeps the current secret.
+type ruleRequest struct {
+	models.ForwardingRule
+	Enabled *bool   `json:"enabled"`
+	Secret  *string `json:"secret"`
+}
+
+func (req *ruleRequest) apply(rule *models.ForwardingRule) {
+	id, createdAt, secret := rule.Id, rule.CreatedAt, rule.Secret
+
+	*rule = req.ForwardingRule
+	rule.Id, rule.CreatedAt, rule.Secret = id, createdAt, secret
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	if req.Secret != nil {
+		rule.Secret = *req.Secret
+	}
+	if rule.Format == "" {
+		rule.Format = models.FormatGeneric
+	}
+}
+
+func (s *Server) handleForwardingRules() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := s.storage.Forwarding().SelectRules()
+		if err != nil {
+			s.logger.Info(`[ForwardingRules] Error while fetching rules`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if rules == nil {
+			rules = []models.ForwardingRule{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rules)
+	}
+}
+
+func (s *Server) handleCreateForwardingRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ruleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[CreateForwardingRule] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var rule models.ForwardingRule
+		req.apply(&rule)
+		if err := forwarding.Validate(&rule); err != nil {
+			s.logger.Info(`[CreateForwardingRule] Invalid rule`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := s.storage.Forwarding().CreateRule(&rule); err != nil {
+			s.logger.Info(`[CreateForwardingRule] Error while creating rule`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rule)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+func (s *Server) handleUpdateForwardingRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[UpdateForwardingRule] Can't parse rule id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req ruleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[UpdateForwardingRule] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rule, err := s.storage.Forwarding().SelectRuleById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[UpdateForwardingRule] Rule not found`)
+			http.Error(w, "Rule not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[UpdateForwardingRule] Error while fetching rule`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		req.apply(rule)
+		if err := forwarding.Validate(rule); err != nil {
+			s.logger.Info(`[UpdateForwardingRule] Invalid rule`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := s.storage.Forwarding().UpdateRule(rule); err != nil {
+			s.logger.Info(`[UpdateForwardingRule] Error while updating rule`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rule)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+func (s *Server) handleDeleteForwardingRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[DeleteForwardingRule] Can't parse rule id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := s.storage.Forwarding().DeleteRule(id); err != nil {
+			s.logger.Info(`[DeleteForwardingRule] Error while deleting rule`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+// handleDeliveries is the delivery log, newest first.
+func (s *Server) handleDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := models.DeliveryFilter{
+			Status: r.URL.Query().Get("status"),
+			Limit:  100,
+		}
+
+		var err error
+		if v := r.URL.Query().Get("rule_id"); v != "" {
+			if filter.RuleId, err = strconv.Atoi(v); err != nil {
+				s.logger.Info(`[Deliveries] Can't parse rule id`)
+				http.Error(w, "rule_id must be a number", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := r.URL.Query().Get("limit"); v != "" {
+			if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 1 || filter.Limit > 1000 {
+				s.logger.Info(`[Deliveries] Can't parse limit`)
+				http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+				return
+			}
+		}
+
+		deliveries, err := s.storage.Forwarding().SelectDeliveries(filter)
+		if err != nil {
+			s.logger.Info(`[Deliveries] Error while fetching deliveries`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if deliveries == nil {
+			deliveries = []models.Delivery{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deliveries)
+	}
+}
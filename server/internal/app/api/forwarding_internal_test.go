@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Forwarding(t *testing.T) {
+	var received []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	defer hook.Close()
+
+	s, st := testServer(t)
+	createTestUser(t, st, "tester@example.org", auth.RoleTester)
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+
+	tester := login(t, s, "tester@example.org")
+	manager := login(t, s, "manager@example.org")
+
+	rule := fmt.Sprintf(`{"name":"bank","sender":"Bank","url":%q,"format":"mattermost","secret":"s3cret"}`, hook.URL)
+	rec := serve(s, http.MethodPost, "/api/forwarding/rules", rule, tester.AccessToken)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serve(s, http.MethodPost, "/api/forwarding/rules", `{"name":"bad","url":"ftp://x"}`, manager.AccessToken)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(s, http.MethodPost, "/api/forwarding/rules", rule, manager.AccessToken)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var created map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&created)
+	assert.Equal(t, true, created["enabled"])
+	assert.NotContains(t, created, "secret")
+
+	id, deviceToken := enroll(t, s, tester.AccessToken)
+	st.Enrollment().BindPhone(id, p.Id)
+	serveDevice(s, "/api/new_notification", `{"sender":"Bank","body":"Code: 1111"}`, deviceToken)
+	serveDevice(s, "/api/new_notification", `{"sender":"Shop","body":"Sale"}`, deviceToken)
+	assert.NoError(t, s.forwarder.Process())
+
+	assert.Len(t, received, 1)
+	assert.Contains(t, received[0], "Code: 1111")
+
+	rec = serve(s, http.MethodGet, "/api/forwarding/deliveries?status=delivered", "", manager.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var deliveries []models.Delivery
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&deliveries))
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, http.StatusOK, deliveries[0].LastStatusCode)
+
+	rule = fmt.Sprintf(`{"name":"bank","sender":"Bank","url":%q,"enabled":false}`, hook.URL)
+	rec = serve(s, http.MethodPut, "/api/forwarding/rules/1", rule, manager.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	saved, _ := st.Forwarding().SelectRuleById(1)
+	assert.False(t, saved.Enabled)
+	assert.Equal(t, "s3cret", saved.Secret)
+
+	rec = serve(s, http.MethodPut, "/api/forwarding/rules/42", rule, manager.AccessToken)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
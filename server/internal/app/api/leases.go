@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"server/internal/app/lease"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleAcquireLease() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			models.LeaseFilter
+			Ttl string `json:"ttl"`
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[AcquireLease] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ttl, err := parseTtl(req.Ttl)
+		if err != nil {
+			s.logger.Info(`[AcquireLease] Invalid ttl`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[AcquireLease] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		l, err := s.leases.Acquire(user.Id, req.LeaseFilter, ttl)
+		if err != nil {
+			s.logger.Info(`[AcquireLease] Error while acquiring lease`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "Could not acquire lease", http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusCreated
+		if l.Status == models.LeaseQueued {
+			status = http.StatusAccepted
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(l)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, status))
+	}
+}
+
+func (s *Server) handleLease() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, err := s.leases.Get(mux.Vars(r)["token"])
+		s.writeLease(w, r, "[Lease]", l, err)
+	}
+}
+
+func (s *Server) handleLeaseHeartbeat() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Ttl string `json:"ttl"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			s.logger.Info(`[LeaseHeartbeat] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ttl, err := parseTtl(req.Ttl)
+		if err != nil {
+			s.logger.Info(`[LeaseHeartbeat] Invalid ttl`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		l, err := s.leases.Heartbeat(mux.Vars(r)["token"], ttl)
+		s.writeLease(w, r, "[LeaseHeartbeat]", l, err)
+	}
+}
+
+func (s *Server) handleReleaseLease() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, err := s.leases.Release(mux.Vars(r)["token"])
+		s.writeLease(w, r, "[ReleaseLease]", l, err)
+	}
+}
+
+func (s *Server) writeLease(w http.ResponseWriter, r *http.Request, tag string, l *models.Lease, err error) {
+	switch err {
+	case nil:
+	case storage.ErrRecordNotFound:
+		s.logger.Info(tag + ` Lease not found`)
+		http.Error(w, "Lease not found", http.StatusNotFound)
+		return
+	case lease.ErrLeaseClosed:
+		s.logger.Info(tag + ` Lease is closed`)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(l)
+		return
+	default:
+		s.logger.Info(tag + ` Error while processing lease`)
+		s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l)
+	s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+}
+
+// parseTtl accepts an empty string, meaning the configured default, or a
+// positive Go duration.
+func parseTtl(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(v)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("ttl must be a positive value like \"10m\"")
+	}
+
+	return ttl, nil
+}
@@ -0,0 +1,429 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// locationPaths names the locations every slot is nested in, by slot id.
+func locationPaths(locations []models.Location) map[int]*models.LocationPath {
+	byId := make(map[int]*models.Location, len(locations))
+	for i := range locations {
+		byId[locations[i].Id] = &locations[i]
+	}
+
+	paths := make(map[int]*models.LocationPath)
+	for _, l := range locations {
+		if l.Kind != models.LocationSlot {
+			continue
+		}
+
+		path := &models.LocationPath{SlotId: l.Id}
+		for at := &l; at != nil; at = parentOf(byId, at) {
+			switch at.Kind {
+			case models.LocationSite:
+				path.Site = at.Name
+			case models.LocationRoom:
+				path.Room = at.Name
+			case models.LocationCabinet:
+				path.Cabinet = at.Name
+			case models.LocationSlot:
+				path.Slot = at.Name
+			}
+		}
+		paths[l.Id] = path
+	}
+
+	return paths
+}
+
+func parentOf(byId map[int]*models.Location, l *models.Location) *models.Location {
+	if l.ParentId == nil {
+		return nil
+	}
+
+	return byId[*l.ParentId]
+}
+
+// within tells whether the location is id or nested in it.
+func within(byId map[int]*models.Location, l *models.Location, id int) bool {
+	for ; l != nil; l = parentOf(byId, l) {
+		if l.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) handleLocations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locations, err := s.storage.Location().SelectAll()
+		if err != nil {
+			s.logger.Info(`[Locations] Error while fetching locations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if locations == nil {
+			locations = []models.Location{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(locations)
+	}
+}
+
+// handleLocation returns a location with its children and the phones kept
+// anywhere inside it.
+func (s *Server) handleLocation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Response struct {
+			models.Location
+			Children []models.Location `json:"children"`
+			Phones   []models.Phone    `json:"phones"`
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[Location] Can't parse location id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		locations, err := s.storage.Location().SelectAll()
+		if err != nil {
+			s.logger.Info(`[Location] Error while fetching locations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		phones, err := s.storage.Phone().SelectAll()
+		if err != nil {
+			s.logger.Info(`[Location] Error while fetching phones`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		byId := make(map[int]*models.Location, len(locations))
+		for i := range locations {
+			byId[locations[i].Id] = &locations[i]
+		}
+		l, ok := byId[id]
+		if !ok {
+			s.logger.Info(`[Location] Location not found`)
+			http.Error(w, "Location not found", http.StatusNotFound)
+			return
+		}
+
+		response := Response{Location: *l, Children: []models.Location{}, Phones: []models.Phone{}}
+		for _, child := range locations {
+			if child.ParentId != nil && *child.ParentId == id {
+				response.Children = append(response.Children, child)
+			}
+		}
+		for _, p := range phones {
+			if p.SlotId != nil && within(byId, byId[*p.SlotId], id) {
+				response.Phones = append(response.Phones, p)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (s *Server) handleCreateLocation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var l models.Location
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			s.logger.Info(`[CreateLocation] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l.Name = strings.TrimSpace(l.Name)
+		if l.Name == "" {
+			s.logger.Info(`[CreateLocation] Name is missing`)
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		parentKind, ok := models.ParentKind(l.Kind)
+		if !ok {
+			s.logger.Info(`[CreateLocation] Unknown kind`)
+			http.Error(w, "kind must be one of site, room, cabinet, slot", http.StatusBadRequest)
+			return
+		}
+
+		if parentKind == "" && l.ParentId != nil {
+			s.logger.Info(`[CreateLocation] Site with a parent`)
+			http.Error(w, "A site can't have a parent", http.StatusBadRequest)
+			return
+		}
+		if parentKind != "" {
+			var parent *models.Location
+			var err error
+			if l.ParentId != nil {
+				parent, err = s.storage.Location().SelectById(*l.ParentId)
+			}
+			if err != nil && err != storage.ErrRecordNotFound {
+				s.logger.Info(`[CreateLocation] Error while fetching parent`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if parent == nil || parent.Kind != parentKind {
+				s.logger.Info(`[CreateLocation] Invalid parent`)
+				http.Error(w, fmt.Sprintf("A %s must be in a %s", l.Kind, parentKind), http.StatusBadRequest)
+				return
+			}
+		}
+
+		created, err := s.storage.Location().Create(&l)
+		if err == storage.ErrRecordExists {
+			s.logger.Info(`[CreateLocation] Location already exists`)
+			http.Error(w, "A location with this name already exists here", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[CreateLocation] Error while creating location`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+// handleUpdateLocation renames a location. Locations can't be moved; phones
+// are moved between slots instead.
+func (s *Server) handleUpdateLocation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			Name string `json:"name"`
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[UpdateLocation] Can't parse location id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[UpdateLocation] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			s.logger.Info(`[UpdateLocation] Name is missing`)
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+
+		l, err := s.storage.Location().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[UpdateLocation] Location not found`)
+			http.Error(w, "Location not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[UpdateLocation] Error while fetching location`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		l.Name = name
+		err = s.storage.Location().Update(l)
+		if err == storage.ErrRecordExists {
+			s.logger.Info(`[UpdateLocation] Location already exists`)
+			http.Error(w, "A location with this name already exists here", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[UpdateLocation] Error while updating location`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(l)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+// handleDeleteLocation deletes an empty location: one without children and,
+// for slots, without a phone.
+func (s *Server) handleDeleteLocation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[DeleteLocation] Can't parse location id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		locations, err := s.storage.Location().SelectAll()
+		if err != nil {
+			s.logger.Info(`[DeleteLocation] Error while fetching locations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		phones, err := s.storage.Phone().SelectAll()
+		if err != nil {
+			s.logger.Info(`[DeleteLocation] Error while fetching phones`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		found := false
+		for _, l := range locations {
+			if l.Id == id {
+				found = true
+			}
+			if l.ParentId != nil && *l.ParentId == id {
+				s.logger.Info(`[DeleteLocation] Location has children`)
+				http.Error(w, "Location is not empty", http.StatusConflict)
+				return
+			}
+		}
+		if !found {
+			s.logger.Info(`[DeleteLocation] Location not found`)
+			http.Error(w, "Location not found", http.StatusNotFound)
+			return
+		}
+		for _, p := range phones {
+			if p.SlotId != nil && *p.SlotId == id {
+				s.logger.Info(`[DeleteLocation] Slot holds a phone`)
+				http.Error(w, "Location is not empty", http.StatusConflict)
+				return
+			}
+		}
+
+		if err := s.storage.Location().Delete(id); err != nil {
+			s.logger.Info(`[DeleteLocation] Error while deleting location`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+// handleAssignSlot puts a phone into a slot, or takes it out with a null
+// slot_id, and records the move in the phone history.
+func (s *Server) handleAssignSlot() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			SlotId *int `json:"slot_id"`
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[AssignSlot] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[AssignSlot] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		phone, err := s.storage.Phone().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[AssignSlot] Phone not found`)
+			http.Error(w, "Phone not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[AssignSlot] Error while fetching phone`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		locations, err := s.storage.Location().SelectAll()
+		if err != nil {
+			s.logger.Info(`[AssignSlot] Error while fetching locations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		paths := locationPaths(locations)
+		if req.SlotId != nil && paths[*req.SlotId] == nil {
+			s.logger.Info(`[AssignSlot] Not a slot`)
+			http.Error(w, "slot_id must be a slot location", http.StatusBadRequest)
+			return
+		}
+
+		err = s.storage.Phone().AssignSlot(id, req.SlotId)
+		if err == storage.ErrRecordExists {
+			s.logger.Info(`[AssignSlot] Slot is taken`)
+			http.Error(w, "Another phone is in this slot", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[AssignSlot] Error while assigning slot`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var oldPath, newPath string
+		if phone.SlotId != nil && paths[*phone.SlotId] != nil {
+			oldPath = paths[*phone.SlotId].String()
+		}
+		if req.SlotId != nil {
+			newPath = paths[*req.SlotId].String()
+		}
+		if oldPath != newPath {
+			s.recordMove(phone.Id, models.PhoneChangeSlot, oldPath, newPath)
+		}
+		phone.SlotId = req.SlotId
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(phone)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+// recordMove adds a phone moved entry to the history. The move itself has
+// been saved already, so a failure is only logged.
+func (s *Server) recordMove(phoneId int, field, oldValue, newValue string) {
+	err := s.storage.Phone().CreateChange(&models.PhoneChange{
+		PhoneId:  phoneId,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+	})
+	if err != nil {
+		s.logger.Info(`[Phone moved] Error while recording history`)
+		s.logger.Error(err)
+	}
+}
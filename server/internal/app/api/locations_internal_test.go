@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Locations(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "viewer@example.org", auth.RoleViewer)
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	other, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+
+	viewer := login(t, s, "viewer@example.org")
+	manager := login(t, s, "manager@example.org")
+
+	create := func(body string) int {
+		t.Helper()
+		rec := serve(s, http.MethodPost, "/api/locations", body, manager.AccessToken)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var l models.Location
+		json.NewDecoder(rec.Body).Decode(&l)
+		return l.Id
+	}
+	site := create(`{"kind":"site","name":"Moscow"}`)
+	room := create(fmt.Sprintf(`{"kind":"room","name":"Lab 3","parent_id":%d}`, site))
+	cabinet := create(fmt.Sprintf(`{"kind":"cabinet","name":"Rack B","parent_id":%d}`, room))
+	slot1 := create(fmt.Sprintf(`{"kind":"slot","name":"1","parent_id":%d}`, cabinet))
+	slot2 := create(fmt.Sprintf(`{"kind":"slot","name":"2","parent_id":%d}`, cabinet))
+
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost, "/api/locations", `{"kind":"site","name":"Kazan"}`, viewer.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodPost, "/api/locations", `{"kind":"shelf","name":"A"}`, manager.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodPost, "/api/locations", fmt.Sprintf(`{"kind":"slot","name":"3","parent_id":%d}`, room), manager.AccessToken).Code)
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodPost, "/api/locations", fmt.Sprintf(`{"kind":"slot","name":"2","parent_id":%d}`, cabinet), manager.AccessToken).Code)
+
+	assign := func(phoneId int, slot string) int {
+		t.Helper()
+		return serve(s, http.MethodPut, fmt.Sprintf("/api/phones/%d/slot", phoneId), `{"slot_id":`+slot+`}`, manager.AccessToken).Code
+	}
+	assert.Equal(t, http.StatusBadRequest, assign(p.Id, fmt.Sprint(cabinet)))
+	assert.Equal(t, http.StatusNotFound, assign(42, fmt.Sprint(slot1)))
+	assert.Equal(t, http.StatusOK, assign(p.Id, fmt.Sprint(slot1)))
+	assert.Equal(t, http.StatusConflict, assign(other.Id, fmt.Sprint(slot1)))
+	assert.Equal(t, http.StatusOK, assign(p.Id, fmt.Sprint(slot2)))
+
+	rec := serve(s, http.MethodGet, "/api/devices", "", viewer.AccessToken)
+	var devices struct {
+		Phones []device `json:"phones"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&devices))
+	if assert.Len(t, devices.Phones, 2) && assert.NotNil(t, devices.Phones[0].Slot) {
+		assert.Equal(t, "Moscow / Lab 3 / Rack B / 2", devices.Phones[0].Slot.String())
+		assert.Nil(t, devices.Phones[1].Slot)
+	}
+
+	rec = serve(s, http.MethodGet, fmt.Sprintf("/api/locations/%d", room), "", viewer.AccessToken)
+	var location struct {
+		Children []models.Location `json:"children"`
+		Phones   []models.Phone    `json:"phones"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&location))
+	assert.Len(t, location.Children, 1)
+	assert.Len(t, location.Phones, 1)
+
+	// Occupied slots and locations with children can't be deleted.
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodDelete, fmt.Sprintf("/api/locations/%d", slot2), "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodDelete, fmt.Sprintf("/api/locations/%d", cabinet), "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodDelete, fmt.Sprintf("/api/locations/%d", slot1), "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodDelete, fmt.Sprintf("/api/locations/%d", slot1), "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPatch, fmt.Sprintf("/api/locations/%d", cabinet), `{"name":"Rack C"}`, manager.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, assign(p.Id, "null"))
+
+	history, _ := st.Phone().SelectHistory(p.Id)
+	var moves []string
+	for _, c := range history {
+		if c.Field == models.PhoneChangeSlot {
+			moves = append(moves, c.OldValue+" -> "+c.NewValue)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		" -> Moscow / Lab 3 / Rack B / 1",
+		"Moscow / Lab 3 / Rack B / 1 -> Moscow / Lab 3 / Rack B / 2",
+		"Moscow / Lab 3 / Rack C / 2 -> ",
+	}, moves)
+}
+
+func TestApi_SubnetMove(t *testing.T) {
+	// Reports are enriched from the data directory of the server root.
+	wd, _ := os.Getwd()
+	if err := os.Chdir("../../.."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+
+	tk := login(t, s, "user@example.org")
+	id, deviceToken := enroll(t, s, tk.AccessToken)
+	st.Enrollment().BindPhone(id, p.Id)
+
+	for _, ip := range []string{"10.0.4.17", "10.0.4.90", "192.168.1.5"} {
+		assert.Equal(t, http.StatusOK, serveDevice(s, "/api/heartbeat", `{"battery_level":50,"ip":"`+ip+`"}`, deviceToken).Code)
+	}
+	// Reports tell the address too; those without it don't count as a move.
+	for _, ip := range []string{"", "172.16.0.5"} {
+		report := `{"phone_info":{"model_number":"SM-G973F/DS"},"ip":"` + ip + `"}`
+		assert.Equal(t, http.StatusOK, serveDevice(s, "/api/phone_info", report, deviceToken).Code)
+	}
+
+	history, _ := st.Phone().SelectHistory(p.Id)
+	var moves []models.PhoneChange
+	for _, c := range history {
+		if c.Field == models.PhoneChangeSubnet {
+			moves = append(moves, c)
+		}
+	}
+	if assert.Len(t, moves, 2) {
+		assert.Equal(t, "10.0.4.0/24", moves[0].OldValue)
+		assert.Equal(t, "192.168.1.0/24", moves[0].NewValue)
+		assert.Equal(t, "192.168.1.0/24", moves[1].OldValue)
+		assert.Equal(t, "172.16.0.0/24", moves[1].NewValue)
+	}
+}
+
+func TestApi_LocationMove(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+
+	tk := login(t, s, "manager@example.org")
+	for _, body := range []string{`{"location":"Rack B"}`, `{"location":" Rack B "}`, `{"notes":"Cracked screen"}`, `{"location":"Rack C"}`} {
+		assert.Equal(t, http.StatusOK, serve(s, http.MethodPatch, fmt.Sprintf("/api/phones/%d", p.Id), body, tk.AccessToken).Code)
+	}
+
+	history, _ := st.Phone().SelectHistory(p.Id)
+	var moves []string
+	for _, c := range history {
+		if c.Field == models.PhoneChangeLocation {
+			moves = append(moves, c.OldValue+" -> "+c.NewValue)
+		}
+	}
+	assert.ElementsMatch(t, []string{" -> Rack B", "Rack B -> Rack C"}, moves)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"server/internal/app/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdempotencyKeyHeader may carry the idempotency key of a new notification
+// instead of the idempotency_key field.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NextCursorHeader carries the cursor of the next page of notifications. It
+// is absent on the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
+const (
+	defaultNotificationLimit = 100
+	maxNotificationLimit     = 1000
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// fingerprint identifies notifications with the same content from the same
+// device, regardless of when they were posted.
+func fingerprint(n *models.Notification) string {
+	h := sha256.New()
+	for _, field := range []string{n.ModelNumber, n.Source, n.Sender, n.Body} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func encodeCursor(c models.NotificationCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.Timestamp, c.Id)))
+}
+
+func decodeCursor(s string) (*models.NotificationCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	timestamp, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+
+	c := &models.NotificationCursor{}
+	if c.Timestamp, err = strconv.ParseInt(timestamp, 10, 64); err != nil {
+		return nil, errInvalidCursor
+	}
+	if c.Id, err = strconv.Atoi(id); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+// notificationFilter reads search parameters. Results are newest first
+// unless order=asc.
+func notificationFilter(r *http.Request) (models.NotificationFilter, error) {
+	q := r.URL.Query()
+	filter := models.NotificationFilter{
+		ModelNumber: q.Get("model_number"),
+		PhoneNumber: q.Get("phone_number"),
+		Sender:      q.Get("sender"),
+		Source:      q.Get("source"),
+		Query:       strings.TrimSpace(q.Get("q")),
+		Limit:       defaultNotificationLimit,
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, errors.New("from must be an RFC 3339 time")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, errors.New("to must be an RFC 3339 time")
+		}
+	}
+	switch q.Get("order") {
+	case "", "desc":
+		filter.Descending = true
+	case "asc":
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 1 || filter.Limit > maxNotificationLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxNotificationLimit)
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		if filter.After, err = decodeCursor(v); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+func (s *Server) handleNotifications() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := notificationFilter(r)
+		if err != nil {
+			s.logger.Info(`[Notifications] Invalid search parameters`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// One extra row tells whether there is a next page.
+		limit := filter.Limit
+		filter.Limit++
+		notificationList, err := s.storage.Notification().Search(filter)
+		if err != nil {
+			s.logger.Info(`[Notifications] Error while fetching notifications`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if len(notificationList) > limit {
+			notificationList = notificationList[:limit]
+			last := notificationList[limit-1]
+			w.Header().Set(NextCursorHeader, encodeCursor(models.NotificationCursor{Timestamp: last.Timestamp, Id: last.Id}))
+		}
+		if notificationList == nil {
+			notificationList = []models.Notification{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(notificationList)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_NewNotificationResolvesSim(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	slot0, slot1 := 0, 1
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS", Slot: &slot0}, p)
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79001112233", Operator: "Beeline", Slot: &slot1}, p)
+
+	tk := login(t, s, "user@example.org")
+	id, deviceToken := enroll(t, s, tk.AccessToken)
+	st.Enrollment().BindPhone(id, p.Id)
+
+	testCases := []struct {
+		name        string
+		body        string
+		code        int
+		phoneNumber string
+	}{
+		{"by slot", `{"sender":"900","body":"Code: 1111","sim_slot":1}`, http.StatusOK, "79001112233"},
+		{"by phone number", `{"sender":"900","body":"Code: 2222","phone_number":"79889484608"}`, http.StatusOK, "79889484608"},
+		{"ambiguous", `{"sender":"900","body":"Code: 3333"}`, http.StatusOK, ""},
+		{"foreign number", `{"sender":"900","body":"Code: 4444","phone_number":"70000000000"}`, http.StatusOK, ""},
+		{"other phone", `{"model_number":"SM-A525F","sender":"900","body":"Code: 5555"}`, http.StatusForbidden, ""},
+		{"invalid json", `{"sender":`, http.StatusBadRequest, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serveDevice(s, "/api/new_notification", tc.body, deviceToken)
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+
+	notifications, _ := st.Notification().SelectByModelTag("SM-G973F/DS")
+	assert.Len(t, notifications, 4)
+	for i, tc := range testCases[:4] {
+		assert.Equal(t, tc.phoneNumber, notifications[i].PhoneNumber, tc.name)
+		assert.Equal(t, p.Id, *notifications[i].PhoneId)
+	}
+
+	rec := serve(s, http.MethodGet, "/api/notifications?phone_number=79001112233", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var byNumber []models.Notification
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&byNumber))
+	assert.Len(t, byNumber, 1)
+	assert.Equal(t, "Code: 1111", byNumber[0].Body)
+}
+
+func TestApi_NotificationSearch(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+	tk := login(t, s, "user@example.org")
+
+	base := time.Date(2023, 7, 20, 12, 0, 0, 0, time.UTC)
+	for i, n := range []models.Notification{
+		{ModelNumber: "SM-G973F/DS", Source: "sms", Sender: "Bank", Body: "Your code is 1111"},
+		{ModelNumber: "SM-G973F/DS", Source: "sms", Sender: "bank", Body: "Payment declined"},
+		{ModelNumber: "SM-G973F/DS", Source: "telegram", Sender: "Telegram", Body: "Login code: 2222"},
+		{ModelNumber: "SM-A525F", Source: "sms", Sender: "Bank", Body: "Your code is 3333"},
+		{ModelNumber: "SM-G973F/DS", Source: "sms", Sender: "Shop", Body: "Sale! Promo code inside"},
+	} {
+		n.PhoneId = &p.Id
+		n.Timestamp = base.Add(time.Duration(i) * time.Minute).UnixMilli()
+		st.Notification().Create(&n)
+	}
+
+	search := func(query string) ([]string, string) {
+		t.Helper()
+		rec := serve(s, http.MethodGet, "/api/notifications?"+query, "", tk.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code, query)
+
+		var notifications []models.Notification
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&notifications))
+		bodies := []string{}
+		for _, n := range notifications {
+			bodies = append(bodies, n.Body)
+		}
+		return bodies, rec.Header().Get(NextCursorHeader)
+	}
+
+	bodies, _ := search("model_number=SM-G973F/DS&sender=BANK")
+	assert.Equal(t, []string{"Payment declined", "Your code is 1111"}, bodies)
+
+	bodies, _ = search("source=sms&q=code+-promo&order=asc")
+	assert.Equal(t, []string{"Your code is 1111", "Your code is 3333"}, bodies)
+
+	bodies, _ = search("from=2023-07-20T12:01:00Z&to=2023-07-20T12:03:00Z")
+	assert.Equal(t, []string{"Login code: 2222", "Payment declined"}, bodies)
+
+	var pages [][]string
+	bodies, cursor := search("model_number=SM-G973F/DS&limit=3")
+	pages = append(pages, bodies)
+	for cursor != "" {
+		bodies, cursor = search("model_number=SM-G973F/DS&limit=3&cursor=" + cursor)
+		pages = append(pages, bodies)
+	}
+	assert.Equal(t, [][]string{
+		{"Sale! Promo code inside", "Login code: 2222", "Payment declined"},
+		{"Your code is 1111"},
+	}, pages)
+
+	for _, query := range []string{"from=yesterday", "order=random", "limit=0", "limit=5000", "cursor=bm90LWEtY3Vyc29y"} {
+		rec := serve(s, http.MethodGet, "/api/notifications?"+query, "", tk.AccessToken)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestApi_NewNotificationDeduplicates(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	tk := login(t, s, "user@example.org")
+	id, deviceToken := enroll(t, s, tk.AccessToken)
+	st.Enrollment().BindPhone(id, p.Id)
+
+	post := func(body, key string) (int, bool) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/new_notification", strings.NewReader(body))
+		req.Header.Set("Authorization", "Device "+deviceToken)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var res struct {
+			NotificationId int  `json:"notification_id"`
+			Duplicate      bool `json:"duplicate"`
+		}
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+		return res.NotificationId, res.Duplicate
+	}
+
+	first, dup := post(`{"sender":"900","body":"Code: 1111","idempotency_key":"a1"}`, "")
+	assert.False(t, dup)
+	// A retry with the key in the header.
+	again, dup := post(`{"sender":"900","body":"Code: 1111"}`, "a1")
+	assert.True(t, dup)
+	assert.Equal(t, first, again)
+
+	// Without keys, identical notifications within the window are collapsed.
+	second, dup := post(`{"sender":"Bank","body":"Balance: 10","timestamp":1690000000000}`, "")
+	assert.False(t, dup)
+	again, dup = post(`{"sender":"Bank","body":"Balance: 10","timestamp":1690000020000}`, "")
+	assert.True(t, dup)
+	assert.Equal(t, second, again)
+	_, dup = post(`{"sender":"Bank","body":"Balance: 10","timestamp":1690000060000}`, "")
+	assert.False(t, dup)
+	_, dup = post(`{"sender":"Bank","body":"Balance: 20","timestamp":1690000020000}`, "")
+	assert.False(t, dup)
+
+	// Without a timestamp nothing tells them apart, so nothing is dropped.
+	_, dup = post(`{"sender":"Bank","body":"Hello"}`, "")
+	assert.False(t, dup)
+	_, dup = post(`{"sender":"Bank","body":"Hello"}`, "")
+	assert.False(t, dup)
+
+	notifications, _ := st.Notification().SelectByModelTag("SM-G973F/DS")
+	assert.Len(t, notifications, 6)
+}
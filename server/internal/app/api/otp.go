@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/hub"
+	"server/internal/app/storage"
+	"time"
+)
+
+// handleOtp returns the newest code received on a phone number. With wait it
+// long-polls until a code arrives or the wait is over.
+func (s *Server) handleOtp() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		phoneNumber := r.URL.Query().Get("phone_number")
+		if phoneNumber == "" {
+			s.logger.Info(`[Otp] There was no phone number in request`)
+			http.Error(w, "phone_number is required", http.StatusBadRequest)
+			return
+		}
+
+		var since time.Time
+		var err error
+		if v := r.URL.Query().Get("since"); v != "" {
+			if since, err = time.Parse(time.RFC3339, v); err != nil {
+				s.logger.Info(`[Otp] Can't parse since`)
+				http.Error(w, "since must be RFC3339", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var wait time.Duration
+		if v := r.URL.Query().Get("wait"); v != "" {
+			if wait, err = time.ParseDuration(v); err != nil || wait < 0 {
+				s.logger.Info(`[Otp] Can't parse wait`)
+				http.Error(w, "wait must be a duration such as 30s", http.StatusBadRequest)
+				return
+			}
+		}
+		if wait > s.config.Otp.MaxWait {
+			wait = s.config.Otp.MaxWait
+		}
+
+		timeout := time.NewTimer(wait)
+		defer timeout.Stop()
+
+		// Subscribing before the lookup makes sure a code stored in between
+		// is not missed.
+		filter := hub.Filter{PhoneNumber: phoneNumber}
+		sub := s.hub.Subscribe(filter, 0)
+		defer func() { s.hub.Unsubscribe(sub) }()
+
+		for {
+			n, err := s.storage.Notification().SelectLatestCode(phoneNumber, since)
+			if err == nil {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(n)
+				s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+				return
+			}
+			if err != storage.ErrRecordNotFound {
+				s.logger.Info(`[Otp] Error while fetching code`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			select {
+			case _, ok := <-sub.C:
+				if !ok {
+					sub = s.hub.Subscribe(filter, 0)
+				}
+			case <-timeout.C:
+				http.Error(w, "No code received", http.StatusNotFound)
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Otp(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS"}, p)
+
+	tk := login(t, s, "user@example.org")
+	id, deviceToken := enroll(t, s, tk.AccessToken)
+	st.Enrollment().BindPhone(id, p.Id)
+
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodGet, "/api/otp", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/otp?phone_number=79889484608", "", tk.AccessToken).Code)
+
+	serveDevice(s, "/api/new_notification", `{"sender":"900","body":"Your code: 1111"}`, deviceToken)
+	serveDevice(s, "/api/new_notification", `{"sender":"900","body":"Balance: 100"}`, deviceToken)
+
+	rec := serve(s, http.MethodGet, "/api/otp?phone_number=79889484608", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var n models.Notification
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&n))
+	assert.Equal(t, "1111", n.Code)
+
+	since := time.Now().Add(time.Second).Format(time.RFC3339)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serve(s, http.MethodGet, "/api/otp?phone_number=79889484608&wait=5s&since="+since, "", tk.AccessToken)
+	}()
+
+	time.Sleep(1100 * time.Millisecond)
+	serveDevice(s, "/api/new_notification", `{"sender":"900","body":"Your code: 2222"}`, deviceToken)
+
+	select {
+	case rec := <-done:
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&n))
+		assert.Equal(t, "2222", n.Code)
+	case <-time.After(3 * time.Second):
+		t.Fatal("long-poll did not return")
+	}
+}
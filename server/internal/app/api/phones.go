@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// recentNotifications is how many notifications the phone page shows; older
+// ones are found through /api/notifications.
+const recentNotifications = 20
+
+// phonePatch is the body of phone updates. Absent fields are left unchanged;
+// everything else about a phone comes from its reports.
+type phonePatch struct {
+	InventoryTag *string `json:"inventory_tag"`
+	DisplayName  *string `json:"display_name"`
+	Location     *string `json:"location"`
+	Notes        *string `json:"notes"`
+}
+
+func (p *phonePatch) apply(phone *models.Phone) {
+	if p.InventoryTag != nil {
+		phone.InventoryTag = strings.TrimSpace(*p.InventoryTag)
+	}
+	if p.DisplayName != nil {
+		phone.DisplayName = strings.TrimSpace(*p.DisplayName)
+	}
+	if p.Location != nil {
+		phone.Location = strings.TrimSpace(*p.Location)
+	}
+	if p.Notes != nil {
+		phone.Notes = *p.Notes
+	}
+}
+
+// handlePhone returns a phone together with everything the phone page shows,
+// so the page needs a single request.
+func (s *Server) handlePhone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Response struct {
+			*models.Phone
+			AssetTag      string                `json:"asset_tag"`
+			SimCards      []models.SimInfo      `json:"sim_cards"`
+			SdCards       []sdCard              `json:"sd_cards"`
+			Owner         *models.User          `json:"owner"`
+			Slot          *models.LocationPath  `json:"slot"`
+			Notifications []models.Notification `json:"notifications"`
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[Phone] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		phone, err := s.storage.Phone().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[Phone] Phone not found`)
+			http.Error(w, "Phone not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[Phone] Error while fetching phone`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		simCards, err := s.storage.Sim().SelectByPhoneId(id)
+		if err != nil {
+			s.logger.Info(`[Phone] Error while fetching sim cards`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if simCards == nil {
+			simCards = []models.SimInfo{}
+		}
+
+		sdInfos, err := s.storage.SdCard().Select(models.SdFilter{PhoneId: &id})
+		if err != nil {
+			s.logger.Info(`[Phone] Error while fetching sd cards`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		sdCards := []sdCard{}
+		for i := range sdInfos {
+			sdCards = append(sdCards, s.sdCard(&sdInfos[i]))
+		}
+
+		owner, err := s.storage.UserPhone().SelectOwner(id)
+		if err != nil && err != storage.ErrRecordNotFound {
+			s.logger.Info(`[Phone] Error while fetching owner`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var slot *models.LocationPath
+		if phone.SlotId != nil {
+			locations, err := s.storage.Location().SelectAll()
+			if err != nil {
+				s.logger.Info(`[Phone] Error while fetching locations`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			slot = locationPaths(locations)[*phone.SlotId]
+		}
+
+		notifications, err := s.storage.Notification().Search(models.NotificationFilter{
+			ModelNumber: phone.ModelNumber,
+			Descending:  true,
+			Limit:       recentNotifications,
+		})
+		if err != nil {
+			s.logger.Info(`[Phone] Error while fetching notifications`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if notifications == nil {
+			notifications = []models.Notification{}
+		}
+
+		response := Response{
+			Phone:         phone,
+			AssetTag:      phone.AssetTag(),
+			SimCards:      simCards,
+			SdCards:       sdCards,
+			Owner:         owner,
+			Slot:          slot,
+			Notifications: notifications,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (s *Server) handleUpdatePhone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[UpdatePhone] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var patch phonePatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			s.logger.Info(`[UpdatePhone] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		phone, err := s.storage.Phone().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[UpdatePhone] Phone not found`)
+			http.Error(w, "Phone not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[UpdatePhone] Error while fetching phone`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		oldLocation := phone.Location
+		patch.apply(phone)
+		err = s.storage.Phone().Update(phone)
+		if err == storage.ErrRecordExists {
+			s.logger.Info(`[UpdatePhone] Inventory tag is taken`)
+			http.Error(w, "Another phone has this inventory tag", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[UpdatePhone] Error while updating phone`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if phone.Location != oldLocation {
+			s.recordMove(phone.Id, models.PhoneChangeLocation, oldLocation, phone.Location)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(phone)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Phone(t *testing.T) {
+	s, st := testServer(t)
+	viewer := createTestUser(t, st, "viewer@example.org", auth.RoleViewer)
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS", OsVersion: "12"})
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+	slot := 0
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS", Slot: &slot}, p)
+	st.SdCard().Create(&models.SdInfo{SerialNo: "0x1a8ed52f", TotalSpace: 100, UsedSpace: 95, FreeSpace: 5}, p)
+	st.UserPhone().CreateRelation(viewer.Id, p.Id)
+	for i := 0; i < recentNotifications+5; i++ {
+		st.Notification().Create(&models.Notification{ModelNumber: p.ModelNumber, Source: "sms", Body: "hi", Timestamp: int64(i + 1)})
+	}
+
+	viewerTokens := login(t, s, "viewer@example.org")
+	manager := login(t, s, "manager@example.org")
+
+	get := func() map[string]any {
+		t.Helper()
+		rec := serve(s, http.MethodGet, "/api/phones/1", "", viewerTokens.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var phone map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&phone))
+		return phone
+	}
+
+	phone := get()
+	assert.Equal(t, "SM-G973F/DS", phone["model_number"])
+	assert.Len(t, phone["sim_cards"], 1)
+	assert.Len(t, phone["sd_cards"], 1)
+	assert.Equal(t, true, phone["sd_cards"].([]any)[0].(map[string]any)["above_threshold"])
+	owner := phone["owner"].(map[string]any)
+	assert.Equal(t, "viewer@example.org", owner["email"])
+	assert.Empty(t, owner["password"])
+	notifications := phone["notifications"].([]any)
+	assert.Len(t, notifications, recentNotifications)
+	assert.Equal(t, float64(recentNotifications+5), notifications[0].(map[string]any)["timestamp"])
+
+	rec := serve(s, http.MethodGet, "/api/phones/2", "", viewerTokens.AccessToken)
+	assert.Contains(t, rec.Body.String(), `"owner":null`)
+	assert.Contains(t, rec.Body.String(), `"sim_cards":[]`)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/phones/42", "", viewerTokens.AccessToken).Code)
+
+	body := `{"inventory_tag":" QA-0042 ","display_name":"Galaxy S10 #2","location":"Rack B","notes":"Cracked screen"}`
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPatch, "/api/phones/1", body, viewerTokens.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPatch, "/api/phones/1", body, manager.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPatch, "/api/phones/1", `{"location":"Rack C"}`, manager.AccessToken).Code)
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodPatch, "/api/phones/2", `{"inventory_tag":"QA-0042"}`, manager.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodPatch, "/api/phones/42", `{}`, manager.AccessToken).Code)
+
+	phone = get()
+	assert.Equal(t, "QA-0042", phone["inventory_tag"])
+	assert.Equal(t, "Galaxy S10 #2", phone["display_name"])
+	assert.Equal(t, "Rack C", phone["location"])
+	assert.Equal(t, "Cracked screen", phone["notes"])
+
+	// A new report keeps what lab staff entered and doesn't record it as history.
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS", OsVersion: "13"})
+	stored, _ := st.Phone().SelectById(1)
+	assert.Equal(t, "13", stored.OsVersion)
+	assert.Equal(t, "QA-0042", stored.InventoryTag)
+	history, _ := st.Phone().SelectHistory(1)
+	for _, c := range history {
+		assert.NotEqual(t, "inventory_tag", c.Field)
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strings"
+	"time"
+)
+
+// device is a phone as listed in /api/devices, with its presence, the slot
+// it is kept in and who has it checked out until when.
+type device struct {
+	models.Phone
+	Status    string               `json:"status"`
+	Heartbeat *models.Heartbeat    `json:"heartbeat"`
+	Slot      *models.LocationPath `json:"slot"`
+	Holder    *holder              `json:"holder"`
+	DueAt     *time.Time           `json:"due_at"`
+}
+
+type holder struct {
+	UserId int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+// devices lists phones with what is known about them; reservations are the
+// active ones.
+func (s *Server) devices(phones []models.Phone, heartbeats []models.Heartbeat, locations []models.Location, reservations []models.Reservation) []device {
+	byPhone := make(map[int]*models.Heartbeat, len(heartbeats))
+	for i := range heartbeats {
+		byPhone[heartbeats[i].PhoneId] = &heartbeats[i]
+	}
+	held := make(map[int]*models.Reservation, len(reservations))
+	for i := range reservations {
+		held[reservations[i].PhoneId] = &reservations[i]
+	}
+
+	paths := locationPaths(locations)
+
+	now := time.Now()
+	devices := []device{}
+	for _, p := range phones {
+		d := device{
+			Phone:     p,
+			Status:    s.config.Presence.Status(p.LastSeenAt, now),
+			Heartbeat: byPhone[p.Id],
+		}
+		if p.SlotId != nil {
+			d.Slot = paths[*p.SlotId]
+		}
+		if res := held[p.Id]; res != nil {
+			d.Holder = &holder{UserId: res.UserId, Name: res.UserName, Email: res.UserEmail}
+			d.DueAt = &res.EndsAt
+		}
+		devices = append(devices, d)
+	}
+
+	return devices
+}
+
+// subnet returns the network an address is in: its /24 for IPv4 and its /64
+// for IPv6. It returns "" for anything that isn't an address.
+func subnet(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+
+	if v4 := addr.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: addr.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// recordSubnetMove adds a phone moved entry if the phone showed up on another
+// network, as it has been carried elsewhere.
+func (s *Server) recordSubnetMove(phoneId int, previousIp, ip string) {
+	from, to := subnet(previousIp), subnet(ip)
+	if from != "" && to != "" && from != to {
+		s.recordMove(phoneId, models.PhoneChangeSubnet, from, to)
+	}
+}
+
+// handleHeartbeat records that the agent of a phone is alive. The phone is
+// the one its credential is bound to, so it must have reported once.
+func (s *Server) handleHeartbeat() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			BatteryLevel int    `json:"battery_level"`
+			Charging     bool   `json:"charging"`
+			NetworkType  string `json:"network_type"`
+			Ip           string `json:"ip"`
+			Uptime       int64  `json:"uptime"`
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[Heartbeat] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.BatteryLevel < 0 || req.BatteryLevel > 100 {
+			s.logger.Info(`[Heartbeat] Battery level out of range`)
+			http.Error(w, "battery_level must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+		if req.Uptime < 0 {
+			s.logger.Info(`[Heartbeat] Negative uptime`)
+			http.Error(w, "uptime must not be negative", http.StatusBadRequest)
+			return
+		}
+
+		c := deviceCredential(r)
+		if c.PhoneId == nil {
+			s.logger.Info(`[Heartbeat] Credential is not bound to a phone`)
+			http.Error(w, "Phone has not reported yet", http.StatusConflict)
+			return
+		}
+
+		hb := &models.Heartbeat{
+			PhoneId:      *c.PhoneId,
+			BatteryLevel: req.BatteryLevel,
+			Charging:     req.Charging,
+			NetworkType:  strings.TrimSpace(req.NetworkType),
+			Ip:           strings.TrimSpace(req.Ip),
+			Uptime:       req.Uptime,
+			ReceivedAt:   time.Now(),
+		}
+		// Agents that can't tell their address get the one they connect from.
+		if hb.Ip == "" {
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				hb.Ip = host
+			}
+		}
+
+		previousIp, err := s.storage.Heartbeat().Save(hb)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[Heartbeat] Phone not found`)
+			http.Error(w, "Phone not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[Heartbeat] Error while saving heartbeat`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		s.recordSubnetMove(hb.PhoneId, previousIp, hb.Ip)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(hb)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Heartbeat(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+
+	tk := login(t, s, "user@example.org")
+	id, deviceToken := enroll(t, s, tk.AccessToken)
+	body := `{"battery_level":87,"charging":true,"network_type":"wifi","ip":"10.0.4.17","uptime":3600}`
+
+	assert.Equal(t, http.StatusUnauthorized, serveDevice(s, "/api/heartbeat", body, "").Code)
+	assert.Equal(t, http.StatusConflict, serveDevice(s, "/api/heartbeat", body, deviceToken).Code)
+	st.Enrollment().BindPhone(id, p.Id)
+	assert.Equal(t, http.StatusBadRequest, serveDevice(s, "/api/heartbeat", `{"battery_level":120}`, deviceToken).Code)
+	rec := serveDevice(s, "/api/heartbeat", body, deviceToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var hb models.Heartbeat
+	json.NewDecoder(rec.Body).Decode(&hb)
+	assert.Equal(t, 87, hb.BatteryLevel)
+	assert.Equal(t, "10.0.4.17", hb.Ip)
+
+	rec = serve(s, http.MethodGet, "/api/devices", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var devices struct {
+		Phones []device `json:"phones"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&devices))
+	if assert.Len(t, devices.Phones, 2) {
+		assert.Equal(t, "online", devices.Phones[0].Status)
+		assert.NotNil(t, devices.Phones[0].LastSeenAt)
+		assert.Equal(t, "wifi", devices.Phones[0].Heartbeat.NetworkType)
+		assert.Equal(t, "offline", devices.Phones[1].Status)
+		assert.Nil(t, devices.Phones[1].Heartbeat)
+	}
+
+	// A silent phone raises an event that lists under /api/events.
+	s.config.Presence.OfflineAfter = 0
+	events, err := s.presence.Check()
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	rec = serve(s, http.MethodGet, "/api/events?type=device_offline&phone_id=1", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var listed []models.Event
+	json.NewDecoder(rec.Body).Decode(&listed)
+	assert.Len(t, listed, 1)
+	assert.Equal(t, `[]`+"\n", serve(s, http.MethodGet, "/api/events?phone_id=2", "", tk.AccessToken).Body.String())
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodGet, "/api/events?limit=0", "", tk.AccessToken).Code)
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultCalendarWindow is how far ahead GET /phones/{id}/reservations looks
+// when no range is given.
+const defaultCalendarWindow = 30 * 24 * time.Hour
+
+// currentUser resolves the user behind the token checked by IsAuthorized.
+func (s *Server) currentUser(r *http.Request) (*models.User, error) {
+	sbj, _ := r.Context().Value("subject").(string)
+
+	return s.storage.User().SelectByEmail(sbj)
+}
+
+func (s *Server) handleCheckout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			Duration string     `json:"duration"`
+			StartsAt *time.Time `json:"starts_at"`
+			Note     string     `json:"note"`
+		}
+
+		phoneId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[Checkout] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[Checkout] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			s.logger.Info(`[Checkout] Invalid duration`)
+			http.Error(w, "duration must be a positive value like \"2h30m\"", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		startsAt := now
+		if req.StartsAt != nil {
+			if req.StartsAt.Before(now.Add(-time.Minute)) {
+				s.logger.Info(`[Checkout] Reservation starts in the past`)
+				http.Error(w, "starts_at is in the past", http.StatusBadRequest)
+				return
+			}
+			startsAt = *req.StartsAt
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[Checkout] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if !startsAt.After(now) {
+			leased, err := s.leases.IsLeased(phoneId)
+			if err != nil {
+				s.logger.Info(`[Checkout] Error while checking leases`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if leased {
+				s.logger.Info(`[Checkout] Phone is leased`)
+				http.Error(w, "Phone is leased by an automated job", http.StatusConflict)
+				return
+			}
+		}
+
+		res, err := s.storage.Reservation().Create(&models.Reservation{
+			PhoneId:  phoneId,
+			UserId:   user.Id,
+			StartsAt: startsAt,
+			EndsAt:   startsAt.Add(duration),
+			Note:     req.Note,
+		})
+		switch err {
+		case nil:
+		case storage.ErrRecordNotFound:
+			s.logger.Info(`[Checkout] Phone not found`)
+			http.Error(w, "Phone not found", http.StatusNotFound)
+			return
+		case storage.ErrReservationConflict:
+			s.logger.Info(`[Checkout] Reservation conflict`)
+			http.Error(w, "Phone is already reserved for this period", http.StatusConflict)
+			return
+		default:
+			s.logger.Info(`[Checkout] Error while creating reservation`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "Could not create reservation", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(res)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+func (s *Server) handleCheckin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		phoneId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[Checkin] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[Checkin] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		now := time.Now()
+		active, err := s.storage.Reservation().SelectActive(now)
+		if err != nil {
+			s.logger.Info(`[Checkin] Error while fetching reservations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var res *models.Reservation
+		for i := range active {
+			if active[i].PhoneId == phoneId {
+				res = &active[i]
+				break
+			}
+		}
+		if res == nil {
+			s.logger.Info(`[Checkin] Phone is not checked out`)
+			http.Error(w, "Phone is not checked out", http.StatusConflict)
+			return
+		}
+
+		if res.UserId != user.Id && !auth.HasPermission(user.Role, auth.PermReservationsManage) {
+			s.logger.Info(`[Checkin] Current user have not permission`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if err := s.storage.Reservation().CheckIn(res.Id, now); err != nil {
+			s.logger.Info(`[Checkin] Error while checking in`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		res.CheckedInAt = &now
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+func (s *Server) handlePhoneReservations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		phoneId, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[Reservations] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		from := time.Now()
+		if v := r.URL.Query().Get("from"); v != "" {
+			if from, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, "from must be RFC3339", http.StatusBadRequest)
+				return
+			}
+		}
+		to := from.Add(defaultCalendarWindow)
+		if v := r.URL.Query().Get("to"); v != "" {
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, "to must be RFC3339", http.StatusBadRequest)
+				return
+			}
+		}
+
+		reservations, err := s.storage.Reservation().SelectByPhoneId(phoneId, from, to)
+		if err != nil {
+			s.logger.Info(`[Reservations] Error while fetching reservations`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if reservations == nil {
+			reservations = []models.Reservation{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reservations)
+	}
+}
+
+func (s *Server) handleCancelReservation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[CancelReservation] Can't parse reservation id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		res, err := s.storage.Reservation().SelectById(id)
+		if err != nil {
+			s.logger.Info(`[CancelReservation] Error while fetching reservation`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusNotFound))
+			http.Error(w, "Reservation not found", http.StatusNotFound)
+			return
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[CancelReservation] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if res.UserId != user.Id && !auth.HasPermission(user.Role, auth.PermReservationsManage) {
+			s.logger.Info(`[CancelReservation] Current user have not permission`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if res.IsActive(time.Now()) {
+			s.logger.Info(`[CancelReservation] Reservation already started`)
+			http.Error(w, "Reservation already started, check the phone in instead", http.StatusConflict)
+			return
+		}
+
+		if err := s.storage.Reservation().Delete(id); err != nil {
+			s.logger.Info(`[CancelReservation] Error while deleting reservation`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/app/auth"
+	"server/internal/app/config"
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func authorizedRequest(method, target, body string, u *models.User, vars map[string]string) *http.Request {
+	req, _ := http.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), "subject", u.Email)
+	ctx = context.WithValue(ctx, "role", u.Role)
+
+	return mux.SetURLVars(req.WithContext(ctx), vars)
+}
+
+func TestApi_CheckoutCheckin(t *testing.T) {
+	st := memstorage.New()
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	alice, _ := st.User().Create(&models.User{Email: "alice@example.org", Role: auth.RoleTester})
+	bob, _ := st.User().Create(&models.User{Email: "bob@example.org", Role: auth.RoleTester})
+	vars := map[string]string{"id": strconv.Itoa(p.Id)}
+
+	s := New(config.NewConfig(), st)
+
+	rec := httptest.NewRecorder()
+	s.handleCheckout().ServeHTTP(rec, authorizedRequest(http.MethodPost, "/", `{"duration":"2h"}`, alice, vars))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handleCheckout().ServeHTTP(rec, authorizedRequest(http.MethodPost, "/", `{"duration":"1h"}`, bob, vars))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handleDevices().ServeHTTP(rec, authorizedRequest(http.MethodGet, "/", "", alice, nil))
+	var devices struct {
+		Phones []device `json:"phones"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&devices))
+	if assert.Len(t, devices.Phones, 1) && assert.NotNil(t, devices.Phones[0].Holder) {
+		assert.Equal(t, "alice@example.org", devices.Phones[0].Holder.Email)
+		assert.WithinDuration(t, time.Now().Add(2*time.Hour), *devices.Phones[0].DueAt, time.Minute)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleCheckin().ServeHTTP(rec, authorizedRequest(http.MethodPost, "/", "", bob, vars))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handleCheckin().ServeHTTP(rec, authorizedRequest(http.MethodPost, "/", "", alice, vars))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handleCheckout().ServeHTTP(rec, authorizedRequest(http.MethodPost, "/", `{"duration":"1h"}`, bob, vars))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handleCheckout().ServeHTTP(rec, authorizedRequest(http.MethodPost, "/", `{"duration":"-1h"}`, bob, vars))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
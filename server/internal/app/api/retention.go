@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) handleRetention() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.retention.Stats())
+	}
+}
+
+// handlePurge runs the retention policy now. With dry_run=true it only
+// reports how many notifications would be removed.
+func (s *Server) handlePurge() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun := false
+		if v := r.URL.Query().Get("dry_run"); v != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(v); err != nil {
+				s.logger.Info(`[Purge] Can't parse dry_run`)
+				http.Error(w, "dry_run must be a boolean", http.StatusBadRequest)
+				return
+			}
+		}
+
+		res, err := s.retention.Run(dryRun)
+		if err != nil {
+			s.logger.Info(`[Purge] Error while purging notifications`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"server/internal/app/retention"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Purge(t *testing.T) {
+	s, st := testServer(t)
+	s.config.Retention.MaxRowsPerDevice = 1
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	createTestUser(t, st, "admin@example.org", auth.RoleAdmin)
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	for i := 0; i < 3; i++ {
+		st.Notification().Create(&models.Notification{ModelNumber: "SM-G973F/DS", ReceivedAt: time.Now()})
+	}
+
+	manager := login(t, s, "manager@example.org")
+	admin := login(t, s, "admin@example.org")
+
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost, "/api/retention/purge", "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodPost, "/api/retention/purge?dry_run=maybe", "", admin.AccessToken).Code)
+
+	for _, dryRun := range []bool{true, false} {
+		target := "/api/retention/purge"
+		if dryRun {
+			target += "?dry_run=true"
+		}
+		rec := serve(s, http.MethodPost, target, "", admin.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var res retention.Result
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+		assert.Equal(t, dryRun, res.DryRun)
+		assert.Equal(t, 2, res.Removed)
+	}
+
+	notifications, _ := st.Notification().SelectByModelTag("SM-G973F/DS")
+	assert.Len(t, notifications, 1)
+
+	rec := serve(s, http.MethodGet, "/api/retention", "", admin.AccessToken)
+	var stats retention.Stats
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Equal(t, 1, stats.Runs)
+	assert.Equal(t, 2, stats.Removed)
+}
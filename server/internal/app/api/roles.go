@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/storage"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Role struct {
+			Name        string            `json:"name"`
+			Permissions []auth.Permission `json:"permissions"`
+		}
+
+		roles := make([]Role, 0, len(auth.Roles))
+		for _, name := range auth.Roles {
+			roles = append(roles, Role{Name: name, Permissions: auth.PermissionsOf(name)})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(roles)
+	}
+}
+
+func (s *Server) handleUpdateUserRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			Role string `json:"role"`
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[UpdateUserRole] Can't parse user id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[UpdateUserRole] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !auth.IsValidRole(req.Role) {
+			s.logger.Info(`[UpdateUserRole] Unknown role`)
+			http.Error(w, "Unknown role", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[UpdateUserRole] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Keeps an admin from locking everyone out by demoting themselves.
+		if user.Id == id {
+			s.logger.Info(`[UpdateUserRole] Attempt to change own role`)
+			http.Error(w, "Can't change own role", http.StatusConflict)
+			return
+		}
+
+		err = s.storage.User().UpdateRole(id, req.Role)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[UpdateUserRole] User not found`)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[UpdateUserRole] Error while updating role`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
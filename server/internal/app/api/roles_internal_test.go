@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_PermissionMiddleware(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "admin@example.org", auth.RoleAdmin)
+	viewer := createTestUser(t, st, "viewer@example.org", auth.RoleViewer)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+
+	admin := login(t, s, "admin@example.org")
+	tk := login(t, s, "viewer@example.org")
+
+	deletePhone := "/api/phone?id=" + strconv.Itoa(p.Id)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/api/devices", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodGet, "/api/notifications?model_number=x", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodDelete, deletePhone, "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodGet, "/api/users", "", tk.AccessToken).Code)
+
+	// The new role applies to the already issued token.
+	target := "/api/users/" + strconv.Itoa(viewer.Id) + "/role"
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPut, target, `{"role":"admin"}`, tk.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPut, target, `{"role":"lab-manager"}`, admin.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodDelete, deletePhone, "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/api/users", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodDelete, "/api/user?id=1", "", tk.AccessToken).Code)
+}
+
+func TestApi_UpdateUserRole(t *testing.T) {
+	s, st := testServer(t)
+	a := createTestUser(t, st, "admin@example.org", auth.RoleAdmin)
+	u := createTestUser(t, st, "user@example.org", auth.RoleTester)
+
+	admin := login(t, s, "admin@example.org")
+
+	testCases := []struct {
+		name string
+		id   int
+		body string
+		code int
+	}{
+		{"valid", u.Id, `{"role":"viewer"}`, http.StatusOK},
+		{"unknown role", u.Id, `{"role":"root"}`, http.StatusBadRequest},
+		{"own role", a.Id, `{"role":"viewer"}`, http.StatusConflict},
+		{"unknown user", 100, `{"role":"viewer"}`, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(s, http.MethodPut, "/api/users/"+strconv.Itoa(tc.id)+"/role", tc.body, admin.AccessToken)
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+
+	updated, _ := st.User().SelectById(u.Id)
+	assert.Equal(t, auth.RoleViewer, updated.Role)
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// sdCard is an SD card with its fill level against the configured
+// threshold.
+type sdCard struct {
+	models.SdInfo
+	UsedPercent    float64 `json:"used_percent"`
+	AboveThreshold bool    `json:"above_threshold"`
+}
+
+func (s *Server) sdCard(sd *models.SdInfo) sdCard {
+	used := sd.UsedPercent()
+
+	return sdCard{SdInfo: *sd, UsedPercent: used, AboveThreshold: used > s.config.SdUsageThreshold}
+}
+
+func (s *Server) handleSdCards() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		filter := models.SdFilter{SdManufacturerId: q.Get("sd_manufacturer_id")}
+
+		if v := q.Get("phone_id"); v != "" {
+			phoneId, err := strconv.Atoi(v)
+			if err != nil {
+				s.logger.Info(`[SdCards] Can't parse phone id`)
+				http.Error(w, "phone_id must be a number", http.StatusBadRequest)
+				return
+			}
+			filter.PhoneId = &phoneId
+		}
+		if v := q.Get("assigned"); v != "" {
+			assigned, err := strconv.ParseBool(v)
+			if err != nil {
+				s.logger.Info(`[SdCards] Can't parse assigned`)
+				http.Error(w, "assigned must be a boolean", http.StatusBadRequest)
+				return
+			}
+			filter.Assigned = &assigned
+		}
+		var aboveThreshold *bool
+		if v := q.Get("above_threshold"); v != "" {
+			above, err := strconv.ParseBool(v)
+			if err != nil {
+				s.logger.Info(`[SdCards] Can't parse above_threshold`)
+				http.Error(w, "above_threshold must be a boolean", http.StatusBadRequest)
+				return
+			}
+			aboveThreshold = &above
+		}
+
+		sdInfos, err := s.storage.SdCard().Select(filter)
+		if err != nil {
+			s.logger.Info(`[SdCards] Error while fetching sd cards`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		sdCards := []sdCard{}
+		for i := range sdInfos {
+			sd := s.sdCard(&sdInfos[i])
+			if aboveThreshold == nil || sd.AboveThreshold == *aboveThreshold {
+				sdCards = append(sdCards, sd)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sdCards)
+	}
+}
+
+func (s *Server) handleSdCard() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[SdCard] Can't parse sd card id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		sd, err := s.storage.SdCard().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[SdCard] Sd card not found`)
+			http.Error(w, "Sd card not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[SdCard] Error while fetching sd card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.sdCard(sd))
+	}
+}
+
+// handleSdCardUsage returns the fill level of a card at each report, for
+// charting. from and to are RFC 3339 times.
+func (s *Server) handleSdCardUsage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[SdCardUsage] Can't parse sd card id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var from, to time.Time
+		if v := r.URL.Query().Get("from"); v != "" {
+			if from, err = time.Parse(time.RFC3339, v); err != nil {
+				s.logger.Info(`[SdCardUsage] Can't parse from`)
+				http.Error(w, "from must be an RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := r.URL.Query().Get("to"); v != "" {
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				s.logger.Info(`[SdCardUsage] Can't parse to`)
+				http.Error(w, "to must be an RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+		}
+
+		_, err = s.storage.SdCard().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[SdCardUsage] Sd card not found`)
+			http.Error(w, "Sd card not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[SdCardUsage] Error while fetching sd card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		usage, err := s.storage.SdCard().SelectUsage(id, from, to)
+		if err != nil {
+			s.logger.Info(`[SdCardUsage] Error while fetching usage`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if usage == nil {
+			usage = []models.SdUsage{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(usage)
+	}
+}
+
+// handleDeleteSdCard deletes a card that is not in a phone, with its usage
+// history.
+func (s *Server) handleDeleteSdCard() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[DeleteSdCard] Can't parse sd card id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		sd, err := s.storage.SdCard().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[DeleteSdCard] Sd card not found`)
+			http.Error(w, "Sd card not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[DeleteSdCard] Error while fetching sd card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if sd.PhoneId != nil {
+			s.logger.Info(`[DeleteSdCard] Sd card is in a phone`)
+			http.Error(w, "Sd card is in a phone", http.StatusConflict)
+			return
+		}
+
+		if err := s.storage.SdCard().Delete(id); err != nil {
+			s.logger.Info(`[DeleteSdCard] Error while deleting sd card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
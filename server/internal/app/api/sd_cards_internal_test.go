@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_SdCards(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	other, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+
+	// Two reports of a filling card, then it moves to another phone.
+	st.SdCard().Create(&models.SdInfo{SdManufacturerId: "0x03", SerialNo: "A1", TotalSpace: 100, UsedSpace: 50, FreeSpace: 50}, p)
+	st.SdCard().Create(&models.SdInfo{SdManufacturerId: "0x03", SerialNo: "A1", TotalSpace: 100, UsedSpace: 95, FreeSpace: 5}, p)
+	st.SdCard().Create(&models.SdInfo{SdManufacturerId: "0x1b", SerialNo: "B2", TotalSpace: 100, UsedSpace: 10, FreeSpace: 90}, other)
+	st.SdCard().RemovePhoneId(other.Id)
+
+	tk := login(t, s, "manager@example.org")
+	list := func(query string) []sdCard {
+		t.Helper()
+		rec := serve(s, http.MethodGet, "/api/sd_cards"+query, "", tk.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var sdCards []sdCard
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&sdCards))
+		return sdCards
+	}
+
+	all := list("")
+	assert.Len(t, all, 2)
+	assert.Equal(t, 95, all[0].UsedSpace)
+	assert.True(t, all[0].AboveThreshold)
+	assert.Equal(t, 10.0, all[1].UsedPercent)
+	assert.False(t, all[1].AboveThreshold)
+
+	assert.Equal(t, "A1", list("?above_threshold=true")[0].SerialNo)
+	assert.Equal(t, "B2", list("?assigned=false")[0].SerialNo)
+	assert.Equal(t, "B2", list("?sd_manufacturer_id=0x1b")[0].SerialNo)
+	assert.Len(t, list("?phone_id=1"), 1)
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodGet, "/api/sd_cards?assigned=yes please", "", tk.AccessToken).Code)
+
+	rec := serve(s, http.MethodGet, "/api/sd_cards/1/usage", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var usage []models.SdUsage
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&usage))
+	assert.Len(t, usage, 2)
+	assert.Equal(t, []int{50, 95}, []int{usage[0].UsedSpace, usage[1].UsedSpace})
+
+	rec = serve(s, http.MethodGet, "/api/sd_cards/1/usage?from=2100-01-01T00:00:00Z", "", tk.AccessToken)
+	assert.Equal(t, "[]\n", rec.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/sd_cards/42", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodDelete, "/api/sd_cards/1", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodDelete, "/api/sd_cards/2", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/sd_cards/2/usage", "", tk.AccessToken).Code)
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+const refreshCookieName = "refresh_token"
+
+func (s *Server) createSession(u *models.User, r *http.Request) (*models.Session, string, error) {
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	session, err := s.storage.Session().Create(&models.Session{
+		UserId:    u.Id,
+		TokenHash: hash,
+		UserAgent: r.UserAgent(),
+		Ip:        r.RemoteAddr,
+		ExpiresAt: time.Now().Add(s.config.Auth.RefreshTokenTtl),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// writeTokens issues an access token for the session and sends it together
+// with the refresh token as cookies and in the response body.
+func (s *Server) writeTokens(w http.ResponseWriter, u *models.User, session *models.Session, refreshToken string) error {
+	type Response struct {
+		AccessToken    string    `json:"access_token"`
+		AccessExpires  time.Time `json:"access_token_expires_at"`
+		RefreshToken   string    `json:"refresh_token"`
+		RefreshExpires time.Time `json:"refresh_token_expires_at"`
+	}
+
+	expirationTime := time.Now().Add(s.config.Auth.AccessTokenTtl)
+
+	claims := &models.Claims{
+		Role:      u.Role,
+		SessionId: session.Id,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   u.Email,
+			ExpiresAt: expirationTime.Unix(),
+		},
+	}
+
+	tokenString, err := s.keys.Sign(claims)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:    "token",
+		Value:   tokenString,
+		Expires: expirationTime,
+		Path:    "/",
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    refreshToken,
+		Expires:  session.ExpiresAt,
+		Path:     "/api",
+		HttpOnly: true,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	return json.NewEncoder(w).Encode(Response{
+		AccessToken:    tokenString,
+		AccessExpires:  expirationTime,
+		RefreshToken:   refreshToken,
+		RefreshExpires: session.ExpiresAt,
+	})
+}
+
+func (s *Server) handleRefresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			s.logger.Info(`[Refresh] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.RefreshToken == "" {
+			if c, err := r.Cookie(refreshCookieName); err == nil {
+				req.RefreshToken = c.Value
+			}
+		}
+		if req.RefreshToken == "" {
+			s.logger.Info(`[Refresh] No refresh token`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		now := time.Now()
+		hash := auth.HashToken(req.RefreshToken)
+
+		session, err := s.storage.Session().SelectByTokenHash(hash)
+		if err != nil {
+			s.logger.Info(`[Refresh] Unknown refresh token`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// A rotated-out token being presented again means it was copied:
+		// end the session for both parties.
+		if session.TokenHash != hash {
+			s.logger.Info(fmt.Sprintf(`[Refresh] Refresh token reuse, revoking session %d`, session.Id))
+			if err := s.storage.Session().Revoke(session.Id, now); err != nil {
+				s.logger.Error(err)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if !session.IsActive(now) {
+			s.logger.Info(`[Refresh] Session is revoked or expired`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.storage.User().SelectById(session.UserId)
+		if err != nil {
+			s.logger.Info(`[Refresh] Error while fetching user`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		refreshToken, newHash, err := auth.NewRefreshToken()
+		if err != nil {
+			s.logger.Info(`[Refresh] Error while generating refresh token`)
+			s.logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		session.ExpiresAt = now.Add(s.config.Auth.RefreshTokenTtl)
+		if err := s.storage.Session().Rotate(session.Id, hash, newHash, session.ExpiresAt, now); err != nil {
+			s.logger.Info(`[Refresh] Error while rotating refresh token`)
+			s.logger.Error(err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := s.writeTokens(w, user, session, refreshToken); err != nil {
+			s.logger.Info(`[Refresh] Error while generating jwt`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			http.Error(w, "could not generate token", http.StatusInternalServerError)
+			return
+		}
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+func (s *Server) handleSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[Sessions] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		userId := user.Id
+		if v := r.URL.Query().Get("user_id"); v != "" {
+			if !auth.HasPermission(user.Role, auth.PermSessionsManage) {
+				s.logger.Info(`[Sessions] Current user have not permission`)
+				s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if userId, err = strconv.Atoi(v); err != nil {
+				s.logger.Info(`[Sessions] Can't parse user id`)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		sessions, err := s.storage.Session().SelectActiveByUserId(userId, time.Now())
+		if err != nil {
+			s.logger.Info(`[Sessions] Error while fetching sessions`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if sessions == nil {
+			sessions = []models.Session{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sessions)
+	}
+}
+
+func (s *Server) handleRevokeSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[RevokeSession] Can't parse session id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.currentUser(r)
+		if err != nil {
+			s.logger.Info(`[RevokeSession] Error while fetching current user`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		session, err := s.storage.Session().SelectById(id)
+		if err != nil {
+			s.logger.Info(`[RevokeSession] Error while fetching session`)
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+
+		if session.UserId != user.Id && !auth.HasPermission(user.Role, auth.PermSessionsManage) {
+			s.logger.Info(`[RevokeSession] Current user have not permission`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, "no permission", http.StatusForbidden))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if err := s.storage.Session().Revoke(id, time.Now()); err != nil {
+			s.logger.Info(`[RevokeSession] Error while revoking session`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/app/auth"
+	"server/internal/app/config"
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func testServer(t *testing.T) (*Server, *memstorage.Storage) {
+	t.Helper()
+
+	c := config.NewConfig()
+	c.Auth.Keys = []auth.KeyConfig{{Id: "test", Secret: "test-secret"}}
+
+	st := memstorage.New()
+	s := New(c, st)
+	if err := s.configureAuth(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.configureOtp(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.configureTelemetry(); err != nil {
+		t.Fatal(err)
+	}
+	s.configureRouter()
+
+	return s, st
+}
+
+func createTestUser(t *testing.T, st *memstorage.Storage, email, role string) *models.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := st.User().Create(&models.User{Email: email, Name: email, Password: string(hash), Role: role})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+func serve(s *Server, method, target, body, accessToken string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, target, strings.NewReader(body))
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func login(t *testing.T, s *Server, email string) tokens {
+	t.Helper()
+
+	rec := serve(s, http.MethodPost, "/api/login", `{"email":"`+email+`","password":"password"}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login failed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var tk tokens
+	if err := json.NewDecoder(rec.Body).Decode(&tk); err != nil {
+		t.Fatal(err)
+	}
+
+	return tk
+}
+
+func TestApi_RefreshRotation(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+
+	tk := login(t, s, "user@example.org")
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/api/sessions", "", tk.AccessToken).Code)
+
+	rec := serve(s, http.MethodPost, "/api/refresh", `{"refresh_token":"`+tk.RefreshToken+`"}`, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var rotated tokens
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&rotated))
+	assert.NotEqual(t, tk.RefreshToken, rotated.RefreshToken)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/api/sessions", "", rotated.AccessToken).Code)
+
+	// Replaying the old refresh token revokes the whole session.
+	rec = serve(s, http.MethodPost, "/api/refresh", `{"refresh_token":"`+tk.RefreshToken+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(s, http.MethodGet, "/api/sessions", "", rotated.AccessToken).Code)
+	rec = serve(s, http.MethodPost, "/api/refresh", `{"refresh_token":"`+rotated.RefreshToken+`"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestApi_RevokeSession(t *testing.T) {
+	s, st := testServer(t)
+	u := createTestUser(t, st, "user@example.org", auth.RoleTester)
+	createTestUser(t, st, "other@example.org", auth.RoleTester)
+
+	laptop := login(t, s, "user@example.org")
+	phone := login(t, s, "user@example.org")
+	other := login(t, s, "other@example.org")
+
+	sessions, _ := st.Session().SelectActiveByUserId(u.Id, time.Now())
+	assert.Len(t, sessions, 2)
+
+	var phoneSession int
+	for _, session := range sessions {
+		if session.TokenHash == auth.HashToken(phone.RefreshToken) {
+			phoneSession = session.Id
+		}
+	}
+	target := "/api/sessions/" + strconv.Itoa(phoneSession) + "/revoke"
+
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost, target, "", other.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPost, target, "", laptop.AccessToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(s, http.MethodGet, "/api/sessions", "", phone.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/api/sessions", "", laptop.AccessToken).Code)
+}
+
+func TestApi_DeletedUserIsLoggedOut(t *testing.T) {
+	s, st := testServer(t)
+	u := createTestUser(t, st, "user@example.org", auth.RoleTester)
+
+	tk := login(t, s, "user@example.org")
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/api/user", "", tk.AccessToken).Code)
+
+	assert.NoError(t, st.User().Delete(u.Id))
+	assert.Equal(t, http.StatusUnauthorized, serve(s, http.MethodGet, "/api/user", "", tk.AccessToken).Code)
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// simPatch is the body of SIM updates. Absent fields are left unchanged;
+// the phone number and phone are reported by the agents and can't be edited.
+type simPatch struct {
+	Operator *string         `json:"operator"`
+	Notes    *string         `json:"notes"`
+	Tags     *[]string       `json:"tags"`
+	Plan     *models.SimPlan `json:"plan"`
+}
+
+func (p *simPatch) apply(sim *models.SimInfo) {
+	if p.Operator != nil {
+		sim.Operator = *p.Operator
+	}
+	if p.Notes != nil {
+		sim.Notes = *p.Notes
+	}
+	if p.Tags != nil {
+		sim.Tags = normalizeTags(*p.Tags)
+	}
+	if p.Plan != nil {
+		sim.Plan = *p.Plan
+	}
+}
+
+// normalizeTags trims tags and drops empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized
+}
+
+func (s *Server) handleSims() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := models.SimFilter{
+			Operator: r.URL.Query().Get("operator"),
+			Tag:      r.URL.Query().Get("tag"),
+		}
+		if v := r.URL.Query().Get("assigned"); v != "" {
+			assigned, err := strconv.ParseBool(v)
+			if err != nil {
+				s.logger.Info(`[Sims] Can't parse assigned`)
+				http.Error(w, "assigned must be a boolean", http.StatusBadRequest)
+				return
+			}
+			filter.Assigned = &assigned
+		}
+
+		sims, err := s.storage.Sim().Select(filter)
+		if err != nil {
+			s.logger.Info(`[Sims] Error while fetching sim cards`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if sims == nil {
+			sims = []models.SimInfo{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sims)
+	}
+}
+
+func (s *Server) handleSim() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[Sim] Can't parse sim id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		sim, err := s.storage.Sim().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[Sim] Sim card not found`)
+			http.Error(w, "Sim card not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[Sim] Error while fetching sim card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sim)
+	}
+}
+
+// handleRegisterSim adds a SIM that is on the shelf rather than in a phone.
+// Once a phone reports it, it is assigned to that phone.
+func (s *Server) handleRegisterSim() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Request struct {
+			PhoneNumber string `json:"phone_number"`
+			simPatch
+		}
+
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Info(`[RegisterSim] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sim := &models.SimInfo{PhoneNumber: strings.TrimSpace(req.PhoneNumber), Tags: []string{}}
+		if sim.PhoneNumber == "" {
+			s.logger.Info(`[RegisterSim] Phone number is missing`)
+			http.Error(w, "phone_number is required", http.StatusBadRequest)
+			return
+		}
+		req.apply(sim)
+
+		sim, err := s.storage.Sim().Register(sim)
+		if err == storage.ErrRecordExists {
+			s.logger.Info(`[RegisterSim] Sim card already exists`)
+			http.Error(w, "Sim card with this phone number already exists", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[RegisterSim] Error while creating sim card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sim)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusCreated))
+	}
+}
+
+func (s *Server) handleUpdateSim() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[UpdateSim] Can't parse sim id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var patch simPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			s.logger.Info(`[UpdateSim] Error while decoding json`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sim, err := s.storage.Sim().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[UpdateSim] Sim card not found`)
+			http.Error(w, "Sim card not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[UpdateSim] Error while fetching sim card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		patch.apply(sim)
+		if err := s.storage.Sim().Update(sim); err != nil {
+			s.logger.Info(`[UpdateSim] Error while updating sim card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sim)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
+
+// handleDeleteSim deletes a SIM that is not in a phone; the next report of a
+// phone would bring it back otherwise.
+func (s *Server) handleDeleteSim() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[DeleteSim] Can't parse sim id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		sim, err := s.storage.Sim().SelectById(id)
+		if err == storage.ErrRecordNotFound {
+			s.logger.Info(`[DeleteSim] Sim card not found`)
+			http.Error(w, "Sim card not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.logger.Info(`[DeleteSim] Error while fetching sim card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if sim.PhoneId != nil {
+			s.logger.Info(`[DeleteSim] Sim card is in a phone`)
+			http.Error(w, "Sim card is in a phone", http.StatusConflict)
+			return
+		}
+
+		if err := s.storage.Sim().Delete(id); err != nil {
+			s.logger.Info(`[DeleteSim] Error while deleting sim card`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Sims(t *testing.T) {
+	s, st := testServer(t)
+	createTestUser(t, st, "viewer@example.org", auth.RoleViewer)
+	createTestUser(t, st, "manager@example.org", auth.RoleLabManager)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	slot := 0
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS", Slot: &slot}, p)
+
+	viewer := login(t, s, "viewer@example.org")
+	manager := login(t, s, "manager@example.org")
+
+	list := func(query string) []models.SimInfo {
+		t.Helper()
+		rec := serve(s, http.MethodGet, "/api/sims"+query, "", viewer.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var sims []models.SimInfo
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&sims))
+		return sims
+	}
+
+	body := `{"phone_number":"79001112233","operator":"Beeline","tags":["otp"," otp ","roaming"],
+			  "plan":{"name":"Unlimited","contract_owner":"QA","monthly_limit":"20 GB"}}`
+	assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost, "/api/sims", body, viewer.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodPost, "/api/sims", `{"operator":"MTS"}`, manager.AccessToken).Code)
+	rec := serve(s, http.MethodPost, "/api/sims", body, manager.AccessToken)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var created models.SimInfo
+	json.NewDecoder(rec.Body).Decode(&created)
+	assert.Nil(t, created.PhoneId)
+	assert.Equal(t, []string{"otp", "roaming"}, created.Tags)
+	assert.Equal(t, "QA", created.Plan.ContractOwner)
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodPost, "/api/sims", body, manager.AccessToken).Code)
+
+	assert.Len(t, list(""), 2)
+	assert.Equal(t, "79001112233", list("?assigned=false")[0].PhoneNumber)
+	assert.Equal(t, "79889484608", list("?assigned=true")[0].PhoneNumber)
+	assert.Len(t, list("?operator=MTS"), 1)
+	assert.Len(t, list("?tag=roaming"), 1)
+	assert.Empty(t, list("?tag=esim"))
+
+	rec = serve(s, http.MethodPatch, "/api/sims/1", `{"notes":"Taped to the back","tags":["otp"]}`, manager.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = serve(s, http.MethodGet, "/api/sims/1", "", viewer.AccessToken)
+	var sim models.SimInfo
+	json.NewDecoder(rec.Body).Decode(&sim)
+	assert.Equal(t, "MTS", sim.Operator)
+	assert.Equal(t, "Taped to the back", sim.Notes)
+	assert.Equal(t, []string{"otp"}, sim.Tags)
+
+	// A new report keeps what lab staff entered.
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS", Slot: &slot}, p)
+	sim2, _ := st.Sim().SelectById(1)
+	assert.Equal(t, "Taped to the back", sim2.Notes)
+
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/sims/42", "", viewer.AccessToken).Code)
+	assert.Equal(t, http.StatusConflict, serve(s, http.MethodDelete, "/api/sims/1", "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodDelete, "/api/sims/2", "", manager.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodDelete, "/api/sims/2", "", manager.AccessToken).Code)
+}
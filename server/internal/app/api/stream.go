@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/hub"
+	"server/internal/app/websocket"
+	"strconv"
+	"time"
+)
+
+// streamHistory is how many notifications are kept for resuming streams.
+const streamHistory = 1000
+
+const streamKeepAlive = 15 * time.Second
+
+func streamFilter(r *http.Request) hub.Filter {
+	q := r.URL.Query()
+
+	return hub.Filter{
+		ModelNumber: q.Get("model_number"),
+		PhoneNumber: q.Get("phone_number"),
+		Sender:      q.Get("sender"),
+	}
+}
+
+// lastEventId reads the id of the last notification a client has seen, from
+// the header sent by reconnecting EventSources or from the query.
+func lastEventId(r *http.Request) int {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	id, _ := strconv.Atoi(v)
+
+	return id
+}
+
+func (s *Server) handleNotificationStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			s.logger.Info(`[NotificationStream] Streaming is not supported`)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		sub := s.hub.Subscribe(streamFilter(r), lastEventId(r))
+		defer s.hub.Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusOK))
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case n, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(n)
+				if err != nil {
+					s.logger.Error(err)
+					return
+				}
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", n.Id, data); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) handleNotificationSocket() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, lastId := streamFilter(r), lastEventId(r)
+
+		conn, err := websocket.Upgrade(w, r, s.config.AllowedOrigins)
+		if err != nil {
+			s.logger.Info(`[NotificationSocket] Error while upgrading connection`)
+			s.logger.Error(err)
+			return
+		}
+		defer conn.Close()
+		s.logger.Info(fmt.Sprintf(`%s %s%s %d`, r.Method, r.Host, r.RequestURI, http.StatusSwitchingProtocols))
+
+		sub := s.hub.Subscribe(filter, lastId)
+		defer s.hub.Unsubscribe(sub)
+
+		closed := make(chan struct{})
+		go func() {
+			conn.ReadLoop()
+			close(closed)
+		}()
+
+		for {
+			select {
+			case n, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(n)
+				if err != nil {
+					s.logger.Error(err)
+					return
+				}
+				if err := conn.WriteText(data); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}
+}
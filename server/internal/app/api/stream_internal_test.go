@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"server/internal/app/websocket"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func streamTestServer(t *testing.T) (*Server, *httptest.Server, tokens, string) {
+	t.Helper()
+
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS"}, p)
+
+	tk := login(t, s, "user@example.org")
+	id, deviceToken := enroll(t, s, tk.AccessToken)
+	st.Enrollment().BindPhone(id, p.Id)
+
+	ts := httptest.NewServer(s.router)
+	t.Cleanup(ts.Close)
+
+	return s, ts, tk, deviceToken
+}
+
+// readEvent reads one server-sent event, skipping keep-alive comments.
+func readEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+
+	event := make(map[string]string)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(event) > 0 {
+				return event
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		k, v, _ := strings.Cut(line, ": ")
+		event[k] = v
+	}
+}
+
+func TestApi_NotificationStream(t *testing.T) {
+	s, ts, tk, deviceToken := streamTestServer(t)
+
+	open := func(lastEventId string) (*bufio.Reader, func()) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/notifications/stream?sender=900", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tk.AccessToken})
+		if lastEventId != "" {
+			req.Header.Set("Last-Event-ID", lastEventId)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		return bufio.NewReader(resp.Body), func() { resp.Body.Close() }
+	}
+
+	r, closeStream := open("")
+	serveDevice(s, "/api/new_notification", `{"sender":"Bank","body":"Balance: 100"}`, deviceToken)
+	serveDevice(s, "/api/new_notification", `{"sender":"900","body":"Your code: 1111"}`, deviceToken)
+
+	event := readEvent(t, r)
+	assert.Equal(t, "notification", event["event"])
+	var n models.Notification
+	assert.NoError(t, json.Unmarshal([]byte(event["data"]), &n))
+	assert.Equal(t, "1111", n.Code)
+	assert.Equal(t, "79889484608", n.PhoneNumber)
+	closeStream()
+
+	serveDevice(s, "/api/new_notification", `{"sender":"900","body":"Your code: 2222"}`, deviceToken)
+
+	// A reconnecting client gets what it missed.
+	r, closeStream = open(event["id"])
+	defer closeStream()
+	event = readEvent(t, r)
+	assert.NoError(t, json.Unmarshal([]byte(event["data"]), &n))
+	assert.Equal(t, "2222", n.Code)
+}
+
+func TestApi_NotificationStreamUnauthorized(t *testing.T) {
+	_, ts, _, _ := streamTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/api/notifications/stream")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestApi_NotificationSocket(t *testing.T) {
+	s, ts, tk, deviceToken := streamTestServer(t)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	io.WriteString(conn, "GET /api/notifications/ws?phone_number=79889484608 HTTP/1.1\r\n"+
+		"Host: "+strings.TrimPrefix(ts.URL, "http://")+"\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: "+key+"\r\nSec-WebSocket-Version: 13\r\n"+
+		"Authorization: Bearer "+tk.AccessToken+"\r\n\r\n")
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, websocket.AcceptKey(key), resp.Header.Get("Sec-WebSocket-Accept"))
+
+	serveDevice(s, "/api/new_notification", `{"sender":"900","body":"Your code: 3333"}`, deviceToken)
+
+	var head [2]byte
+	_, err = io.ReadFull(r, head[:])
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x81), head[0])
+	length := int(head[1])
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	assert.NoError(t, err)
+
+	var n models.Notification
+	assert.NoError(t, json.Unmarshal(payload, &n))
+	assert.Equal(t, "3333", n.Code)
+}
+
+func TestApi_NotificationSocketCrossOrigin(t *testing.T) {
+	_, ts, tk, _ := streamTestServer(t)
+
+	handshake := func(origin string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/notifications/ws", nil)
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Origin", origin)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tk.AccessToken})
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusForbidden, handshake("https://evil.example.com"))
+	assert.Equal(t, http.StatusSwitchingProtocols, handshake(ts.URL))
+	assert.Equal(t, http.StatusSwitchingProtocols, handshake("http://localhost:9111"))
+}
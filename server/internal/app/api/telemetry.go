@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"server/internal/app/telemetry"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultTelemetryRange is the range of a series when from is not given.
+const defaultTelemetryRange = 24 * time.Hour
+
+// handleTelemetry returns a metric of a phone for charting. from and to are
+// RFC 3339 times and default to the last day.
+func (s *Server) handleTelemetry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		type Response struct {
+			Metric string `json:"metric"`
+			// Step is the width of the points in seconds.
+			Step   int64                   `json:"step"`
+			Points []models.TelemetryPoint `json:"points"`
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.logger.Info(`[Telemetry] Can't parse phone id`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusBadRequest))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		metric := r.URL.Query().Get("metric")
+		if !telemetry.IsMetric(metric) {
+			s.logger.Info(`[Telemetry] Unknown metric`)
+			http.Error(w, "metric must be one of "+strings.Join(telemetry.Metrics, ", "), http.StatusBadRequest)
+			return
+		}
+
+		to := time.Now()
+		if v := r.URL.Query().Get("to"); v != "" {
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				s.logger.Info(`[Telemetry] Can't parse to`)
+				http.Error(w, "to must be an RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+		}
+		from := to.Add(-defaultTelemetryRange)
+		if v := r.URL.Query().Get("from"); v != "" {
+			if from, err = time.Parse(time.RFC3339, v); err != nil {
+				s.logger.Info(`[Telemetry] Can't parse from`)
+				http.Error(w, "from must be an RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+		}
+		if !from.Before(to) {
+			s.logger.Info(`[Telemetry] Empty range`)
+			http.Error(w, "from must be before to", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := s.storage.Phone().SelectById(id); err == storage.ErrRecordNotFound {
+			s.logger.Info(`[Telemetry] Phone not found`)
+			http.Error(w, "Phone not found", http.StatusNotFound)
+			return
+		}
+
+		step, points, err := s.telemetry.Series(id, metric, from, to)
+		if err != nil {
+			s.logger.Info(`[Telemetry] Error while fetching series`)
+			s.logger.Error(fmt.Sprintf(`%s %d`, err, http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if points == nil {
+			points = []models.TelemetryPoint{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Response{Metric: metric, Step: int64(step / time.Second), Points: points})
+	}
+}
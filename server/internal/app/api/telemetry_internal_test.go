@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApi_Telemetry(t *testing.T) {
+	// Reports are enriched from the data directory of the server root.
+	wd, _ := os.Getwd()
+	if err := os.Chdir("../../.."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	s, st := testServer(t)
+	createTestUser(t, st, "user@example.org", auth.RoleTester)
+
+	tk := login(t, s, "user@example.org")
+	_, deviceToken := enroll(t, s, tk.AccessToken)
+	report := `{"phone_info":{"model_number":"SM-A525F"},"battery":{"level":64,"temperature":46.5,"health":"overheat","cycle_count":812}}`
+	assert.Equal(t, http.StatusOK, serveDevice(s, "/api/phone_info", report, deviceToken).Code)
+
+	events, _ := st.Event().Select(models.EventFilter{Type: models.EventTelemetryAlert})
+	assert.Len(t, events, 1)
+
+	type series struct {
+		Metric string                  `json:"metric"`
+		Step   int64                   `json:"step"`
+		Points []models.TelemetryPoint `json:"points"`
+	}
+	rec := serve(s, http.MethodGet, "/api/phones/1/telemetry?metric=battery_cycle_count", "", tk.AccessToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var res series
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	assert.Equal(t, int64(300), res.Step)
+	if assert.Len(t, res.Points, 1) {
+		assert.Equal(t, 812.0, res.Points[0].Avg)
+	}
+
+	to := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	rec = serve(s, http.MethodGet, "/api/phones/1/telemetry?metric=battery_level&to="+to, "", tk.AccessToken)
+	assert.Contains(t, rec.Body.String(), `"points":[]`)
+
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodGet, "/api/phones/1/telemetry", "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(s, http.MethodGet, "/api/phones/1/telemetry?metric=battery_level&from="+to+"&to="+to, "", tk.AccessToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(s, http.MethodGet, "/api/phones/42/telemetry?metric=battery_level", "", tk.AccessToken).Code)
+}
@@ -0,0 +1,206 @@
+// Package audit matches the codes scanned during a physical inventory with
+// the phones, SIM and SD cards on record, and reconciles the two.
+package audit
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strings"
+)
+
+// Inventory is what the records say should exist.
+type Inventory struct {
+	Phones  []models.Phone
+	Sims    []models.SimInfo
+	SdCards []models.SdInfo
+}
+
+func Load(st storage.Storage) (*Inventory, error) {
+	phones, err := st.Phone().SelectAll()
+	if err != nil {
+		return nil, err
+	}
+	sims, err := st.Sim().SelectAll()
+	if err != nil {
+		return nil, err
+	}
+	sdCards, err := st.SdCard().SelectAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Inventory{Phones: phones, Sims: sims, SdCards: sdCards}, nil
+}
+
+func phoneItem(p *models.Phone) models.AuditItem {
+	return models.AuditItem{Kind: models.AssetPhone, Id: p.Id, AssetTag: p.AssetTag(), Code: p.InventoryTag, Name: p.Name()}
+}
+
+func simItem(sim *models.SimInfo) models.AuditItem {
+	return models.AuditItem{Kind: models.AssetSim, Id: sim.Id, AssetTag: sim.AssetTag(), Code: sim.PhoneNumber, Name: sim.PhoneNumber}
+}
+
+func sdItem(sd *models.SdInfo) models.AuditItem {
+	return models.AuditItem{Kind: models.AssetSdCard, Id: sd.Id, AssetTag: sd.AssetTag(), Code: sd.SerialNo, Name: sd.SerialNo}
+}
+
+// Items returns the items of a kind, or all of them for an empty kind.
+func (inv *Inventory) Items(kind string) []models.AuditItem {
+	var items []models.AuditItem
+
+	if kind == "" || kind == models.AssetPhone {
+		for i := range inv.Phones {
+			items = append(items, phoneItem(&inv.Phones[i]))
+		}
+	}
+	if kind == "" || kind == models.AssetSim {
+		for i := range inv.Sims {
+			items = append(items, simItem(&inv.Sims[i]))
+		}
+	}
+	if kind == "" || kind == models.AssetSdCard {
+		for i := range inv.SdCards {
+			items = append(items, sdItem(&inv.SdCards[i]))
+		}
+	}
+
+	return items
+}
+
+// Match returns the item a scanned code stands for: the asset with that
+// tag, a phone by inventory tag, a SIM by phone number or an SD card by
+// serial number, compared without regard to case. A non-empty kind limits
+// the search to that kind.
+func (inv *Inventory) Match(code, kind string) (models.AuditItem, bool) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return models.AuditItem{}, false
+	}
+
+	if tagKind, id, ok := models.ParseAssetTag(code); ok && (kind == "" || kind == tagKind) {
+		for _, item := range inv.Items(tagKind) {
+			if item.Id == id {
+				return item, true
+			}
+		}
+	}
+
+	if kind == "" || kind == models.AssetPhone {
+		for i := range inv.Phones {
+			if strings.EqualFold(inv.Phones[i].InventoryTag, code) {
+				return phoneItem(&inv.Phones[i]), true
+			}
+		}
+	}
+	if kind == "" || kind == models.AssetSim {
+		for i := range inv.Sims {
+			if strings.EqualFold(inv.Sims[i].PhoneNumber, code) {
+				return simItem(&inv.Sims[i]), true
+			}
+		}
+	}
+	if kind == "" || kind == models.AssetSdCard {
+		for i := range inv.SdCards {
+			if strings.EqualFold(inv.SdCards[i].SerialNo, code) {
+				return sdItem(&inv.SdCards[i]), true
+			}
+		}
+	}
+
+	return models.AuditItem{}, false
+}
+
+type key struct {
+	kind string
+	id   int
+}
+
+// Reconcile compares the scans of an audit with the inventory. Every item on
+// record is either found or missing. Scans that matched nothing, or an item
+// deleted since, are unexpected. A found item is relocated when its last
+// scan says it was somewhere else than recorded.
+func (inv *Inventory) Reconcile(a models.Audit, scans []models.AuditScan) *models.AuditReport {
+	report := &models.AuditReport{
+		Audit:      a,
+		Expected:   len(inv.Phones) + len(inv.Sims) + len(inv.SdCards),
+		Found:      []models.AuditItem{},
+		Missing:    []models.AuditItem{},
+		Unexpected: []models.AuditScan{},
+		Relocated:  []models.AuditRelocation{},
+	}
+
+	known := make(map[key]bool, report.Expected)
+	for _, p := range inv.Phones {
+		known[key{models.AssetPhone, p.Id}] = true
+	}
+	for _, sim := range inv.Sims {
+		known[key{models.AssetSim, sim.Id}] = true
+	}
+	for _, sd := range inv.SdCards {
+		known[key{models.AssetSdCard, sd.Id}] = true
+	}
+
+	lastScans := make(map[key]*models.AuditScan)
+	for i := range scans {
+		scan := &scans[i]
+		if scan.ItemId == nil || !known[key{scan.Kind, *scan.ItemId}] {
+			report.Unexpected = append(report.Unexpected, *scan)
+			continue
+		}
+		lastScans[key{scan.Kind, *scan.ItemId}] = scan
+	}
+
+	for i := range inv.Phones {
+		p := &inv.Phones[i]
+		item := phoneItem(p)
+		scan := lastScans[key{item.Kind, item.Id}]
+		if scan == nil {
+			report.Missing = append(report.Missing, item)
+			continue
+		}
+		report.Found = append(report.Found, item)
+		if scan.SlotId != nil && !sameIntPtr(scan.SlotId, p.SlotId) {
+			report.Relocated = append(report.Relocated, models.AuditRelocation{
+				AuditItem:      item,
+				ExpectedSlotId: p.SlotId,
+				FoundSlotId:    scan.SlotId,
+			})
+		}
+	}
+
+	cards := make([]models.AuditItem, 0, len(inv.Sims)+len(inv.SdCards))
+	phoneIds := make([]*int, 0, cap(cards))
+	for i := range inv.Sims {
+		cards = append(cards, simItem(&inv.Sims[i]))
+		phoneIds = append(phoneIds, inv.Sims[i].PhoneId)
+	}
+	for i := range inv.SdCards {
+		cards = append(cards, sdItem(&inv.SdCards[i]))
+		phoneIds = append(phoneIds, inv.SdCards[i].PhoneId)
+	}
+	for i, item := range cards {
+		scan := lastScans[key{item.Kind, item.Id}]
+		if scan == nil {
+			report.Missing = append(report.Missing, item)
+			continue
+		}
+		report.Found = append(report.Found, item)
+		if scan.PhoneId != nil && !sameIntPtr(scan.PhoneId, phoneIds[i]) {
+			report.Relocated = append(report.Relocated, models.AuditRelocation{
+				AuditItem:       item,
+				ExpectedPhoneId: phoneIds[i],
+				FoundPhoneId:    scan.PhoneId,
+			})
+		}
+	}
+
+	return report
+}
+
+func sameIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
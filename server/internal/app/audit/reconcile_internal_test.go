@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventory_Match(t *testing.T) {
+	inv := &Inventory{
+		Phones:  []models.Phone{{Id: 1, ModelNumber: "SM-G973F/DS", InventoryTag: "QA-0001"}, {Id: 2, ModelNumber: "SM-A525F"}},
+		Sims:    []models.SimInfo{{Id: 1, PhoneNumber: "79889484608"}},
+		SdCards: []models.SdInfo{{Id: 1, SerialNo: "0x1a8ed52f"}},
+	}
+
+	item, ok := inv.Match(" qa-0001 ", "")
+	assert.True(t, ok)
+	assert.Equal(t, models.AuditItem{Kind: models.AssetPhone, Id: 1, AssetTag: "PH-000001", Code: "QA-0001", Name: "SM-G973F/DS"}, item)
+	item, ok = inv.Match("0x1A8ED52F", "")
+	assert.True(t, ok)
+	assert.Equal(t, models.AssetSdCard, item.Kind)
+
+	item, ok = inv.Match("sim-000001", "")
+	assert.True(t, ok)
+	assert.Equal(t, "79889484608", item.Code)
+	_, ok = inv.Match("PH-000042", "")
+	assert.False(t, ok)
+
+	_, ok = inv.Match("79889484608", models.AssetSdCard)
+	assert.False(t, ok)
+	// Phones without a tag can't be scanned.
+	_, ok = inv.Match("", "")
+	assert.False(t, ok)
+}
+
+func TestInventory_Reconcile(t *testing.T) {
+	slot1, slot2, phoneId := 1, 2, 1
+	inv := &Inventory{
+		Phones: []models.Phone{
+			{Id: 1, InventoryTag: "QA-0001", SlotId: &slot1},
+			{Id: 2, InventoryTag: "QA-0002", SlotId: &slot2},
+			{Id: 3, InventoryTag: "QA-0003"},
+		},
+		Sims:    []models.SimInfo{{Id: 1, PhoneNumber: "79889484608", PhoneId: &phoneId}},
+		SdCards: []models.SdInfo{{Id: 1, SerialNo: "0x1a8ed52f", PhoneId: &phoneId}},
+	}
+	id := func(v int) *int { return &v }
+	scans := []models.AuditScan{
+		{Id: 1, Kind: models.AssetPhone, ItemId: id(1), SlotId: &slot2},
+		// The last scan of an item tells where it is.
+		{Id: 2, Kind: models.AssetPhone, ItemId: id(1), SlotId: &slot1},
+		{Id: 3, Kind: models.AssetPhone, ItemId: id(3), SlotId: &slot2},
+		{Id: 4, Kind: models.AssetSim, ItemId: id(1)},
+		{Id: 5, Code: "QA-9999"},
+		{Id: 6, Kind: models.AssetPhone, ItemId: id(42)},
+	}
+
+	report := inv.Reconcile(models.Audit{Id: 1}, scans)
+	assert.Equal(t, 5, report.Expected)
+	assert.Len(t, report.Found, 3)
+	if assert.Len(t, report.Missing, 2) {
+		assert.Equal(t, 2, report.Missing[0].Id)
+		assert.Equal(t, models.AssetSdCard, report.Missing[1].Kind)
+	}
+	if assert.Len(t, report.Unexpected, 2) {
+		assert.Equal(t, 5, report.Unexpected[0].Id)
+		assert.Equal(t, 6, report.Unexpected[1].Id)
+	}
+	if assert.Len(t, report.Relocated, 1) {
+		assert.Equal(t, 3, report.Relocated[0].Id)
+		assert.Nil(t, report.Relocated[0].ExpectedSlotId)
+		assert.Equal(t, &slot2, report.Relocated[0].FoundSlotId)
+	}
+}
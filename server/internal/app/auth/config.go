@@ -0,0 +1,39 @@
+package auth
+
+import "time"
+
+type KeyConfig struct {
+	Id        string `toml:"id"`
+	Algorithm string `toml:"algorithm"`
+	// Secret or SecretEnv (the name of an environment variable holding the
+	// secret) configure HS256 keys.
+	Secret    string `toml:"secret"`
+	SecretEnv string `toml:"secret_env"`
+	// PrivateKeyFile and PublicKeyFile point to PEM files for RS256 and
+	// EdDSA keys. A key with only a public part can verify but not sign.
+	PrivateKeyFile string `toml:"private_key_file"`
+	PublicKeyFile  string `toml:"public_key_file"`
+}
+
+type Config struct {
+	// SigningKey is the id of the key used for new tokens. Every other key
+	// is only accepted for verification, which allows rotating keys without
+	// logging everybody out.
+	SigningKey string      `toml:"signing_key"`
+	Keys       []KeyConfig `toml:"keys"`
+	// AccessTokenTtl limits how long a stolen access token stays usable;
+	// RefreshTokenTtl is how long a session survives without being used.
+	AccessTokenTtl  time.Duration `toml:"access_token_ttl"`
+	RefreshTokenTtl time.Duration `toml:"refresh_token_ttl"`
+	// EnrollmentTokenTtl is how long a device enrollment token can be
+	// redeemed.
+	EnrollmentTokenTtl time.Duration `toml:"enrollment_token_ttl"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		AccessTokenTtl:     15 * time.Minute,
+		RefreshTokenTtl:    30 * 24 * time.Hour,
+		EnrollmentTokenTtl: 15 * time.Minute,
+	}
+}
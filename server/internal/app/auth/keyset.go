@@ -0,0 +1,200 @@
+// Package auth signs and verifies the JWTs issued at login.
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"server/internal/app/models"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var ErrUnknownKey = errors.New("token is signed with an unknown key")
+
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet holds every key tokens may be signed with. New tokens carry the id
+// of the signing key in the "kid" header.
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+}
+
+func NewKeySet(config *Config) (*KeySet, error) {
+	if len(config.Keys) == 0 {
+		return nil, errors.New("auth: no keys configured")
+	}
+
+	ks := &KeySet{
+		keys: make(map[string]*key),
+	}
+
+	for _, kc := range config.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("auth: key %q: %w", kc.Id, err)
+		}
+		if _, ok := ks.keys[k.id]; ok {
+			return nil, fmt.Errorf("auth: duplicate key id %q", k.id)
+		}
+		ks.keys[k.id] = k
+	}
+
+	signingId := config.SigningKey
+	if signingId == "" && len(config.Keys) == 1 {
+		signingId = config.Keys[0].Id
+	}
+	ks.signing = ks.keys[signingId]
+	if ks.signing == nil {
+		return nil, fmt.Errorf("auth: signing key %q is not configured", signingId)
+	}
+	if ks.signing.signKey == nil {
+		return nil, fmt.Errorf("auth: signing key %q has no private part", signingId)
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Parse verifies tokenString against the key named in its "kid" header.
+// Tokens without a kid, issued before key ids were introduced, are checked
+// against the signing key.
+func (ks *KeySet) Parse(tokenString string) (*models.Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
+		k := ks.signing
+		if kid, ok := token.Header["kid"]; ok {
+			id, _ := kid.(string)
+			if k = ks.keys[id]; k == nil {
+				return nil, ErrUnknownKey
+			}
+		}
+
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+
+		return k.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*models.Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+func loadKey(kc KeyConfig) (*key, error) {
+	if kc.Id == "" {
+		return nil, errors.New("id is required")
+	}
+
+	k := &key{id: kc.Id}
+
+	switch kc.Algorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		secret := kc.Secret
+		if kc.SecretEnv != "" {
+			secret = os.Getenv(kc.SecretEnv)
+			if secret == "" {
+				return nil, fmt.Errorf("environment variable %s is empty", kc.SecretEnv)
+			}
+		}
+		if secret == "" {
+			return nil, errors.New("secret is required for HS256")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey = []byte(secret)
+		k.verifyKey = []byte(secret)
+	case jwt.SigningMethodRS256.Alg():
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = priv
+			k.verifyKey = &priv.PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = pub
+		}
+	case SigningMethodEdDSA.Alg():
+		k.method = SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			priv, err := parsePEM(kc.PrivateKeyFile, x509.ParsePKCS8PrivateKey)
+			if err != nil {
+				return nil, err
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("private key is not an Ed25519 key")
+			}
+			k.signKey = edPriv
+			k.verifyKey = edPriv.Public()
+		}
+		if kc.PublicKeyFile != "" {
+			pub, err := parsePEM(kc.PublicKeyFile, x509.ParsePKIXPublicKey)
+			if err != nil {
+				return nil, err
+			}
+			edPub, ok := pub.(ed25519.PublicKey)
+			if !ok {
+				return nil, errors.New("public key is not an Ed25519 key")
+			}
+			k.verifyKey = edPub
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+
+	if k.verifyKey == nil {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	return k, nil
+}
+
+func parsePEM(path string, parse func([]byte) (any, error)) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	return parse(block.Bytes)
+}
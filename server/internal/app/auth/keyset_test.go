@@ -0,0 +1,177 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"server/internal/app/auth"
+	"server/internal/app/models"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClaims() *models.Claims {
+	return &models.Claims{
+		Role: "admin",
+		StandardClaims: jwt.StandardClaims{
+			Subject:   "admin@example.org",
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+	}
+}
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	old, err := auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{Id: "old", Secret: "old-secret"}},
+	})
+	assert.NoError(t, err)
+
+	token, err := old.Sign(testClaims())
+	assert.NoError(t, err)
+
+	rotated, err := auth.NewKeySet(&auth.Config{
+		SigningKey: "new",
+		Keys: []auth.KeyConfig{
+			{Id: "old", Secret: "old-secret"},
+			{Id: "new", Secret: "new-secret"},
+		},
+	})
+	assert.NoError(t, err)
+
+	claims, err := rotated.Parse(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@example.org", claims.Subject)
+
+	fresh, err := rotated.Sign(testClaims())
+	assert.NoError(t, err)
+
+	_, err = old.Parse(fresh)
+	assert.Error(t, err)
+
+	dropped, err := auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{Id: "new", Secret: "new-secret"}},
+	})
+	assert.NoError(t, err)
+
+	_, err = dropped.Parse(token)
+	assert.Error(t, err)
+	_, err = dropped.Parse(fresh)
+	assert.NoError(t, err)
+}
+
+func TestKeySet_SecretEnv(t *testing.T) {
+	t.Setenv("TEST_JWT_SECRET", "from-env")
+
+	ks, err := auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{Id: "env", SecretEnv: "TEST_JWT_SECRET"}},
+	})
+	assert.NoError(t, err)
+
+	token, err := ks.Sign(testClaims())
+	assert.NoError(t, err)
+
+	parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+		return []byte("from-env"), nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	_, err = auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{Id: "env", SecretEnv: "TEST_JWT_SECRET_MISSING"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestKeySet_RS256(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	pubDer, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	assert.NoError(t, err)
+
+	signer, err := auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{
+			Id:             "rsa",
+			Algorithm:      "RS256",
+			PrivateKeyFile: writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(priv)),
+		}},
+	})
+	assert.NoError(t, err)
+
+	verifier, err := auth.NewKeySet(&auth.Config{
+		SigningKey: "hmac",
+		Keys: []auth.KeyConfig{
+			{Id: "hmac", Secret: "secret"},
+			{Id: "rsa", Algorithm: "RS256", PublicKeyFile: writePEM(t, "rsa.pub", "PUBLIC KEY", pubDer)},
+		},
+	})
+	assert.NoError(t, err)
+
+	token, err := signer.Sign(testClaims())
+	assert.NoError(t, err)
+
+	_, err = verifier.Parse(token)
+	assert.NoError(t, err)
+}
+
+func TestKeySet_EdDSA(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	privDer, err := x509.MarshalPKCS8PrivateKey(priv)
+	assert.NoError(t, err)
+	pubDer, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NoError(t, err)
+
+	signer, err := auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{
+			Id:             "ed",
+			Algorithm:      "EdDSA",
+			PrivateKeyFile: writePEM(t, "ed.pem", "PRIVATE KEY", privDer),
+		}},
+	})
+	assert.NoError(t, err)
+
+	token, err := signer.Sign(testClaims())
+	assert.NoError(t, err)
+
+	_, err = signer.Parse(token)
+	assert.NoError(t, err)
+
+	_, err = auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{Id: "ed", Algorithm: "EdDSA", PublicKeyFile: writePEM(t, "ed.pub", "PUBLIC KEY", pubDer)}},
+	})
+	assert.Error(t, err, "verify-only key cannot be the signing key")
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	ks, err := auth.NewKeySet(&auth.Config{
+		Keys: []auth.KeyConfig{{Id: "k", Secret: "secret"}},
+	})
+	assert.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, testClaims())
+	token.Header["kid"] = "k"
+	signed, err := token.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	_, err = ks.Parse(signed)
+	assert.Error(t, err)
+}
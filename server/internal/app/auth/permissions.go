@@ -0,0 +1,95 @@
+package auth
+
+type Permission string
+
+const (
+	PermDevicesRead        Permission = "devices:read"
+	PermDevicesEnroll      Permission = "devices:enroll"
+	PermDevicesManage      Permission = "devices:manage"
+	PermPhonesDelete       Permission = "phones:delete"
+	PermPhonesReserve      Permission = "phones:reserve"
+	PermReservationsManage Permission = "reservations:manage"
+	PermLeasesUse          Permission = "leases:use"
+	PermNotificationsRead  Permission = "notifications:read"
+	PermNotificationsPurge Permission = "notifications:purge"
+	PermForwardingManage   Permission = "forwarding:manage"
+	PermUsersRead          Permission = "users:read"
+	PermUsersDelete        Permission = "users:delete"
+	PermUsersManageRoles   Permission = "users:manage_roles"
+	PermSessionsManage     Permission = "sessions:manage"
+)
+
+const (
+	RoleViewer     = "viewer"
+	RoleTester     = "tester"
+	RoleLabManager = "lab-manager"
+	RoleAdmin      = "admin"
+
+	// DefaultRole is given to users created through registration.
+	DefaultRole = RoleTester
+)
+
+// Roles lists every known role, from the least to the most privileged.
+var Roles = []string{RoleViewer, RoleTester, RoleLabManager, RoleAdmin}
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer: {
+		PermDevicesRead,
+	},
+	RoleTester: {
+		PermDevicesRead,
+		PermDevicesEnroll,
+		PermPhonesReserve,
+		PermLeasesUse,
+		PermNotificationsRead,
+	},
+	RoleLabManager: {
+		PermDevicesRead,
+		PermDevicesEnroll,
+		PermDevicesManage,
+		PermPhonesReserve,
+		PermLeasesUse,
+		PermNotificationsRead,
+		PermPhonesDelete,
+		PermReservationsManage,
+		PermForwardingManage,
+		PermUsersRead,
+	},
+	RoleAdmin: {
+		PermDevicesRead,
+		PermDevicesEnroll,
+		PermDevicesManage,
+		PermPhonesReserve,
+		PermLeasesUse,
+		PermNotificationsRead,
+		PermNotificationsPurge,
+		PermPhonesDelete,
+		PermReservationsManage,
+		PermForwardingManage,
+		PermUsersRead,
+		PermUsersDelete,
+		PermUsersManageRoles,
+		PermSessionsManage,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants p. Unknown roles grant nothing.
+func HasPermission(role string, p Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PermissionsOf returns the permissions granted to role.
+func PermissionsOf(role string) []Permission {
+	return append([]Permission(nil), rolePermissions[role]...)
+}
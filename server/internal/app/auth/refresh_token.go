@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewRefreshToken returns an opaque refresh token and the hash that is
+// stored server side in its place.
+func NewRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashToken(token), nil
+}
+
+// NewDeviceToken returns the long-lived credential of an enrolled phone and
+// its hash.
+func NewDeviceToken() (string, string, error) {
+	return NewRefreshToken()
+}
+
+// NewEnrollmentToken returns a short one-time token and its hash. It is meant
+// to be typed on the phone, so it only uses upper case letters and digits.
+func NewEnrollmentToken() (string, string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base32.StdEncoding.EncodeToString(b)
+
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -1,17 +1,57 @@
 package config
 
-import "server/internal/app/storage"
+import (
+	"server/internal/app/auth"
+	"server/internal/app/forwarding"
+	"server/internal/app/labels"
+	"server/internal/app/lease"
+	"server/internal/app/otp"
+	"server/internal/app/presence"
+	"server/internal/app/retention"
+	"server/internal/app/storage"
+	"server/internal/app/telemetry"
+	"time"
+)
 
 type Config struct {
-	BindAddr string `toml:"bind_addr"`
-	LogLevel string `toml:"log_level"`
-	Storage  *storage.DbConfig
+	BindAddr    string `toml:"bind_addr"`
+	LogLevel    string `toml:"log_level"`
+	AutoMigrate bool   `toml:"auto_migrate"`
+	// DedupWindow is how far apart the timestamps of identical notifications
+	// from agents that send no idempotency key may be to count as duplicates.
+	DedupWindow time.Duration `toml:"dedup_window"`
+	// SdUsageThreshold is the fill level, in percent, above which SD cards
+	// are flagged.
+	SdUsageThreshold float64 `toml:"sd_usage_threshold"`
+	// AllowedOrigins are the pages, besides the server's own, that may open
+	// websockets with the login cookie.
+	AllowedOrigins []string `toml:"allowed_origins"`
+	Storage        *storage.DbConfig
+	Lease          *lease.Config
+	Auth           *auth.Config
+	Otp            *otp.Config
+	Forwarding     *forwarding.Config
+	Retention      *retention.Config
+	Presence       *presence.Config
+	Telemetry      *telemetry.Config
+	Labels         *labels.Config
 }
 
 func NewConfig() *Config {
 	return &Config{
-		BindAddr: ":8080",
-		LogLevel: "debug",
-		Storage:  storage.NewConfig(),
+		BindAddr:         ":8080",
+		LogLevel:         "debug",
+		DedupWindow:      30 * time.Second,
+		SdUsageThreshold: 90,
+		AllowedOrigins:   []string{"http://localhost:9111"},
+		Storage:          storage.NewConfig(),
+		Lease:            lease.NewConfig(),
+		Auth:             auth.NewConfig(),
+		Otp:              otp.NewConfig(),
+		Forwarding:       forwarding.NewConfig(),
+		Retention:        retention.NewConfig(),
+		Presence:         presence.NewConfig(),
+		Telemetry:        telemetry.NewConfig(),
+		Labels:           labels.NewConfig(),
 	}
 }
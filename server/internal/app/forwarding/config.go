@@ -0,0 +1,26 @@
+package forwarding
+
+import "time"
+
+type Config struct {
+	// PollInterval is how often due deliveries are retried.
+	PollInterval time.Duration `toml:"poll_interval"`
+	BatchSize    int           `toml:"batch_size"`
+	// Failed attempts are retried after MinBackoff, doubling up to
+	// MaxBackoff, until MaxAttempts is reached.
+	MaxAttempts int           `toml:"max_attempts"`
+	MinBackoff  time.Duration `toml:"min_backoff"`
+	MaxBackoff  time.Duration `toml:"max_backoff"`
+	Timeout     time.Duration `toml:"timeout"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		PollInterval: 5 * time.Second,
+		BatchSize:    50,
+		MaxAttempts:  8,
+		MinBackoff:   10 * time.Second,
+		MaxBackoff:   time.Hour,
+		Timeout:      10 * time.Second,
+	}
+}
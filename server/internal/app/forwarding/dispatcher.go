@@ -0,0 +1,183 @@
+// Package forwarding sends incoming notifications to webhooks.
+//
+// Matching notifications are stored as deliveries first, so they survive
+// restarts, and are then sent by the dispatcher. Failed deliveries are
+// retried with exponential backoff until the attempts run out.
+package forwarding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strconv"
+	"time"
+)
+
+type Dispatcher struct {
+	config  *Config
+	storage storage.ForwardingRepository
+	client  *http.Client
+	now     func() time.Time
+	wake    chan struct{}
+}
+
+func NewDispatcher(config *Config, repo storage.ForwardingRepository) *Dispatcher {
+	return &Dispatcher{
+		config:  config,
+		storage: repo,
+		client:  &http.Client{Timeout: config.Timeout},
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue stores a delivery of n for every rule it matches.
+func (d *Dispatcher) Enqueue(n *models.Notification) error {
+	rules, err := d.storage.SelectRules()
+	if err != nil {
+		return err
+	}
+
+	queued := false
+	for i := range rules {
+		rule := &rules[i]
+		if !Matches(rule, n) {
+			continue
+		}
+
+		payload, err := Payload(rule, n)
+		if err != nil {
+			return err
+		}
+
+		_, err = d.storage.CreateDelivery(&models.Delivery{
+			RuleId:         rule.Id,
+			NotificationId: n.Id,
+			Payload:        string(payload),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  d.now(),
+		})
+		if err != nil {
+			return err
+		}
+		queued = true
+	}
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Wake is signalled when new deliveries are queued.
+func (d *Dispatcher) Wake() <-chan struct{} {
+	return d.wake
+}
+
+// Process makes one attempt at every due delivery.
+func (d *Dispatcher) Process() error {
+	due, err := d.storage.SelectDueDeliveries(d.now(), d.config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		if err := d.deliver(&due[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) deliver(delivery *models.Delivery) error {
+	rule, err := d.storage.SelectRuleById(delivery.RuleId)
+	if err == storage.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := d.now()
+	delivery.Attempts++
+
+	if !rule.Enabled {
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = "rule is disabled"
+		return d.storage.UpdateDelivery(delivery)
+	}
+
+	code, err := d.send(rule, delivery, now)
+	delivery.LastStatusCode = code
+	delivery.LastError = ""
+
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+	default:
+		if err != nil {
+			delivery.LastError = err.Error()
+		} else {
+			delivery.LastError = fmt.Sprintf("unexpected status %d", code)
+		}
+
+		if delivery.Attempts >= d.config.MaxAttempts || isPermanent(code) {
+			delivery.Status = models.DeliveryFailed
+		} else {
+			delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+		}
+	}
+
+	return d.storage.UpdateDelivery(delivery)
+}
+
+func (d *Dispatcher) send(rule *models.ForwardingRule, delivery *models.Delivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, rule.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.Id))
+	if rule.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(rule.Secret, body, now))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
+
+// isPermanent tells client errors, which won't go away by retrying, from
+// rate limits and timeouts.
+func isPermanent(code int) bool {
+	return code >= 400 && code < 500 &&
+		code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.config.MinBackoff
+	for i := 1; i < attempts && backoff < d.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.config.MaxBackoff {
+		backoff = d.config.MaxBackoff
+	}
+
+	return backoff
+}
@@ -0,0 +1,176 @@
+package forwarding
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type standIn struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	s.requests = append(s.requests, r)
+	s.bodies = append(s.bodies, body)
+
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func testDispatcher(t *testing.T, statuses ...int) (*Dispatcher, *memstorage.Storage, *standIn, *httptest.Server, *time.Time) {
+	t.Helper()
+
+	st := memstorage.New()
+	d := NewDispatcher(NewConfig(), st.Forwarding())
+	now := time.Date(2023, 7, 15, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	h := &standIn{statuses: statuses}
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	return d, st, h, ts, &now
+}
+
+func createNotification(t *testing.T, st *memstorage.Storage, sender, body string) *models.Notification {
+	t.Helper()
+
+	p, _ := st.Phone().SelectByModelNumber("SM-G973F/DS")
+	if p == nil {
+		p, _ = st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	}
+	n, err := st.Notification().Create(&models.Notification{
+		ModelNumber: p.ModelNumber,
+		PhoneId:     &p.Id,
+		Sender:      sender,
+		Body:        body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func TestDispatcher_DeliversSigned(t *testing.T) {
+	d, st, h, ts, now := testDispatcher(t)
+
+	rule, _ := st.Forwarding().CreateRule(&models.ForwardingRule{
+		Name: "bank", Enabled: true, Sender: "Bank", BodyPattern: `\d{4}`,
+		Url: ts.URL, Format: models.FormatSlack, Secret: "s3cret",
+	})
+
+	assert.NoError(t, d.Enqueue(createNotification(t, st, "Other", "Code 1234")))
+	assert.NoError(t, d.Enqueue(createNotification(t, st, "Bank", "No code")))
+	assert.NoError(t, d.Enqueue(createNotification(t, st, "Bank", "Code 1234")))
+	assert.NoError(t, d.Process())
+
+	assert.Len(t, h.requests, 1)
+	var payload map[string]string
+	assert.NoError(t, json.Unmarshal(h.bodies[0], &payload))
+	assert.Equal(t, "SM-G973F/DS from Bank:\nCode 1234", payload["text"])
+	assert.Equal(t, Sign("s3cret", h.bodies[0], *now), h.requests[0].Header.Get(SignatureHeader))
+
+	deliveries, _ := st.Forwarding().SelectDeliveries(models.DeliveryFilter{RuleId: rule.Id})
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusOK, deliveries[0].LastStatusCode)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	d, st, h, ts, now := testDispatcher(t, http.StatusBadGateway, http.StatusTooManyRequests)
+
+	st.Forwarding().CreateRule(&models.ForwardingRule{Name: "all", Enabled: true, Url: ts.URL, Format: models.FormatGeneric})
+	d.Enqueue(createNotification(t, st, "900", "Code 1234"))
+
+	assert.NoError(t, d.Process())
+	deliveries, _ := st.Forwarding().SelectDeliveries(models.DeliveryFilter{})
+	assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+	assert.Equal(t, "unexpected status 502", deliveries[0].LastError)
+	assert.Equal(t, now.Add(10*time.Second), deliveries[0].NextAttemptAt)
+
+	// Not due yet.
+	assert.NoError(t, d.Process())
+	assert.Len(t, h.requests, 1)
+
+	*now = now.Add(10 * time.Second)
+	assert.NoError(t, d.Process())
+	deliveries, _ = st.Forwarding().SelectDeliveries(models.DeliveryFilter{})
+	assert.Equal(t, now.Add(20*time.Second), deliveries[0].NextAttemptAt)
+
+	*now = now.Add(20 * time.Second)
+	assert.NoError(t, d.Process())
+	deliveries, _ = st.Forwarding().SelectDeliveries(models.DeliveryFilter{})
+	assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+	assert.Len(t, h.requests, 3)
+}
+
+func TestDispatcher_GivesUp(t *testing.T) {
+	d, st, _, ts, now := testDispatcher(t, http.StatusInternalServerError, http.StatusNotFound, http.StatusInternalServerError)
+	d.config.MaxAttempts = 2
+
+	st.Forwarding().CreateRule(&models.ForwardingRule{Name: "a", Enabled: true, Url: ts.URL, Format: models.FormatGeneric})
+	st.Forwarding().CreateRule(&models.ForwardingRule{Name: "b", Enabled: true, Url: ts.URL, Format: models.FormatGeneric})
+	d.Enqueue(createNotification(t, st, "900", "Code 1234"))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, d.Process())
+		*now = now.Add(time.Hour)
+	}
+
+	// Rule a failed twice and ran out of attempts, rule b got a 404 which is
+	// not retried.
+	deliveries, _ := st.Forwarding().SelectDeliveries(models.DeliveryFilter{Status: models.DeliveryFailed})
+	assert.Len(t, deliveries, 2)
+	for _, delivery := range deliveries {
+		assert.Less(t, delivery.Attempts, 3)
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(&Config{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}, nil)
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, 5*time.Second, d.backoff(4))
+	assert.Equal(t, 5*time.Second, d.backoff(30))
+}
+
+func TestValidate(t *testing.T) {
+	valid := models.ForwardingRule{Name: "r", Url: "https://hooks.example/x", Format: models.FormatGeneric}
+	assert.NoError(t, Validate(&valid))
+
+	for name, mutate := range map[string]func(*models.ForwardingRule){
+		"no name":          func(r *models.ForwardingRule) { r.Name = "" },
+		"relative url":     func(r *models.ForwardingRule) { r.Url = "/hook" },
+		"bad pattern":      func(r *models.ForwardingRule) { r.BodyPattern = "(" },
+		"unknown format":   func(r *models.ForwardingRule) { r.Format = "irc" },
+		"telegram no chat": func(r *models.ForwardingRule) { r.Format = models.FormatTelegram },
+	} {
+		rule := valid
+		mutate(&rule)
+		assert.Error(t, Validate(&rule), name)
+	}
+}
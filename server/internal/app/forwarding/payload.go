@@ -0,0 +1,56 @@
+package forwarding
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"server/internal/app/models"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Cardtracker-Signature"
+	DeliveryHeader  = "X-Cardtracker-Delivery"
+)
+
+// Payload renders the request body for rule.Format.
+func Payload(rule *models.ForwardingRule, n *models.Notification) ([]byte, error) {
+	switch rule.Format {
+	case models.FormatSlack:
+		return json.Marshal(map[string]string{"text": text(n)})
+	case models.FormatMattermost:
+		return json.Marshal(map[string]string{"text": text(n), "username": "cardtracker"})
+	case models.FormatTelegram:
+		return json.Marshal(map[string]string{"chat_id": rule.ChatId, "text": text(n)})
+	default:
+		return json.Marshal(struct {
+			RuleId       int                  `json:"rule_id"`
+			Notification *models.Notification `json:"notification"`
+		}{rule.Id, n})
+	}
+}
+
+func text(n *models.Notification) string {
+	device := n.ModelNumber
+	if n.PhoneNumber != "" {
+		device += " (" + n.PhoneNumber + ")"
+	}
+
+	return fmt.Sprintf("%s from %s:\n%s", device, n.Sender, n.Body)
+}
+
+// Sign returns the signature header value for body, which receivers check
+// by computing HMAC-SHA256 over "<t>.<body>" with the shared secret.
+func Sign(secret string, body []byte, at time.Time) string {
+	t := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
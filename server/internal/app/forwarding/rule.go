@@ -0,0 +1,64 @@
+package forwarding
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"server/internal/app/models"
+)
+
+// Validate checks a rule before it is stored.
+func Validate(rule *models.ForwardingRule) error {
+	if rule.Name == "" {
+		return errors.New("name is required")
+	}
+	u, err := url.Parse(rule.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) url")
+	}
+	if rule.BodyPattern != "" {
+		if _, err := regexp.Compile(rule.BodyPattern); err != nil {
+			return fmt.Errorf("body_pattern: %w", err)
+		}
+	}
+
+	switch rule.Format {
+	case models.FormatGeneric, models.FormatSlack, models.FormatMattermost:
+	case models.FormatTelegram:
+		if rule.ChatId == "" {
+			return errors.New("chat_id is required for the telegram format")
+		}
+	default:
+		return fmt.Errorf("unknown format %q", rule.Format)
+	}
+
+	return nil
+}
+
+// Matches reports whether n should be forwarded by rule.
+func Matches(rule *models.ForwardingRule, n *models.Notification) bool {
+	if !rule.Enabled {
+		return false
+	}
+	if rule.Source != "" && rule.Source != n.Source {
+		return false
+	}
+	if rule.Sender != "" && rule.Sender != n.Sender {
+		return false
+	}
+	if rule.PhoneId != nil && (n.PhoneId == nil || *rule.PhoneId != *n.PhoneId) {
+		return false
+	}
+	if rule.SimCardId != nil && (n.SimCardId == nil || *rule.SimCardId != *n.SimCardId) {
+		return false
+	}
+	if rule.BodyPattern != "" {
+		re, err := regexp.Compile(rule.BodyPattern)
+		if err != nil || !re.MatchString(n.Body) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,12 +2,12 @@ package helper
 
 import (
 	"encoding/csv"
-	"github.com/dgrijalva/jwt-go"
 	"golang.org/x/crypto/bcrypt"
 	"io"
 	"os"
 	"path/filepath"
 	"server/internal/app/models"
+	"strconv"
 	"strings"
 )
 
@@ -91,20 +91,38 @@ func GenerateHashPassword(password string) (string, error) {
 	return string(bytes), err
 }
 
-func ParseToken(tokenString string) (claims *models.Claims, err error) {
-	token, err := jwt.ParseWithClaims(tokenString, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte("very_secret_key"), nil
-	})
-
-	if err != nil {
-		return nil, err
+// DiffPhones lists the reported fields that differ between the stored and
+// the reported phone. For a new phone pass an empty old value to get the
+// initial values.
+func DiffPhones(old, new models.Phone) []models.PhoneChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"manufacturer", old.Manufacturer, new.Manufacturer},
+		{"model_tag", old.ModelTag, new.ModelTag},
+		{"os_version", old.OsVersion, new.OsVersion},
+		{"api_version", old.ApiVersion, new.ApiVersion},
+		{"cpu", old.Cpu, new.Cpu},
+		{"firmware", old.Firmware, new.Firmware},
+		{"bootloader", old.Bootloader, new.Bootloader},
+		{"supported_archs", strings.Join(old.SupportedArchs, ","), strings.Join(new.SupportedArchs, ",")},
+		{"sim_slots", strconv.Itoa(old.SimSlots), strconv.Itoa(new.SimSlots)},
+		{"sd_slots", strconv.Itoa(old.SdSlots), strconv.Itoa(new.SdSlots)},
 	}
 
-	claims, ok := token.Claims.(*models.Claims)
+	var changes []models.PhoneChange
 
-	if !ok {
-		return nil, err
+	for _, f := range fields {
+		if f.old != f.new {
+			changes = append(changes, models.PhoneChange{
+				PhoneId:  new.Id,
+				Field:    f.name,
+				OldValue: f.old,
+				NewValue: f.new,
+			})
+		}
 	}
 
-	return claims, nil
+	return changes
 }
@@ -0,0 +1,110 @@
+// Package hub fans new notifications out to the live streams of connected
+// clients.
+package hub
+
+import (
+	"server/internal/app/models"
+	"sync"
+)
+
+// subscriptionBuffer is how many events a subscriber may lag behind before
+// it is dropped. A dropped client reconnects and catches up from the history.
+const subscriptionBuffer = 64
+
+type Filter struct {
+	ModelNumber string
+	PhoneNumber string
+	Sender      string
+}
+
+func (f Filter) Matches(n *models.Notification) bool {
+	return (f.ModelNumber == "" || f.ModelNumber == n.ModelNumber) &&
+		(f.PhoneNumber == "" || f.PhoneNumber == n.PhoneNumber) &&
+		(f.Sender == "" || f.Sender == n.Sender)
+}
+
+type Subscription struct {
+	// C receives matching notifications. It is closed when the subscriber
+	// falls too far behind or unsubscribes.
+	C      <-chan models.Notification
+	ch     chan models.Notification
+	filter Filter
+}
+
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	history     []models.Notification
+	historySize int
+}
+
+// New returns a hub that keeps the last historySize notifications for
+// clients resuming a stream.
+func New(historySize int) *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+		historySize: historySize,
+	}
+}
+
+func (h *Hub) Publish(n models.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.history = append(h.history, n)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for s := range h.subscribers {
+		if !s.filter.Matches(&n) {
+			continue
+		}
+		select {
+		case s.ch <- n:
+		default:
+			h.drop(s)
+		}
+	}
+}
+
+// Subscribe starts a subscription. If lastId is set, the kept notifications
+// published after it are delivered first.
+func (h *Hub) Subscribe(f Filter, lastId int) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []models.Notification
+	if lastId > 0 {
+		for i := range h.history {
+			if h.history[i].Id > lastId && f.Matches(&h.history[i]) {
+				missed = append(missed, h.history[i])
+			}
+		}
+	}
+
+	ch := make(chan models.Notification, subscriptionBuffer+len(missed))
+	for _, n := range missed {
+		ch <- n
+	}
+
+	s := &Subscription{C: ch, ch: ch, filter: f}
+	h.subscribers[s] = struct{}{}
+
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[s]; ok {
+		h.drop(s)
+	}
+}
+
+// drop must be called with mu held.
+func (h *Hub) drop(s *Subscription) {
+	delete(h.subscribers, s)
+	close(s.ch)
+}
@@ -0,0 +1,57 @@
+package hub_test
+
+import (
+	"server/internal/app/hub"
+	"server/internal/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub_PublishFiltered(t *testing.T) {
+	h := hub.New(10)
+	all := h.Subscribe(hub.Filter{}, 0)
+	bank := h.Subscribe(hub.Filter{Sender: "Bank", PhoneNumber: "79889484608"}, 0)
+
+	h.Publish(models.Notification{Id: 1, Sender: "Bank", PhoneNumber: "79001112233"})
+	h.Publish(models.Notification{Id: 2, Sender: "Bank", PhoneNumber: "79889484608"})
+
+	assert.Equal(t, 1, (<-all.C).Id)
+	assert.Equal(t, 2, (<-all.C).Id)
+	assert.Equal(t, 2, (<-bank.C).Id)
+	assert.Len(t, bank.C, 0)
+
+	h.Unsubscribe(bank)
+	_, ok := <-bank.C
+	assert.False(t, ok)
+}
+
+func TestHub_Resume(t *testing.T) {
+	h := hub.New(2)
+	for id := 1; id <= 3; id++ {
+		h.Publish(models.Notification{Id: id, ModelNumber: "SM-G973F/DS"})
+	}
+
+	// Only the last two are kept.
+	sub := h.Subscribe(hub.Filter{ModelNumber: "SM-G973F/DS"}, 1)
+	assert.Equal(t, 2, (<-sub.C).Id)
+	assert.Equal(t, 3, (<-sub.C).Id)
+
+	sub = h.Subscribe(hub.Filter{}, 0)
+	assert.Len(t, sub.C, 0)
+}
+
+func TestHub_DropsSlowSubscribers(t *testing.T) {
+	h := hub.New(1)
+	sub := h.Subscribe(hub.Filter{}, 0)
+
+	for id := 1; id <= 100; id++ {
+		h.Publish(models.Notification{Id: id})
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	assert.Less(t, received, 100)
+}
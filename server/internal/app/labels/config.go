@@ -0,0 +1,21 @@
+package labels
+
+import "strings"
+
+type Config struct {
+	// BaseUrl is where the web app is served. Labels link to
+	// BaseUrl/assets/<tag>, the page that looks the tag up and shows the
+	// device.
+	BaseUrl string `toml:"base_url"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		BaseUrl: "http://localhost:8080",
+	}
+}
+
+// Link returns the address the QR code of an asset label points to.
+func (c *Config) Link(tag string) string {
+	return strings.TrimRight(c.BaseUrl, "/") + "/assets/" + tag
+}
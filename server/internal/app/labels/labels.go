@@ -0,0 +1,216 @@
+// Package labels renders printable asset labels: a QR code linking to the
+// device page with the asset tag under or beside it. It needs nothing but
+// the standard library, so labels print the same on any host.
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"server/internal/app/qr"
+	"strings"
+)
+
+type Label struct {
+	Tag  string
+	Name string
+	Link string
+}
+
+const (
+	// pngModule is the size of a QR module in PNG labels, in pixels.
+	pngModule = 6
+	// pngText is the size of a font pixel in PNG labels.
+	pngText = 3
+)
+
+// WritePNG renders a single label: the QR code with the tag under it.
+func WritePNG(w io.Writer, l Label) error {
+	code, err := qr.Encode([]byte(l.Link))
+	if err != nil {
+		return err
+	}
+
+	codeWidth := (code.Size + 2*qr.QuietZone) * pngModule
+	textWidth := len([]rune(l.Tag)) * (glyphWidth + 1) * pngText
+	width := codeWidth
+	if textWidth+2*qr.QuietZone*pngModule > width {
+		width = textWidth + 2*qr.QuietZone*pngModule
+	}
+	height := codeWidth + (glyphHeight+2)*pngText
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+	fill := func(x, y, size int) {
+		for dy := 0; dy < size; dy++ {
+			for dx := 0; dx < size; dx++ {
+				img.SetColorIndex(x+dx, y+dy, 1)
+			}
+		}
+	}
+
+	left := (width - codeWidth) / 2
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fill(left+(x+qr.QuietZone)*pngModule, (y+qr.QuietZone)*pngModule, pngModule)
+			}
+		}
+	}
+
+	x, top := (width-textWidth)/2, codeWidth
+	for _, r := range l.Tag {
+		if g, ok := glyph(r); ok {
+			for gy, row := range g {
+				for gx, c := range row {
+					if c == '#' {
+						fill(x+gx*pngText, top+gy*pngText, pngText)
+					}
+				}
+			}
+		}
+		x += (glyphWidth + 1) * pngText
+	}
+
+	return png.Encode(w, img)
+}
+
+// The sheet is A4 with 3 columns of 8 labels, in points.
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	pageMargin   = 36
+	sheetColumns = 3
+	sheetRows    = 8
+	labelWidth   = (pageWidth - 2*pageMargin) / sheetColumns
+	labelHeight  = (pageHeight - 2*pageMargin) / sheetRows
+	codeBox      = 84
+	// nameChars is as much of the name as fits beside the code.
+	nameChars = 20
+)
+
+// WritePDF renders labels on as many A4 sheets as they take.
+func WritePDF(w io.Writer, labels []Label) error {
+	var pages []string
+	var page strings.Builder
+	for i, l := range labels {
+		if i > 0 && i%(sheetColumns*sheetRows) == 0 {
+			pages = append(pages, page.String())
+			page.Reset()
+		}
+		n := i % (sheetColumns * sheetRows)
+		left := pageMargin + float64(n%sheetColumns)*labelWidth
+		top := pageHeight - pageMargin - float64(n/sheetColumns)*labelHeight
+		if err := drawLabel(&page, l, left, top); err != nil {
+			return err
+		}
+	}
+	if page.Len() > 0 || len(pages) == 0 {
+		pages = append(pages, page.String())
+	}
+
+	var doc pdf
+	doc.buf.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	doc.object(`<< /Type /Catalog /Pages 2 0 R >>`)
+	doc.object(fmt.Sprintf(`<< /Type /Pages /Kids [%s] /Count %d >>`, strings.Join(kids, " "), len(pages)))
+	doc.object(`<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>`)
+	doc.object(`<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>`)
+	for i, content := range pages {
+		doc.object(fmt.Sprintf(`<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>`,
+			pageWidth, pageHeight, 6+2*i))
+		doc.object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	_, err := w.Write(doc.finish())
+	return err
+}
+
+// drawLabel draws a label with its top left corner at left, top: the code
+// on the left, the tag and the name on the right, and a thin outline to cut
+// along.
+func drawLabel(page *strings.Builder, l Label, left, top float64) error {
+	code, err := qr.Encode([]byte(l.Link))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(page, "0.8 G 0.5 w %.2f %.2f %.2f %.2f re S 0 g\n", left, top-labelHeight, labelWidth, labelHeight)
+
+	module := codeBox / float64(code.Size+2*qr.QuietZone)
+	codeLeft := left + (labelHeight-codeBox)/2 + qr.QuietZone*module
+	codeTop := top - (labelHeight-codeBox)/2 - qr.QuietZone*module
+	for y := 0; y < code.Size; y++ {
+		// One rectangle per run of dark modules keeps the page small.
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < code.Size && code.Black(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(page, "%.2f %.2f %.2f %.2f re\n",
+				codeLeft+float64(x)*module, codeTop-float64(y+1)*module, float64(run)*module, module)
+			x += run
+		}
+	}
+	page.WriteString("f\n")
+
+	name := []rune(l.Name)
+	if len(name) > nameChars {
+		name = append(name[:nameChars-3], '.', '.', '.')
+	}
+	textLeft := left + labelHeight
+	fmt.Fprintf(page, "BT /F2 12 Tf %.2f %.2f Td (%s) Tj ET\n", textLeft, top-labelHeight/2+4, pdfString(l.Tag))
+	fmt.Fprintf(page, "BT /F1 8 Tf %.2f %.2f Td (%s) Tj ET\n", textLeft, top-labelHeight/2-10, pdfString(string(name)))
+
+	return nil
+}
+
+// pdfString escapes text for a PDF string in WinAnsi encoding. Characters
+// the standard fonts lack are printed as '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdf collects numbered objects and writes the cross-reference table that
+// points to them.
+type pdf struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (p *pdf) object(body string) {
+	p.offsets = append(p.offsets, p.buf.Len())
+	fmt.Fprintf(&p.buf, "%d 0 obj\n%s\nendobj\n", len(p.offsets), body)
+}
+
+func (p *pdf) finish() []byte {
+	xref := p.buf.Len()
+	fmt.Fprintf(&p.buf, "xref\n0 %d\n0000000000 65535 f \n", len(p.offsets)+1)
+	for _, offset := range p.offsets {
+		fmt.Fprintf(&p.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&p.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets)+1, xref)
+
+	return p.buf.Bytes()
+}
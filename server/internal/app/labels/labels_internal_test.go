@@ -0,0 +1,52 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WritePNG(&buf, Label{Tag: "PH-000001", Link: "http://localhost:8080/assets/PH-000001"}))
+
+	img, err := png.Decode(&buf)
+	if assert.NoError(t, err) {
+		// A version 3 code with its quiet zone, and the tag under it.
+		assert.Equal(t, (29+8)*pngModule, img.Bounds().Dx())
+		assert.Equal(t, (29+8)*pngModule+(glyphHeight+2)*pngText, img.Bounds().Dy())
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var sheet []Label
+	for i := 1; i <= sheetColumns*sheetRows+1; i++ {
+		tag := fmt.Sprintf("SIM-%06d", i)
+		sheet = append(sheet, Label{Tag: tag, Name: "MTS (work) \\ тест", Link: "http://localhost:8080/assets/" + tag})
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WritePDF(&buf, sheet))
+	doc := buf.Bytes()
+
+	assert.True(t, bytes.HasPrefix(doc, []byte("%PDF-1.4\n")))
+	assert.Contains(t, string(doc), "/Count 2")
+	assert.Contains(t, string(doc), `(MTS \(work\) \\ ????)`)
+
+	// Every cross-reference entry points at its object.
+	xref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(doc)
+	if assert.NotNil(t, xref) {
+		at, _ := strconv.Atoi(string(xref[1]))
+		entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(doc[at:], -1)
+		assert.Len(t, entries, 8)
+		for i, e := range entries {
+			offset, _ := strconv.Atoi(string(e[1]))
+			assert.True(t, bytes.HasPrefix(doc[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))))
+		}
+	}
+}
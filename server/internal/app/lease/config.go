@@ -0,0 +1,17 @@
+package lease
+
+import "time"
+
+type Config struct {
+	DefaultTtl    time.Duration `toml:"default_ttl"`
+	MaxTtl        time.Duration `toml:"max_ttl"`
+	SweepInterval time.Duration `toml:"sweep_interval"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		DefaultTtl:    10 * time.Minute,
+		MaxTtl:        2 * time.Hour,
+		SweepInterval: 15 * time.Second,
+	}
+}
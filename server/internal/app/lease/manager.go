@@ -0,0 +1,328 @@
+// Package lease hands out time limited, exclusive locks on phones to
+// automated test jobs.
+//
+// A lease request carries a filter. It is granted a free matching phone right
+// away or waits in a queue; queued requests are served in request order, and
+// a request is skipped only while no free phone matches it. Every lease has a
+// TTL that the holder extends with heartbeats; leases that are not renewed
+// expire and their phones go back to the pool.
+//
+// Reservations come first: a phone is not leased if a reservation starts
+// within the TTL, a lease is never extended past the start of the next
+// reservation of its phone, and it expires once one starts.
+package lease
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sync"
+	"time"
+)
+
+var ErrLeaseClosed = errors.New("lease is released or expired")
+
+type Manager struct {
+	mu      sync.Mutex
+	config  *Config
+	storage storage.Storage
+	now     func() time.Time
+}
+
+func NewManager(config *Config, st storage.Storage) *Manager {
+	return &Manager{
+		config:  config,
+		storage: st,
+		now:     time.Now,
+	}
+}
+
+// Sweep expires leases that missed their heartbeat and serves the queue.
+// It is called on every lease operation and periodically by the server.
+func (m *Manager) Sweep() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.sweep()
+
+	return err
+}
+
+// Acquire queues a lease request for userId and grants it immediately if a
+// matching phone is free.
+func (m *Manager) Acquire(userId int, filter models.LeaseFilter, ttl time.Duration) (*models.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl = m.clampTtl(ttl)
+	l := &models.Lease{
+		Token:      token,
+		UserId:     userId,
+		Filter:     filter,
+		Status:     models.LeaseQueued,
+		TtlSeconds: int(ttl / time.Second),
+		ExpiresAt:  m.now().Add(ttl),
+	}
+	if _, err := m.storage.Lease().Create(l); err != nil {
+		return nil, err
+	}
+
+	return m.get(token)
+}
+
+func (m *Manager) Get(token string) (*models.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.get(token)
+}
+
+// Heartbeat extends an open lease by its TTL, or by ttl if it is non-zero.
+func (m *Manager) Heartbeat(token string, ttl time.Duration) (*models.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, err := m.get(token)
+	if err != nil {
+		return nil, err
+	}
+	if !l.IsOpen() {
+		return l, ErrLeaseClosed
+	}
+
+	if ttl != 0 {
+		l.TtlSeconds = int(m.clampTtl(ttl) / time.Second)
+	}
+	now := m.now()
+	l.ExpiresAt = now.Add(time.Duration(l.TtlSeconds) * time.Second)
+	if l.Status == models.LeaseActive {
+		start, err := m.nextReservation(*l.PhoneId, now, l.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if !start.IsZero() {
+			l.ExpiresAt = start
+		}
+	}
+	if err := m.storage.Lease().Update(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (m *Manager) Release(token string) (*models.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, err := m.get(token)
+	if err != nil {
+		return nil, err
+	}
+	if !l.IsOpen() {
+		return l, ErrLeaseClosed
+	}
+
+	now := m.now()
+	l.Status = models.LeaseReleased
+	l.ReleasedAt = &now
+	l.QueuePosition = 0
+	if err := m.storage.Lease().Update(l); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.sweep(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// IsLeased reports whether phoneId is held by an active lease.
+func (m *Manager) IsLeased(phoneId int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	open, err := m.sweep()
+	if err != nil {
+		return false, err
+	}
+
+	for _, l := range open {
+		if l.Status == models.LeaseActive && l.PhoneId != nil && *l.PhoneId == phoneId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// get sweeps and returns the lease with its queue position filled in. Must
+// be called with mu held.
+func (m *Manager) get(token string) (*models.Lease, error) {
+	open, err := m.sweep()
+	if err != nil {
+		return nil, err
+	}
+
+	position := 0
+	for _, l := range open {
+		if l.Status == models.LeaseQueued {
+			position++
+		}
+		if l.Token == token {
+			if l.Status == models.LeaseQueued {
+				l.QueuePosition = position
+			}
+			return &l, nil
+		}
+	}
+
+	return m.storage.Lease().SelectByToken(token)
+}
+
+// sweep expires stale leases, grants free phones to queued requests and
+// returns the leases that are still open. Must be called with mu held.
+func (m *Manager) sweep() ([]models.Lease, error) {
+	now := m.now()
+
+	leases, err := m.storage.Lease().SelectOpen()
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := m.storage.Reservation().SelectActive(now)
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make(map[int]bool)
+	for _, res := range reservations {
+		busy[res.PhoneId] = true
+	}
+	var open []models.Lease
+
+	for _, l := range leases {
+		// An active lease whose phone was deleted can never be used again,
+		// nor one whose phone a reservation has started on.
+		if !l.ExpiresAt.After(now) || (l.Status == models.LeaseActive && (l.PhoneId == nil || busy[*l.PhoneId])) {
+			l.Status = models.LeaseExpired
+			l.ReleasedAt = &now
+			if err := m.storage.Lease().Update(&l); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if l.Status == models.LeaseActive {
+			busy[*l.PhoneId] = true
+		}
+		open = append(open, l)
+	}
+
+	queued := false
+	for _, l := range open {
+		if l.Status == models.LeaseQueued {
+			queued = true
+			break
+		}
+	}
+	if !queued {
+		return open, nil
+	}
+
+	phones, err := m.storage.Phone().SelectAll()
+	if err != nil {
+		return nil, err
+	}
+	simCards, err := m.storage.Sim().SelectAll()
+	if err != nil {
+		return nil, err
+	}
+	sims := make(map[int][]models.SimInfo)
+	for _, sim := range simCards {
+		if sim.PhoneId != nil {
+			sims[*sim.PhoneId] = append(sims[*sim.PhoneId], sim)
+		}
+	}
+
+	for i := range open {
+		l := &open[i]
+		if l.Status != models.LeaseQueued {
+			continue
+		}
+
+		for _, p := range phones {
+			if busy[p.Id] || !Matches(l.Filter, p, sims[p.Id]) {
+				continue
+			}
+			ttl := time.Duration(l.TtlSeconds) * time.Second
+			start, err := m.nextReservation(p.Id, now, now.Add(ttl))
+			if err != nil {
+				return nil, err
+			}
+			if !start.IsZero() {
+				continue
+			}
+
+			phoneId := p.Id
+			l.Status = models.LeaseActive
+			l.PhoneId = &phoneId
+			l.GrantedAt = &now
+			l.ExpiresAt = now.Add(ttl)
+			if err := m.storage.Lease().Update(l); err != nil {
+				return nil, err
+			}
+			busy[p.Id] = true
+			break
+		}
+	}
+
+	return open, nil
+}
+
+// nextReservation returns when the first reservation of phoneId between from
+// and to that hasn't been checked in starts, or the zero time if there is
+// none.
+func (m *Manager) nextReservation(phoneId int, from, to time.Time) (time.Time, error) {
+	reservations, err := m.storage.Reservation().SelectByPhoneId(phoneId, from, to)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, res := range reservations {
+		if res.CheckedInAt == nil {
+			return res.StartsAt, nil
+		}
+	}
+
+	return time.Time{}, nil
+}
+
+func (m *Manager) clampTtl(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		ttl = m.config.DefaultTtl
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	if m.config.MaxTtl > 0 && ttl > m.config.MaxTtl {
+		ttl = m.config.MaxTtl
+	}
+
+	return ttl
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
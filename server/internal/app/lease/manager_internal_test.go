@@ -0,0 +1,139 @@
+package lease
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testManager(t *testing.T) (*Manager, *memstorage.Storage, *time.Time) {
+	t.Helper()
+
+	st := memstorage.New()
+	now := time.Date(2023, 6, 10, 12, 0, 0, 0, time.UTC)
+	m := NewManager(NewConfig(), st)
+	m.now = func() time.Time { return now }
+
+	return m, st, &now
+}
+
+func TestMatches(t *testing.T) {
+	p := models.Phone{
+		Manufacturer:   "Samsung",
+		OsVersion:      "12",
+		ApiVersion:     "31",
+		SupportedArchs: []string{"arm64-v8a"},
+	}
+	sims := []models.SimInfo{{PhoneNumber: "79889484608", Operator: "MTS"}}
+
+	assert.True(t, Matches(models.LeaseFilter{}, p, nil))
+	assert.True(t, Matches(models.LeaseFilter{MinOsVersion: "12", SimOperator: "mts"}, p, sims))
+	assert.True(t, Matches(models.LeaseFilter{MinOsVersion: "8.1", MinApiVersion: 30}, p, nil))
+	assert.False(t, Matches(models.LeaseFilter{MinOsVersion: "12.1"}, p, sims))
+	assert.False(t, Matches(models.LeaseFilter{SimOperator: "Beeline"}, p, sims))
+	assert.False(t, Matches(models.LeaseFilter{HasSim: true}, p, nil))
+	assert.False(t, Matches(models.LeaseFilter{SupportedArch: "x86"}, p, sims))
+	assert.False(t, Matches(models.LeaseFilter{Manufacturer: "Google"}, p, sims))
+}
+
+func TestManager_AcquireQueueRelease(t *testing.T) {
+	m, st, _ := testManager(t)
+	u, _ := st.User().Create(&models.User{Email: "ci@example.org"})
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS", OsVersion: "12"})
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-J250F", OsVersion: "7.1.1"})
+
+	first, err := m.Acquire(u.Id, models.LeaseFilter{MinOsVersion: "12"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseActive, first.Status)
+	assert.Equal(t, p.Id, *first.PhoneId)
+
+	second, err := m.Acquire(u.Id, models.LeaseFilter{MinOsVersion: "12"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseQueued, second.Status)
+	assert.Equal(t, 1, second.QueuePosition)
+
+	// A later request that a free phone satisfies is not blocked by the queue.
+	third, err := m.Acquire(u.Id, models.LeaseFilter{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseActive, third.Status)
+
+	leased, err := m.IsLeased(p.Id)
+	assert.NoError(t, err)
+	assert.True(t, leased)
+
+	_, err = m.Release(first.Token)
+	assert.NoError(t, err)
+
+	second, err = m.Get(second.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseActive, second.Status)
+	assert.Equal(t, p.Id, *second.PhoneId)
+
+	_, err = m.Release(first.Token)
+	assert.ErrorIs(t, err, ErrLeaseClosed)
+}
+
+func TestManager_Expiry(t *testing.T) {
+	m, st, now := testManager(t)
+	u, _ := st.User().Create(&models.User{Email: "ci@example.org"})
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+
+	l, err := m.Acquire(u.Id, models.LeaseFilter{}, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseActive, l.Status)
+
+	*now = now.Add(50 * time.Second)
+	_, err = m.Heartbeat(l.Token, 0)
+	assert.NoError(t, err)
+
+	*now = now.Add(50 * time.Second)
+	l, err = m.Get(l.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseActive, l.Status)
+
+	*now = now.Add(2 * time.Minute)
+	assert.NoError(t, m.Sweep())
+	l, err = m.Get(l.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseExpired, l.Status)
+
+	_, err = m.Heartbeat(l.Token, 0)
+	assert.ErrorIs(t, err, ErrLeaseClosed)
+}
+
+func TestManager_Reservations(t *testing.T) {
+	m, st, now := testManager(t)
+	u, _ := st.User().Create(&models.User{Email: "ci@example.org"})
+	reserved, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	free, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+	starts := now.Add(5 * time.Minute)
+	_, err := st.Reservation().Create(&models.Reservation{PhoneId: reserved.Id, UserId: u.Id, StartsAt: starts, EndsAt: starts.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	// A phone reserved within the TTL is not leased.
+	first, err := m.Acquire(u.Id, models.LeaseFilter{}, 10*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, free.Id, *first.PhoneId)
+	second, err := m.Acquire(u.Id, models.LeaseFilter{}, 10*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, models.LeaseQueued, second.Status)
+
+	// A lease that ends before the reservation starts is granted, but not
+	// extended past its start.
+	short, err := m.Acquire(u.Id, models.LeaseFilter{}, 4*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, reserved.Id, *short.PhoneId)
+	*now = now.Add(3 * time.Minute)
+	short, err = m.Heartbeat(short.Token, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, starts, short.ExpiresAt)
+
+	*now = starts
+	short, _ = m.Get(short.Token)
+	assert.Equal(t, models.LeaseExpired, short.Status)
+	second, _ = m.Get(second.Token)
+	assert.Equal(t, models.LeaseQueued, second.Status)
+}
@@ -0,0 +1,89 @@
+package lease
+
+import (
+	"server/internal/app/models"
+	"strconv"
+	"strings"
+)
+
+// Matches reports whether a phone with the given SIM cards satisfies f.
+func Matches(f models.LeaseFilter, p models.Phone, sims []models.SimInfo) bool {
+	if !equalFold(f.Manufacturer, p.Manufacturer) ||
+		!equalFold(f.ModelTag, p.ModelTag) ||
+		!equalFold(f.ModelNumber, p.ModelNumber) ||
+		!equalFold(f.Cpu, p.Cpu) {
+		return false
+	}
+
+	if f.SupportedArch != "" {
+		found := false
+		for _, arch := range p.SupportedArchs {
+			if strings.EqualFold(arch, f.SupportedArch) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.MinOsVersion != "" && compareVersions(p.OsVersion, f.MinOsVersion) < 0 {
+		return false
+	}
+
+	if f.MinApiVersion > 0 {
+		api, err := strconv.Atoi(p.ApiVersion)
+		if err != nil || api < f.MinApiVersion {
+			return false
+		}
+	}
+
+	if f.HasSim && len(sims) == 0 {
+		return false
+	}
+
+	if f.SimOperator != "" {
+		found := false
+		for _, sim := range sims {
+			if strings.EqualFold(sim.Operator, f.SimOperator) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalFold(want, got string) bool {
+	return want == "" || strings.EqualFold(want, got)
+}
+
+// compareVersions compares dotted numeric versions such as "12" and "8.1.0".
+// Missing or non-numeric parts count as zero.
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(strings.TrimSpace(as[i]))
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(strings.TrimSpace(bs[i]))
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/storage"
+)
+
+// HasPermission wraps isAuthorized and additionally requires the current user
+// to hold a permission. The role is looked up in storage rather than taken
+// from the token, so role changes apply without waiting for a token refresh.
+func HasPermission(isAuthorized func(http.HandlerFunc) http.HandlerFunc, users storage.UserRepository) func(auth.Permission, http.HandlerFunc) http.HandlerFunc {
+	return func(p auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+		return isAuthorized(hasPermission(users, p, next))
+	}
+}
+
+func hasPermission(users storage.UserRepository, p auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sbj, _ := r.Context().Value("subject").(string)
+
+		u, err := users.SelectByEmail(sbj)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if !auth.HasPermission(u.Role, p) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "role", u.Role)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
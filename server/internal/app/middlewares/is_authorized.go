@@ -3,11 +3,19 @@ package middlewares
 import (
 	"context"
 	"net/http"
-	"server/internal/app/helper"
+	"server/internal/app/auth"
+	"server/internal/app/storage"
 	"strings"
+	"time"
 )
 
-func IsAuthorized(next http.HandlerFunc) http.HandlerFunc {
+func IsAuthorized(keys *auth.KeySet, sessions storage.SessionRepository) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return isAuthorized(keys, sessions, next)
+	}
+}
+
+func isAuthorized(keys *auth.KeySet, sessions storage.SessionRepository, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tokenHeader := r.Header.Get("Authorization")
 		if tokenHeader == "" {
@@ -23,15 +31,24 @@ func IsAuthorized(next http.HandlerFunc) http.HandlerFunc {
 
 		token := tokenParts[1]
 
-		claims, err := helper.ParseToken(token)
+		claims, err := keys.Parse(token)
 		if err != nil {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
+		// Sessions are removed together with their user, so this also
+		// rejects tokens of deleted users.
+		session, err := sessions.SelectById(claims.SessionId)
+		if err != nil || !session.IsActive(time.Now()) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, "subject", claims.Subject)
 		ctx = context.WithValue(ctx, "role", claims.Role)
+		ctx = context.WithValue(ctx, "session_id", claims.SessionId)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"server/internal/app/auth"
+	"server/internal/app/storage"
+	"strings"
+)
+
+// IsEnrolledDevice only lets through requests carrying the credential of an
+// enrolled, not revoked device. The credential is put into the context under
+// "device_credential".
+func IsEnrolledDevice(credentials storage.EnrollmentRepository) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return isEnrolledDevice(credentials, next)
+	}
+}
+
+func isEnrolledDevice(credentials storage.EnrollmentRepository, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenParts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(tokenParts) != 2 || tokenParts[0] != "Device" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		c, err := credentials.SelectCredentialByTokenHash(auth.HashToken(tokenParts[1]))
+		if err != nil || !c.IsActive() {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "device_credential", c)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,18 @@
+package middlewares
+
+import "net/http"
+
+// TokenFromCookie lets clients that can't set headers, such as EventSource
+// and WebSocket in browsers, authorize with the "token" cookie set at login.
+// It is meant for read-only GET routes only.
+func TokenFromCookie(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if c, err := r.Cookie("token"); err == nil && c.Value != "" {
+				r.Header.Set("Authorization", "Bearer "+c.Value)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The kinds of assets that get tags and are counted by audits.
+const (
+	AssetPhone  = "phone"
+	AssetSim    = "sim_card"
+	AssetSdCard = "sd_card"
+)
+
+var assetPrefixes = map[string]string{
+	AssetPhone:  "PH",
+	AssetSim:    "SIM",
+	AssetSdCard: "SD",
+}
+
+// IsAssetKind tells whether kind is one of the asset kinds.
+func IsAssetKind(kind string) bool {
+	_, ok := assetPrefixes[kind]
+	return ok
+}
+
+// AssetTag returns the tag printed on the label of an asset, e.g.
+// "PH-000042". Tags are made from the record ids, so they never change and
+// need no column of their own.
+func AssetTag(kind string, id int) string {
+	return fmt.Sprintf("%s-%06d", assetPrefixes[kind], id)
+}
+
+// ParseAssetTag returns the kind and id of an asset tag, in any case. ok is
+// false for anything that isn't one.
+func ParseAssetTag(tag string) (kind string, id int, ok bool) {
+	prefix, number, found := strings.Cut(strings.TrimSpace(tag), "-")
+	if !found {
+		return "", 0, false
+	}
+	id, err := strconv.Atoi(number)
+	if err != nil || id <= 0 || number[0] == '+' {
+		return "", 0, false
+	}
+	for k, p := range assetPrefixes {
+		if strings.EqualFold(p, prefix) {
+			return k, id, true
+		}
+	}
+
+	return "", 0, false
+}
+
+func (p *Phone) AssetTag() string {
+	return AssetTag(AssetPhone, p.Id)
+}
+
+func (sim *SimInfo) AssetTag() string {
+	return AssetTag(AssetSim, sim.Id)
+}
+
+func (sd *SdInfo) AssetTag() string {
+	return AssetTag(AssetSdCard, sd.Id)
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Audit is a physical inventory session. Items are marked found by scans
+// until the audit is finished.
+type Audit struct {
+	Id         int        `json:"audit_id"`
+	Name       string     `json:"name"`
+	StartedBy  int        `json:"started_by"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// AuditScan is one scanned code. Kind and ItemId tell what it matched when
+// it was scanned; both are empty for codes that matched nothing.
+type AuditScan struct {
+	Id      int    `json:"scan_id"`
+	AuditId int    `json:"audit_id"`
+	Code    string `json:"code"`
+	Kind    string `json:"kind"`
+	ItemId  *int   `json:"item_id"`
+	// SlotId is the slot a phone was found in and PhoneId the phone a SIM or
+	// SD card was found in. Nil means the scan doesn't say.
+	SlotId    *int      `json:"slot_id"`
+	PhoneId   *int      `json:"phone_id"`
+	ScannedBy int       `json:"scanned_by"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// AuditItem names a phone, SIM or SD card in an audit report. Code is the
+// inventory tag, phone number or serial number it is also known by.
+type AuditItem struct {
+	Kind     string `json:"kind"`
+	Id       int    `json:"id"`
+	AssetTag string `json:"asset_tag"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+}
+
+// AuditRelocation is an item found somewhere else than the records say:
+// phones in another slot, SIM and SD cards in another phone.
+type AuditRelocation struct {
+	AuditItem
+	ExpectedSlotId  *int `json:"expected_slot_id,omitempty"`
+	FoundSlotId     *int `json:"found_slot_id,omitempty"`
+	ExpectedPhoneId *int `json:"expected_phone_id,omitempty"`
+	FoundPhoneId    *int `json:"found_phone_id,omitempty"`
+}
+
+// AuditReport compares what an audit found with what the records say should
+// exist.
+type AuditReport struct {
+	Audit
+	Expected   int               `json:"expected"`
+	Found      []AuditItem       `json:"found"`
+	Missing    []AuditItem       `json:"missing"`
+	Unexpected []AuditScan       `json:"unexpected"`
+	Relocated  []AuditRelocation `json:"relocated"`
+}
@@ -3,6 +3,7 @@ package models
 import "github.com/dgrijalva/jwt-go"
 
 type Claims struct {
-	Role string `json:"role"`
+	Role      string `json:"role"`
+	SessionId int    `json:"sid"`
 	jwt.StandardClaims
-}
\ No newline at end of file
+}
@@ -0,0 +1,30 @@
+package models
+
+type DeviceReport struct {
+	Phone   Phone     `json:"phone_info"`
+	SimInfo []SimInfo `json:"sim_info"`
+	SdInfo  []SdInfo  `json:"sd_info"`
+	// Battery is missing from the reports of older agents.
+	Battery *Battery `json:"battery"`
+	// Ip is the address of the phone, if the agent tells it.
+	Ip string `json:"ip"`
+	// UserId is the owner of the device credential the report was sent
+	// with; it is not read from the request body.
+	UserId int `json:"-"`
+	// CredentialId is the device credential the report was sent with. The
+	// credential is bound to the phone when the report is saved.
+	CredentialId int `json:"-"`
+}
+
+type DeviceReportResult struct {
+	Phone          *Phone        `json:"phone"`
+	User           *User         `json:"user"`
+	PhoneCreated   bool          `json:"phone_created"`
+	PhoneUpdated   bool          `json:"phone_updated"`
+	PhoneChanges   []PhoneChange `json:"phone_changes"`
+	SimsChanged    bool          `json:"sims_changed"`
+	SdCardsChanged bool          `json:"sd_cards_changed"`
+	OwnerChanged   bool          `json:"owner_changed"`
+	// PreviousIp is the address the phone was seen from before the report.
+	PreviousIp string `json:"-"`
+}
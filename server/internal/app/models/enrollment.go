@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// EnrollmentToken is a one-time secret a user hands to a phone so it can
+// obtain a DeviceCredential.
+type EnrollmentToken struct {
+	Id        int        `json:"enrollment_token_id"`
+	UserId    int        `json:"user_id"`
+	TokenHash string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// DeviceCredential authenticates an enrolled phone. It is bound to the
+// phone on its first report.
+type DeviceCredential struct {
+	Id        int        `json:"device_credential_id"`
+	UserId    int        `json:"user_id"`
+	PhoneId   *int       `json:"phone_id"`
+	TokenHash string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+func (c *DeviceCredential) IsActive() bool {
+	return c.RevokedAt == nil
+}
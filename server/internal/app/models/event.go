@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	EventDeviceOffline  = "device_offline"
+	EventTelemetryAlert = "telemetry_alert"
+)
+
+// Event is something about a device that lab staff should know of, raised
+// by the server rather than reported by the phone.
+type Event struct {
+	Id        int       `json:"event_id"`
+	PhoneId   *int      `json:"phone_id"`
+	Type      string    `json:"type"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EventFilter selects events, newest first. Empty fields don't filter.
+type EventFilter struct {
+	PhoneId *int
+	Type    string
+	Limit   int
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+const (
+	FormatGeneric    = "generic"
+	FormatSlack      = "slack"
+	FormatMattermost = "mattermost"
+	FormatTelegram   = "telegram"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// ForwardingRule sends matching notifications to a webhook. Empty match
+// fields match any notification.
+type ForwardingRule struct {
+	Id          int    `json:"rule_id"`
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"notification_source"`
+	Sender      string `json:"sender"`
+	BodyPattern string `json:"body_pattern"`
+	PhoneId     *int   `json:"phone_id"`
+	SimCardId   *int   `json:"sim_card_id"`
+	Url         string `json:"url"`
+	Format      string `json:"format"`
+	// ChatId is the target chat of the telegram format.
+	ChatId string `json:"chat_id"`
+	// Secret signs the payloads. It is never sent back to clients.
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Delivery is one notification queued for one rule, together with the
+// outcome of its last attempt.
+type Delivery struct {
+	Id             int        `json:"delivery_id"`
+	RuleId         int        `json:"rule_id"`
+	NotificationId int        `json:"notification_id"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+type DeliveryFilter struct {
+	RuleId int
+	Status string
+	Limit  int
+}
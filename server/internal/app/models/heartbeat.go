@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Heartbeat is the latest liveness ping of a phone's agent.
+type Heartbeat struct {
+	PhoneId      int    `json:"phone_id"`
+	BatteryLevel int    `json:"battery_level"`
+	Charging     bool   `json:"charging"`
+	NetworkType  string `json:"network_type"`
+	Ip           string `json:"ip"`
+	// Uptime is in seconds.
+	Uptime     int64     `json:"uptime"`
+	ReceivedAt time.Time `json:"received_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	LeaseQueued   = "queued"
+	LeaseActive   = "active"
+	LeaseReleased = "released"
+	LeaseExpired  = "expired"
+)
+
+// LeaseFilter describes the phone a lease asks for. Empty fields match any
+// phone.
+type LeaseFilter struct {
+	Manufacturer  string `json:"manufacturer"`
+	ModelTag      string `json:"model_tag"`
+	ModelNumber   string `json:"model_number"`
+	Cpu           string `json:"cpu"`
+	SupportedArch string `json:"supported_arch"`
+	MinOsVersion  string `json:"min_os_version"`
+	MinApiVersion int    `json:"min_api_version"`
+	SimOperator   string `json:"sim_operator"`
+	HasSim        bool   `json:"has_sim"`
+}
+
+type Lease struct {
+	Id            int         `json:"lease_id"`
+	Token         string      `json:"token"`
+	UserId        int         `json:"user_id"`
+	PhoneId       *int        `json:"phone_id"`
+	Filter        LeaseFilter `json:"filter"`
+	Status        string      `json:"status"`
+	TtlSeconds    int         `json:"ttl_seconds"`
+	QueuePosition int         `json:"queue_position,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	GrantedAt     *time.Time  `json:"granted_at"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+	ReleasedAt    *time.Time  `json:"released_at"`
+}
+
+func (l *Lease) IsOpen() bool {
+	return l.Status == LeaseQueued || l.Status == LeaseActive
+}
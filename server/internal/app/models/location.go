@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	LocationSite    = "site"
+	LocationRoom    = "room"
+	LocationCabinet = "cabinet"
+	LocationSlot    = "slot"
+)
+
+// parentKinds tells what each kind of location is nested in. Sites are the
+// roots.
+var parentKinds = map[string]string{
+	LocationSite:    "",
+	LocationRoom:    LocationSite,
+	LocationCabinet: LocationRoom,
+	LocationSlot:    LocationCabinet,
+}
+
+// ParentKind returns the kind of location one of the given kind must be
+// nested in, or "" for sites. ok is false for unknown kinds.
+func ParentKind(kind string) (string, bool) {
+	parent, ok := parentKinds[kind]
+	return parent, ok
+}
+
+// Location is a node of the site, room, cabinet and slot tree. Phones are
+// assigned to slots.
+type Location struct {
+	Id        int       `json:"location_id"`
+	ParentId  *int      `json:"parent_id"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LocationPath names the locations a slot is nested in.
+type LocationPath struct {
+	SlotId  int    `json:"slot_id"`
+	Site    string `json:"site"`
+	Room    string `json:"room"`
+	Cabinet string `json:"cabinet"`
+	Slot    string `json:"slot"`
+}
+
+func (p *LocationPath) String() string {
+	return strings.Join([]string{p.Site, p.Room, p.Cabinet, p.Slot}, " / ")
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Notification struct {
 	Id          int    `json:"notification_id"`
 	ModelNumber string `json:"model_number"`
@@ -7,4 +9,40 @@ type Notification struct {
 	Sender      string `json:"sender"`
 	Body        string `json:"body"`
 	Timestamp   int64  `json:"timestamp"`
+	PhoneId     *int   `json:"phone_id"`
+	SimCardId   *int   `json:"sim_card_id"`
+	// PhoneNumber is joined from the receiving SIM.
+	PhoneNumber string `json:"phone_number"`
+	// Code and Links are extracted from Body at ingestion.
+	Code       string    `json:"code"`
+	Links      []string  `json:"links"`
+	ReceivedAt time.Time `json:"received_at"`
+	// IdempotencyKey is chosen by the agent; a retried notification carries
+	// the same key. Fingerprint identifies identical notifications of agents
+	// that don't send keys.
+	IdempotencyKey string `json:"idempotency_key"`
+	Fingerprint    string `json:"-"`
+}
+
+// NotificationCursor is the position of the last notification on a page,
+// in (Timestamp, Id) order.
+type NotificationCursor struct {
+	Timestamp int64
+	Id        int
+}
+
+// NotificationFilter selects notifications for search. Empty fields and zero
+// times don't filter.
+type NotificationFilter struct {
+	ModelNumber string
+	PhoneNumber string
+	Sender      string
+	Source      string
+	From        time.Time
+	To          time.Time
+	// Query is a full-text search on Body.
+	Query      string
+	Descending bool
+	After      *NotificationCursor
+	Limit      int
 }
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Phone struct {
 	Id             int      `json:"phone_id"`
 	Manufacturer   string   `json:"manufacturer"`
@@ -13,4 +15,37 @@ type Phone struct {
 	SupportedArchs []string `json:"supported_archs"`
 	SimSlots       int      `json:"sim_slots"`
 	SdSlots        int      `json:"sd_slots"`
+
+	// Set by admins; phone reports never change them.
+	InventoryTag string `json:"inventory_tag"`
+	DisplayName  string `json:"display_name"`
+	Location     string `json:"location"`
+	Notes        string `json:"notes"`
+	// SlotId is the slot location the phone is kept in.
+	SlotId *int `json:"slot_id"`
+
+	// LastSeenAt is the last heartbeat or report; nil if the phone hasn't
+	// been in touch yet.
+	LastSeenAt *time.Time `json:"last_seen_at"`
+}
+
+// KeepDetails copies the fields a report doesn't carry from the stored
+// phone: the admin-editable ones and the last contact.
+func (p *Phone) KeepDetails(stored Phone) {
+	p.InventoryTag = stored.InventoryTag
+	p.DisplayName = stored.DisplayName
+	p.Location = stored.Location
+	p.Notes = stored.Notes
+	p.SlotId = stored.SlotId
+	p.LastSeenAt = stored.LastSeenAt
+}
+
+// Name is how the phone is called in messages: its display name if set,
+// otherwise its model number.
+func (p *Phone) Name() string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+
+	return p.ModelNumber
 }
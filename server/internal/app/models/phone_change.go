@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// The fields of the history entries recording that a phone was moved: to
+// another slot or free-text location, or to another network as told by its
+// heartbeats and reports.
+const (
+	PhoneChangeSlot     = "slot"
+	PhoneChangeLocation = "location"
+	PhoneChangeSubnet   = "subnet"
+)
+
+type PhoneChange struct {
+	Id        int       `json:"change_id"`
+	PhoneId   int       `json:"phone_id"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Reservation struct {
+	Id          int        `json:"reservation_id"`
+	PhoneId     int        `json:"phone_id"`
+	UserId      int        `json:"user_id"`
+	UserName    string     `json:"user_name"`
+	UserEmail   string     `json:"user_email"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      time.Time  `json:"ends_at"`
+	CheckedInAt *time.Time `json:"checked_in_at"`
+	Note        string     `json:"note"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the phone is held under this reservation at t.
+func (r *Reservation) IsActive(t time.Time) bool {
+	return r.CheckedInAt == nil && !r.StartsAt.After(t) && r.EndsAt.After(t)
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RetentionRule selects notifications to purge. A notification is selected
+// when it is older than ReceivedBefore or beyond the newest KeepPerDevice
+// notifications of its device, counting only notifications the rule applies
+// to.
+type RetentionRule struct {
+	// Sources limits the rule to these sources, ExcludeSources exempts them.
+	Sources        []string
+	ExcludeSources []string
+	// Zero values disable the respective limit.
+	ReceivedBefore time.Time
+	KeepPerDevice  int
+	// Archive copies purged notifications to the archive before deleting.
+	Archive bool
+}
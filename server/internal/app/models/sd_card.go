@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type SdInfo struct {
 	Id               int    `json:"sd_card_id"`
 	PhoneId          *int   `json:"phone_id"`
@@ -9,3 +11,29 @@ type SdInfo struct {
 	UsedSpace        int    `json:"used_space"`
 	FreeSpace        int    `json:"free_space"`
 }
+
+// UsedPercent is the share of TotalSpace in use, 0 for cards of unknown size.
+func (sd *SdInfo) UsedPercent() float64 {
+	if sd.TotalSpace <= 0 {
+		return 0
+	}
+
+	return float64(sd.UsedSpace) * 100 / float64(sd.TotalSpace)
+}
+
+// SdUsage is the fill level of an SD card at one phone report.
+type SdUsage struct {
+	SdCardId   int       `json:"sd_card_id"`
+	TotalSpace int       `json:"total_space"`
+	UsedSpace  int       `json:"used_space"`
+	FreeSpace  int       `json:"free_space"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// SdFilter selects SD cards. Empty fields don't filter.
+type SdFilter struct {
+	PhoneId          *int
+	SdManufacturerId string
+	// Assigned selects cards that are or aren't in a phone.
+	Assigned *bool
+}
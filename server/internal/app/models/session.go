@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Session struct {
+	Id                int        `json:"session_id"`
+	UserId            int        `json:"user_id"`
+	TokenHash         string     `json:"-"`
+	PreviousTokenHash string     `json:"-"`
+	UserAgent         string     `json:"user_agent"`
+	Ip                string     `json:"ip"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+}
+
+func (s *Session) IsActive(t time.Time) bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(t)
+}
@@ -5,4 +5,27 @@ type SimInfo struct {
 	PhoneId     *int   `json:"phone_id"`
 	PhoneNumber string `json:"phone_number"`
 	Operator    string `json:"operator"`
+	// Slot is the index of the SIM in the phone's last report, nil once the
+	// SIM has been taken out.
+	Slot *int `json:"slot"`
+	// Notes, Tags and Plan are kept by lab staff; phone reports don't touch
+	// them.
+	Notes string   `json:"notes"`
+	Tags  []string `json:"tags"`
+	Plan  SimPlan  `json:"plan"`
+}
+
+type SimPlan struct {
+	Name          string `json:"name"`
+	ContractOwner string `json:"contract_owner"`
+	// MonthlyLimit is free text, e.g. "20 GB" or "500 RUB".
+	MonthlyLimit string `json:"monthly_limit"`
+}
+
+// SimFilter selects SIM cards. Empty fields don't filter.
+type SimFilter struct {
+	Operator string
+	// Assigned selects SIMs that are or aren't in a phone.
+	Assigned *bool
+	Tag      string
 }
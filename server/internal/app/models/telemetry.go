@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	MetricBatteryLevel       = "battery_level"
+	MetricBatteryTemperature = "battery_temperature"
+	MetricBatteryCycleCount  = "battery_cycle_count"
+)
+
+// Battery is the battery state sent with a phone report.
+type Battery struct {
+	// Level is in percent.
+	Level int `json:"level"`
+	// Temperature is in degrees Celsius.
+	Temperature float64 `json:"temperature"`
+	// Health is the Android battery health, e.g. "good" or "overheat".
+	Health     string `json:"health"`
+	CycleCount int    `json:"cycle_count"`
+}
+
+// Metric returns the value of a numeric battery metric.
+func (b *Battery) Metric(name string) (float64, bool) {
+	switch name {
+	case MetricBatteryLevel:
+		return float64(b.Level), true
+	case MetricBatteryTemperature:
+		return b.Temperature, true
+	case MetricBatteryCycleCount:
+		return float64(b.CycleCount), true
+	}
+
+	return 0, false
+}
+
+type BatterySample struct {
+	PhoneId int `json:"phone_id"`
+	Battery
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// TelemetryPoint aggregates the samples of one metric taken from At until
+// the start of the next point.
+type TelemetryPoint struct {
+	At      time.Time `json:"at"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	Avg     float64   `json:"avg"`
+	Samples int       `json:"samples"`
+}
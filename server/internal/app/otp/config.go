@@ -0,0 +1,27 @@
+package otp
+
+import "time"
+
+// Rule extracts codes and links from the notifications of matching senders.
+type Rule struct {
+	// Sender is a regular expression matched against the notification
+	// sender. An empty Sender matches every sender.
+	Sender string `toml:"sender"`
+	// Code is a regular expression whose first capture group is the code.
+	Code string `toml:"code"`
+	// Link is a regular expression matching a whole link.
+	Link string `toml:"link"`
+}
+
+type Config struct {
+	// Rules are tried in order before the built-in defaults.
+	Rules []Rule `toml:"rules"`
+	// MaxWait bounds how long GET /api/otp long-polls.
+	MaxWait time.Duration `toml:"max_wait"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		MaxWait: time.Minute,
+	}
+}
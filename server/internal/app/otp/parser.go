@@ -0,0 +1,91 @@
+// Package otp extracts one-time codes and links from notification bodies.
+package otp
+
+import (
+	"fmt"
+	"regexp"
+	"server/internal/app/models"
+)
+
+// defaultRules catch the usual "Your code: 123456" and "G-123456 is your
+// code" messages and plain links. They run after the configured rules.
+var defaultRules = []Rule{
+	{Code: `(?i)\b(?:[a-z]{1,3}-)?(\d{4,8})\s+(?:is\s+your\b[^.\n]{0,40}?\b(?:code|otp|pin|password)|[—–-]\s*ваш\s+(?:код|пароль))`},
+	{Code: `(?i)(?:code|otp|pin|password|код|пароль)\D{0,20}?(\d{4,8})\b`},
+	{Link: `https?://[^\s<>"']+`},
+}
+
+type rule struct {
+	sender *regexp.Regexp
+	code   *regexp.Regexp
+	link   *regexp.Regexp
+}
+
+type Parser struct {
+	rules []rule
+}
+
+func NewParser(config *Config) (*Parser, error) {
+	p := &Parser{}
+
+	for i, r := range append(append([]Rule{}, config.Rules...), defaultRules...) {
+		var compiled rule
+		var err error
+
+		if compiled.sender, err = compile(r.Sender); err != nil {
+			return nil, fmt.Errorf("otp: rule %d: sender: %w", i, err)
+		}
+		if compiled.code, err = compile(r.Code); err != nil {
+			return nil, fmt.Errorf("otp: rule %d: code: %w", i, err)
+		}
+		if compiled.code != nil && compiled.code.NumSubexp() < 1 {
+			return nil, fmt.Errorf("otp: rule %d: code must have a capture group", i)
+		}
+		if compiled.link, err = compile(r.Link); err != nil {
+			return nil, fmt.Errorf("otp: rule %d: link: %w", i, err)
+		}
+
+		p.rules = append(p.rules, compiled)
+	}
+
+	return p, nil
+}
+
+func compile(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile(expr)
+}
+
+// Parse sets the Code and Links of n. The first rule matching the sender
+// and the body wins, separately for codes and for links.
+func (p *Parser) Parse(n *models.Notification) {
+	n.Code = ""
+	n.Links = []string{}
+
+	codeFound, linksFound := false, false
+	for _, r := range p.rules {
+		if r.sender != nil && !r.sender.MatchString(n.Sender) {
+			continue
+		}
+
+		if !codeFound && r.code != nil {
+			if m := r.code.FindStringSubmatch(n.Body); m != nil {
+				n.Code = m[1]
+				codeFound = true
+			}
+		}
+		if !linksFound && r.link != nil {
+			if links := r.link.FindAllString(n.Body, -1); links != nil {
+				n.Links = links
+				linksFound = true
+			}
+		}
+
+		if codeFound && linksFound {
+			return
+		}
+	}
+}
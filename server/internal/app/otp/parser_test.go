@@ -0,0 +1,53 @@
+package otp_test
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/otp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_Parse(t *testing.T) {
+	p, err := otp.NewParser(&otp.Config{
+		Rules: []otp.Rule{
+			{Sender: `^Bank$`, Code: `(\d{3}-\d{3})`},
+			{Sender: `^Shop$`, Link: `https://shop\.example/\S+`},
+		},
+	})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		sender string
+		body   string
+		code   string
+		links  []string
+	}{
+		{"default code", "900", "Код подтверждения: 4821. Никому не сообщайте", "4821", []string{}},
+		{"default english", "Google", "G-582913 is your Google verification code", "582913", []string{}},
+		{"default code first", "Yandex", "382915 — ваш код для входа", "382915", []string{}},
+		{"english keyword", "Telegram", "Login code: 71234. Do not give this code to anyone", "71234", []string{}},
+		{"sender rule", "Bank", "Use 123-456 to confirm the payment of 1500", "123-456", []string{}},
+		{"sender rule ignored", "Other", "Use 123-456 to confirm", "", []string{}},
+		{"default link", "Service", "Confirm here: https://example.org/c?t=1 or code 9911", "9911", []string{"https://example.org/c?t=1"}},
+		{"sender link rule", "Shop", "Track https://t.example/1 and pay https://shop.example/p/42", "", []string{"https://shop.example/p/42"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := &models.Notification{Sender: tc.sender, Body: tc.body}
+			p.Parse(n)
+			assert.Equal(t, tc.code, n.Code)
+			assert.Equal(t, tc.links, n.Links)
+		})
+	}
+}
+
+func TestNewParser_InvalidRules(t *testing.T) {
+	_, err := otp.NewParser(&otp.Config{Rules: []otp.Rule{{Code: `(`}}})
+	assert.Error(t, err)
+
+	_, err = otp.NewParser(&otp.Config{Rules: []otp.Rule{{Code: `\d+`}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+// Package presence tells from the last contact of a phone whether it is
+// still alive, and raises events for phones that went silent.
+package presence
+
+import (
+	"fmt"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+const (
+	Online  = "online"
+	Stale   = "stale"
+	Offline = "offline"
+)
+
+// Status classifies a phone by the time since it was last seen. Phones that
+// were never seen are offline.
+func (c *Config) Status(lastSeenAt *time.Time, now time.Time) string {
+	if lastSeenAt == nil {
+		return Offline
+	}
+
+	silence := now.Sub(*lastSeenAt)
+	switch {
+	case silence >= c.OfflineAfter:
+		return Offline
+	case silence >= c.StaleAfter:
+		return Stale
+	default:
+		return Online
+	}
+}
+
+type Checker struct {
+	config *Config
+	phones storage.PhoneRepository
+	events storage.EventRepository
+	now    func() time.Time
+}
+
+func NewChecker(config *Config, phones storage.PhoneRepository, events storage.EventRepository) *Checker {
+	return &Checker{
+		config: config,
+		phones: phones,
+		events: events,
+		now:    time.Now,
+	}
+}
+
+// Check raises a device_offline event for every phone that went silent
+// since the last check and returns the events.
+func (c *Checker) Check() ([]models.Event, error) {
+	now := c.now()
+
+	phones, err := c.phones.MarkOffline(now.Add(-c.config.OfflineAfter), now)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []models.Event
+
+	for i := range phones {
+		p := &phones[i]
+		e, err := c.events.Create(&models.Event{
+			PhoneId: &p.Id,
+			Type:    models.EventDeviceOffline,
+			Message: fmt.Sprintf("%s has not been seen since %s", p.Name(), p.LastSeenAt.Format(time.RFC3339)),
+		})
+		if err != nil {
+			return events, err
+		}
+		events = append(events, *e)
+	}
+
+	return events, nil
+}
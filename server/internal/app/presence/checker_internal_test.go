@@ -0,0 +1,60 @@
+package presence
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Status(t *testing.T) {
+	c := NewConfig()
+	now := time.Date(2023, 8, 15, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	assert.Equal(t, Offline, c.Status(nil, now))
+	assert.Equal(t, Online, c.Status(ago(time.Minute), now))
+	assert.Equal(t, Stale, c.Status(ago(2*time.Minute), now))
+	assert.Equal(t, Offline, c.Status(ago(10*time.Minute), now))
+}
+
+func TestChecker_Check(t *testing.T) {
+	st := memstorage.New()
+	silent, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	alive, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+	st.Phone().Create(&models.Phone{ModelNumber: "Pixel 7"})
+
+	now := time.Date(2023, 8, 15, 12, 0, 0, 0, time.UTC)
+	st.Heartbeat().Save(&models.Heartbeat{PhoneId: silent.Id, ReceivedAt: now.Add(-time.Hour)})
+	st.Heartbeat().Save(&models.Heartbeat{PhoneId: alive.Id, ReceivedAt: now.Add(-time.Minute)})
+
+	c := NewChecker(NewConfig(), st.Phone(), st.Event())
+	c.now = func() time.Time { return now }
+
+	// The phone that never reported a heartbeat raises nothing.
+	events, err := c.Check()
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, silent.Id, *events[0].PhoneId)
+		assert.Equal(t, models.EventDeviceOffline, events[0].Type)
+		assert.Contains(t, events[0].Message, "SM-G973F/DS")
+	}
+
+	// A silence is reported once.
+	events, _ = c.Check()
+	assert.Empty(t, events)
+
+	// Contact clears it, so the next silence is reported again.
+	st.Heartbeat().Save(&models.Heartbeat{PhoneId: silent.Id, ReceivedAt: now})
+	c.now = func() time.Time { return now.Add(time.Hour) }
+	events, _ = c.Check()
+	assert.Len(t, events, 2)
+
+	stored, _ := st.Event().Select(models.EventFilter{PhoneId: &silent.Id})
+	assert.Len(t, stored, 2)
+}
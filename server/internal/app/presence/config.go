@@ -0,0 +1,23 @@
+package presence
+
+import "time"
+
+type Config struct {
+	// StaleAfter is how long a phone may be silent before it is shown as
+	// stale rather than online.
+	StaleAfter time.Duration `toml:"stale_after"`
+	// OfflineAfter is how long a phone may be silent before it is offline
+	// and an event is raised.
+	OfflineAfter time.Duration `toml:"offline_after"`
+	// CheckInterval is how often silent phones are looked for. Zero
+	// disables the checker; statuses are still computed.
+	CheckInterval time.Duration `toml:"check_interval"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		StaleAfter:    2 * time.Minute,
+		OfflineAfter:  10 * time.Minute,
+		CheckInterval: time.Minute,
+	}
+}
@@ -0,0 +1,256 @@
+package qr
+
+type matrix struct {
+	size    int
+	modules []bool
+	// function marks the modules of the fixed patterns, which carry no data
+	// and aren't masked.
+	function []bool
+}
+
+func newMatrix(ver int) *matrix {
+	size := 17 + 4*ver
+	return &matrix{
+		size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.modules[y*m.size+x] = dark
+	m.function[y*m.size+x] = true
+}
+
+func (m *matrix) drawFunctionPatterns(v *version, ver int) {
+	for i := 0; i < m.size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+
+	last := len(v.alignments) - 1
+	for i, x := range v.alignments {
+		for j, y := range v.alignments {
+			// The corners taken by finders have none.
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			m.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format areas; the bits are drawn once the mask is known.
+	m.drawFormatBits(0)
+	m.drawVersion(ver)
+}
+
+// drawFinder draws a finder pattern centred on x, y with its separator.
+func (m *matrix) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= m.size || yy < 0 || yy >= m.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			m.set(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (m *matrix) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the level M format information for
+// the mask.
+func (m *matrix) drawFormatBits(mask int) {
+	data := mask // Level M is 00.
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(bits, i))
+	}
+	m.set(8, 7, bit(bits, 6))
+	m.set(8, 8, bit(bits, 7))
+	m.set(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(bits, i))
+	}
+	m.set(8, m.size-8, true)
+}
+
+// drawVersion draws the version information, which versions 7 and up have.
+func (m *matrix) drawVersion(ver int) {
+	if ver < 7 {
+		return
+	}
+
+	rem := ver
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := ver<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a, b := m.size-11+i%3, i/3
+		m.set(a, b, bit(bits, i))
+		m.set(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords fills the data modules in the zigzag order of the standard:
+// two columns at a time from the right, alternately upwards and downwards.
+func (m *matrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y*m.size+x] || i >= len(data)*8 {
+					continue
+				}
+				m.modules[y*m.size+x] = data[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by the mask. Applying it twice
+// undoes it.
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.function[y*m.size+x] {
+				continue
+			}
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip {
+				m.modules[y*m.size+x] = !m.modules[y*m.size+x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to scan, by the four rules of the
+// standard. Lower is better.
+func (m *matrix) penalty() int {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			x, y = y, x
+		}
+		return m.modules[y*m.size+x]
+	}
+
+	result := 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < m.size; y++ {
+			run := 0
+			var pattern uint
+			for x := 0; x < m.size; x++ {
+				dark := at(x, y, transpose)
+				if x > 0 && dark == at(x-1, y, transpose) {
+					run++
+					if run == 5 {
+						result += 3
+					} else if run > 5 {
+						result++
+					}
+				} else {
+					run = 1
+				}
+
+				// Finder-like 1:1:3:1:1 runs with four light modules on
+				// one side.
+				pattern = (pattern << 1) & 0x7FF
+				if dark {
+					pattern |= 1
+				}
+				if x >= 10 && (pattern == 0b10111010000 || pattern == 0b00001011101) {
+					result += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			c := m.modules[y*m.size+x]
+			if c {
+				dark++
+			}
+			if x > 0 && y > 0 && c == m.modules[y*m.size+x-1] && c == m.modules[(y-1)*m.size+x] && c == m.modules[(y-1)*m.size+x-1] {
+				result += 3
+			}
+		}
+	}
+	total := m.size * m.size
+	result += abs(dark*100/total-50) / 5 * 10
+
+	return result
+}
+
+func bit(x, i int) bool {
+	return x>>i&1 == 1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,214 @@
+// Package qr encodes text as a QR code (ISO/IEC 18004) for printed labels.
+// It covers what labels need: byte mode, error correction level M and
+// versions 1 to 10, which hold up to 213 bytes.
+package qr
+
+import "errors"
+
+var ErrTooLong = errors.New("qr: data too long")
+
+// Code is a square of modules, true for dark ones. It doesn't include the
+// quiet zone: renderers leave 4 light modules around it.
+type Code struct {
+	Size    int
+	modules []bool
+}
+
+func (c *Code) Black(x, y int) bool {
+	return c.modules[y*c.Size+x]
+}
+
+// QuietZone is the light border, in modules, a code needs to be scanned.
+const QuietZone = 4
+
+// version describes the level M error correction blocks of a version.
+type version struct {
+	ecPerBlock int
+	// blocks holds the number of data codewords of each block. Shorter
+	// blocks come first.
+	blocks     []int
+	alignments []int
+}
+
+var versions = []version{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v *version) dataCodewords() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// countBits is the length of the character count of byte mode.
+func countBits(ver int) int {
+	if ver < 10 {
+		return 8
+	}
+	return 16
+}
+
+// Encode returns the smallest code holding data, with the mask that scans
+// best.
+func Encode(data []byte) (*Code, error) {
+	ver := 1
+	for ; ver < len(versions); ver++ {
+		if 4+countBits(ver)+8*len(data) <= 8*versions[ver].dataCodewords() {
+			break
+		}
+	}
+	if ver == len(versions) {
+		return nil, ErrTooLong
+	}
+	v := &versions[ver]
+
+	codewords := interleave(v, dataCodewords(v, ver, data))
+
+	m := newMatrix(ver)
+	m.drawFunctionPatterns(v, ver)
+	m.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormatBits(mask)
+		if p := m.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask)
+	}
+	m.applyMask(best)
+	m.drawFormatBits(best)
+
+	return &Code{Size: m.size, modules: m.modules}, nil
+}
+
+type bitBuffer struct {
+	bytes []byte
+	n     int
+}
+
+func (b *bitBuffer) append(value, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if value>>i&1 == 1 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// dataCodewords lays out data as a single byte mode segment, padded to the
+// capacity of the version.
+func dataCodewords(v *version, ver int, data []byte) []byte {
+	capacity := v.dataCodewords()
+
+	var b bitBuffer
+	b.append(0b0100, 4)
+	b.append(len(data), countBits(ver))
+	for _, c := range data {
+		b.append(int(c), 8)
+	}
+	terminator := 8*capacity - b.n
+	if terminator > 4 {
+		terminator = 4
+	}
+	b.append(0, terminator)
+	b.append(0, (8-b.n%8)%8)
+	for pad := 0xEC; len(b.bytes) < capacity; pad ^= 0xEC ^ 0x11 {
+		b.bytes = append(b.bytes, byte(pad))
+	}
+
+	return b.bytes
+}
+
+// interleave splits the data into blocks, adds their error correction and
+// interleaves the codewords of all blocks.
+func interleave(v *version, data []byte) []byte {
+	divisor := rsDivisor(v.ecPerBlock)
+
+	var blocks, ecs [][]byte
+	longest := 0
+	for _, n := range v.blocks {
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, rsRemainder(data[:n], divisor))
+		data = data[n:]
+		if n > longest {
+			longest = n
+		}
+	}
+
+	var result []byte
+	for i := 0; i < longest; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				result = append(result, b[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, ec := range ecs {
+			result = append(result, ec[i])
+		}
+	}
+
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor returns the generator polynomial of the given degree, highest
+// coefficient first and without the leading 1.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// rsRemainder returns the error correction codewords of data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+
+	return result
+}
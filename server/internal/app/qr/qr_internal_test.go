@@ -0,0 +1,51 @@
+package qr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRsRemainder(t *testing.T) {
+	// The version 1-M example of the standard.
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	ec := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+
+	assert.Equal(t, ec, rsRemainder(data, rsDivisor(len(ec))))
+}
+
+func TestEncode(t *testing.T) {
+	finder := func(c *Code, x, y int) bool {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				dist := max(abs(dx-3), abs(dy-3))
+				if c.Black(x+dx, y+dy) != (dist != 2) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	for _, tc := range []struct {
+		length int
+		size   int
+	}{
+		{14, 21},
+		{15, 25},
+		{213, 57},
+	} {
+		c, err := Encode([]byte(strings.Repeat("a", tc.length)))
+		if assert.NoError(t, err) {
+			assert.Equal(t, tc.size, c.Size)
+			assert.True(t, finder(c, 0, 0))
+			assert.True(t, finder(c, c.Size-7, 0))
+			assert.True(t, finder(c, 0, c.Size-7))
+			assert.True(t, c.Black(8, c.Size-8))
+		}
+	}
+
+	_, err := Encode([]byte(strings.Repeat("a", 214)))
+	assert.Equal(t, ErrTooLong, err)
+}
@@ -0,0 +1,28 @@
+package retention
+
+import "time"
+
+// Policy limits how long notifications are kept. Zero values mean no limit.
+type Policy struct {
+	MaxAge           time.Duration `toml:"max_age"`
+	MaxRowsPerDevice int           `toml:"max_rows_per_device"`
+}
+
+type Config struct {
+	// Interval is how often the purge runs. Zero disables the worker; purges
+	// can still be triggered through the API.
+	Interval time.Duration `toml:"interval"`
+	Policy
+	// Sources replaces Policy for notifications from the given sources.
+	Sources map[string]Policy `toml:"sources"`
+	// Archive moves purged notifications to notifications_archive instead of
+	// dropping them.
+	Archive bool `toml:"archive"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Sources: map[string]Policy{},
+		Archive: true,
+	}
+}
@@ -0,0 +1,136 @@
+// Package retention purges notifications that are past the configured age or
+// row limits.
+package retention
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSource is the key under which notifications not covered by a
+// per-source policy are counted.
+const DefaultSource = "*"
+
+type Result struct {
+	DryRun  bool      `json:"dry_run"`
+	At      time.Time `json:"at"`
+	Removed int       `json:"removed"`
+	// BySource breaks Removed down by policy: configured sources and
+	// DefaultSource.
+	BySource map[string]int `json:"by_source"`
+}
+
+// Stats are totals over the purges run since the server started. Dry runs
+// are not counted.
+type Stats struct {
+	Runs        int            `json:"runs"`
+	Removed     int            `json:"removed"`
+	BySource    map[string]int `json:"by_source"`
+	LastRun     *Result        `json:"last_run"`
+	LastError   string         `json:"last_error"`
+	LastErrorAt *time.Time     `json:"last_error_at"`
+}
+
+type Purger struct {
+	mu     sync.Mutex
+	config *Config
+	repo   storage.NotificationRepository
+	now    func() time.Time
+	stats  Stats
+}
+
+func NewPurger(config *Config, repo storage.NotificationRepository) *Purger {
+	return &Purger{
+		config: config,
+		repo:   repo,
+		now:    time.Now,
+		stats:  Stats{BySource: map[string]int{}},
+	}
+}
+
+// Run applies the policies. With dryRun it only counts what would be
+// removed.
+func (p *Purger) Run(dryRun bool) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := &Result{DryRun: dryRun, At: p.now(), BySource: map[string]int{}}
+
+	sources := make([]string, 0, len(p.config.Sources))
+	for source := range p.config.Sources {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	rules := []models.RetentionRule{p.rule(p.config.Policy, res.At)}
+	rules[0].ExcludeSources = sources
+	for _, source := range sources {
+		rule := p.rule(p.config.Sources[source], res.At)
+		rule.Sources = []string{source}
+		rules = append(rules, rule)
+	}
+
+	for i, source := range append([]string{DefaultSource}, sources...) {
+		n, err := p.repo.Purge(rules[i], dryRun)
+		res.Removed += n
+		res.BySource[source] = n
+		if err != nil {
+			p.record(res, err)
+			return nil, err
+		}
+	}
+
+	p.record(res, nil)
+
+	return res, nil
+}
+
+func (p *Purger) rule(policy Policy, now time.Time) models.RetentionRule {
+	rule := models.RetentionRule{
+		KeepPerDevice: policy.MaxRowsPerDevice,
+		Archive:       p.config.Archive,
+	}
+	if policy.MaxAge > 0 {
+		rule.ReceivedBefore = now.Add(-policy.MaxAge)
+	}
+
+	return rule
+}
+
+// record counts what a purge removed, including the part done before it
+// failed.
+func (p *Purger) record(res *Result, err error) {
+	if err != nil {
+		at := res.At
+		p.stats.LastError, p.stats.LastErrorAt = err.Error(), &at
+	}
+	if res.DryRun {
+		return
+	}
+
+	p.stats.Runs++
+	p.stats.Removed += res.Removed
+	for source, n := range res.BySource {
+		p.stats.BySource[source] += n
+	}
+	if err == nil {
+		p.stats.LastRun = res
+	}
+}
+
+// Stats returns a copy of the purge totals.
+func (p *Purger) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := p.stats
+	stats.BySource = make(map[string]int, len(p.stats.BySource))
+	for source, n := range p.stats.BySource {
+		stats.BySource[source] = n
+	}
+
+	return stats
+}
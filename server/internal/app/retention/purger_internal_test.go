@@ -0,0 +1,78 @@
+package retention
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurger_Run(t *testing.T) {
+	st := memstorage.New()
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-A525F"})
+
+	now := time.Date(2023, 7, 25, 12, 0, 0, 0, time.UTC)
+	add := func(model, source string, age time.Duration) {
+		st.Notification().Create(&models.Notification{
+			ModelNumber: model, Source: source, Sender: "900", Body: "Code 1234", ReceivedAt: now.Add(-age),
+		})
+	}
+	// Default policy: older than 30 days goes, and only 2 per device are kept.
+	add("SM-G973F/DS", "sms", 40*24*time.Hour)
+	add("SM-G973F/DS", "sms", time.Hour)
+	add("SM-G973F/DS", "sms", time.Hour)
+	add("SM-G973F/DS", "sms", time.Hour)
+	add("SM-A525F", "sms", time.Hour)
+	// Telegram is kept for a day without a row limit.
+	add("SM-G973F/DS", "telegram", 2*24*time.Hour)
+	add("SM-G973F/DS", "telegram", time.Hour)
+	add("SM-G973F/DS", "telegram", time.Hour)
+	add("SM-G973F/DS", "telegram", time.Hour)
+
+	p := NewPurger(&Config{
+		Policy:  Policy{MaxAge: 30 * 24 * time.Hour, MaxRowsPerDevice: 2},
+		Sources: map[string]Policy{"telegram": {MaxAge: 24 * time.Hour}},
+	}, st.Notification())
+	p.now = func() time.Time { return now }
+
+	res, err := p.Run(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Removed)
+	assert.Equal(t, map[string]int{DefaultSource: 2, "telegram": 1}, res.BySource)
+	assert.Zero(t, p.Stats().Runs)
+
+	notifications, _ := st.Notification().SelectByModelTag("SM-G973F/DS")
+	assert.Len(t, notifications, 8)
+
+	res, err = p.Run(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Removed)
+
+	notifications, _ = st.Notification().SelectByModelTag("SM-G973F/DS")
+	var ids []int
+	for _, n := range notifications {
+		ids = append(ids, n.Id)
+	}
+	assert.Equal(t, []int{3, 4, 7, 8, 9}, ids)
+
+	res, _ = p.Run(false)
+	assert.Zero(t, res.Removed)
+
+	stats := p.Stats()
+	assert.Equal(t, 2, stats.Runs)
+	assert.Equal(t, 3, stats.Removed)
+	assert.Equal(t, map[string]int{DefaultSource: 2, "telegram": 1}, stats.BySource)
+}
+
+func TestPurger_NoLimits(t *testing.T) {
+	st := memstorage.New()
+	st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	st.Notification().Create(&models.Notification{ModelNumber: "SM-G973F/DS", ReceivedAt: time.Unix(0, 0)})
+
+	res, err := NewPurger(NewConfig(), st.Notification()).Run(false)
+	assert.NoError(t, err)
+	assert.Zero(t, res.Removed)
+}
@@ -0,0 +1,11 @@
+package storage
+
+import "errors"
+
+var (
+	ErrRecordNotFound = errors.New("record not found")
+	ErrRecordExists   = errors.New("record already exists")
+
+	ErrReservationConflict = errors.New("reservation overlaps an existing one")
+	ErrPhoneBound          = errors.New("phone is bound to another device credential")
+)
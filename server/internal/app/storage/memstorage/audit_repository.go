@@ -0,0 +1,108 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+type AuditRepository struct {
+	storage *Storage
+}
+
+func (r *AuditRepository) Create(a *models.Audit) (*models.Audit, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	a.Id = r.storage.nextId("audits")
+	a.StartedAt = time.Now()
+	a.FinishedAt = nil
+	r.storage.audits[a.Id] = copyAudit(a)
+
+	return a, nil
+}
+
+func (r *AuditRepository) SelectById(id int) (*models.Audit, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	a, ok := r.storage.audits[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copyAudit(a), nil
+}
+
+func (r *AuditRepository) SelectAll() ([]models.Audit, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var audits []models.Audit
+
+	ids := sortedKeys(r.storage.audits)
+	for i := len(ids) - 1; i >= 0; i-- {
+		audits = append(audits, *copyAudit(r.storage.audits[ids[i]]))
+	}
+
+	return audits, nil
+}
+
+func (r *AuditRepository) Finish(id int, at time.Time) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	a, ok := r.storage.audits[id]
+	if !ok || a.FinishedAt != nil {
+		return storage.ErrRecordNotFound
+	}
+	a.FinishedAt = &at
+
+	return nil
+}
+
+func (r *AuditRepository) CreateScan(scan *models.AuditScan) (*models.AuditScan, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, ok := r.storage.audits[scan.AuditId]; !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	scan.Id = r.storage.nextId("audit_scans")
+	scan.ScannedAt = time.Now()
+	r.storage.auditScans[scan.Id] = copyScan(scan)
+
+	return scan, nil
+}
+
+func (r *AuditRepository) SelectScans(auditId int) ([]models.AuditScan, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var scans []models.AuditScan
+
+	for _, id := range sortedKeys(r.storage.auditScans) {
+		if scan := r.storage.auditScans[id]; scan.AuditId == auditId {
+			scans = append(scans, *copyScan(scan))
+		}
+	}
+
+	return scans, nil
+}
+
+func copyAudit(a *models.Audit) *models.Audit {
+	c := *a
+	c.FinishedAt = copyTimePtr(a.FinishedAt)
+
+	return &c
+}
+
+func copyScan(scan *models.AuditScan) *models.AuditScan {
+	c := *scan
+	c.ItemId = copyIntPtr(scan.ItemId)
+	c.SlotId = copyIntPtr(scan.SlotId)
+	c.PhoneId = copyIntPtr(scan.PhoneId)
+
+	return &c
+}
@@ -0,0 +1,98 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+type DeviceReportRepository struct {
+	storage *Storage
+}
+
+func (r *DeviceReportRepository) Save(report *models.DeviceReport) (*models.DeviceReportResult, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	u, ok := r.storage.users[report.UserId]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	user := *u
+	user.Password = ""
+
+	c, ok := r.storage.deviceCredentials[report.CredentialId]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	if c.PhoneId == nil {
+		if p := r.storage.phoneByModelNumber(report.Phone.ModelNumber); p != nil && r.storage.phoneBound(p.Id, c.Id) {
+			return nil, storage.ErrPhoneBound
+		}
+	}
+
+	result := &models.DeviceReportResult{
+		User: &user,
+	}
+
+	created, changes := r.storage.upsertPhone(&report.Phone)
+	phone := &report.Phone
+	now := time.Now()
+	r.storage.markSeen(phone.Id, now)
+	result.PreviousIp = r.storage.swapIp(phone.Id, report.Ip)
+	phone.LastSeenAt = &now
+	result.Phone = phone
+	result.PhoneCreated = created
+	result.PhoneUpdated = !created && len(changes) > 0
+	result.PhoneChanges = changes
+
+	oldSims := make(map[int]bool)
+	for id, sim := range r.storage.simCards {
+		if sim.PhoneId != nil && *sim.PhoneId == phone.Id {
+			oldSims[id] = true
+		}
+	}
+	r.storage.removeSimPhoneId(phone.Id)
+	newSims := make(map[int]bool)
+	for i := range report.SimInfo {
+		if sim := r.storage.createSim(&report.SimInfo[i], phone); sim != nil {
+			newSims[sim.Id] = true
+		}
+	}
+	result.SimsChanged = !sameIds(oldSims, newSims)
+
+	oldSdCards := make(map[int]bool)
+	for id, sd := range r.storage.sdCards {
+		if sd.PhoneId != nil && *sd.PhoneId == phone.Id {
+			oldSdCards[id] = true
+		}
+	}
+	r.storage.removeSdCardPhoneId(phone.Id)
+	newSdCards := make(map[int]bool)
+	for i := range report.SdInfo {
+		if sd := r.storage.createSdCard(&report.SdInfo[i], phone); sd != nil {
+			newSdCards[sd.Id] = true
+		}
+	}
+	result.SdCardsChanged = !sameIds(oldSdCards, newSdCards)
+
+	ownerId, ok := r.storage.userPhones[phone.Id]
+	result.OwnerChanged = !ok || ownerId != user.Id
+	r.storage.createUserPhoneRelation(user.Id, phone.Id)
+	c.PhoneId = intPtr(phone.Id)
+
+	return result, nil
+}
+
+func sameIds(a, b map[int]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id := range a {
+		if !b[id] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,126 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+type EnrollmentRepository struct {
+	storage *Storage
+}
+
+func (r *EnrollmentRepository) CreateToken(t *models.EnrollmentToken) (*models.EnrollmentToken, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, ok := r.storage.users[t.UserId]; !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	for _, existing := range r.storage.enrollmentTokens {
+		if existing.TokenHash == t.TokenHash {
+			return nil, storage.ErrRecordExists
+		}
+	}
+
+	t.Id = r.storage.nextId("enrollment_tokens")
+	t.CreatedAt = time.Now()
+	stored := *t
+	r.storage.enrollmentTokens[t.Id] = &stored
+
+	return t, nil
+}
+
+func (r *EnrollmentRepository) Enroll(tokenHash string, c *models.DeviceCredential, at time.Time) (*models.DeviceCredential, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	var token *models.EnrollmentToken
+	for _, t := range r.storage.enrollmentTokens {
+		if t.TokenHash == tokenHash && t.UsedAt == nil && t.ExpiresAt.After(at) {
+			token = t
+			break
+		}
+	}
+	if token == nil {
+		return nil, storage.ErrRecordNotFound
+	}
+	token.UsedAt = &at
+
+	c.Id = r.storage.nextId("device_credentials")
+	c.UserId = token.UserId
+	c.CreatedAt = at
+	r.storage.deviceCredentials[c.Id] = copyCredential(c)
+
+	return c, nil
+}
+
+func (r *EnrollmentRepository) SelectCredentialById(id int) (*models.DeviceCredential, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	c, ok := r.storage.deviceCredentials[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copyCredential(c), nil
+}
+
+func (r *EnrollmentRepository) SelectCredentialByTokenHash(hash string) (*models.DeviceCredential, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	for _, c := range r.storage.deviceCredentials {
+		if c.TokenHash == hash {
+			return copyCredential(c), nil
+		}
+	}
+
+	return nil, storage.ErrRecordNotFound
+}
+
+func (r *EnrollmentRepository) SelectCredentialsByUserId(userId int) ([]models.DeviceCredential, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var credentials []models.DeviceCredential
+
+	for _, id := range sortedKeys(r.storage.deviceCredentials) {
+		if c := r.storage.deviceCredentials[id]; c.UserId == userId {
+			credentials = append(credentials, *copyCredential(c))
+		}
+	}
+
+	return credentials, nil
+}
+
+func (r *EnrollmentRepository) BindPhone(id, phoneId int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if c, ok := r.storage.deviceCredentials[id]; ok {
+		c.PhoneId = intPtr(phoneId)
+	}
+
+	return nil
+}
+
+func (r *EnrollmentRepository) RevokeCredential(id int, at time.Time) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if c, ok := r.storage.deviceCredentials[id]; ok && c.RevokedAt == nil {
+		c.RevokedAt = &at
+	}
+
+	return nil
+}
+
+func copyCredential(c *models.DeviceCredential) *models.DeviceCredential {
+	cp := *c
+	cp.PhoneId = copyIntPtr(c.PhoneId)
+	cp.RevokedAt = copyTimePtr(c.RevokedAt)
+
+	return &cp
+}
@@ -0,0 +1,50 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"time"
+)
+
+type EventRepository struct {
+	storage *Storage
+}
+
+func (r *EventRepository) Create(e *models.Event) (*models.Event, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	e.Id = r.storage.nextId("events")
+	e.CreatedAt = time.Now()
+	stored := *e
+	stored.PhoneId = copyIntPtr(e.PhoneId)
+	r.storage.events[e.Id] = &stored
+
+	return e, nil
+}
+
+func (r *EventRepository) Select(filter models.EventFilter) ([]models.Event, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var events []models.Event
+
+	ids := sortedKeys(r.storage.events)
+	for i := len(ids) - 1; i >= 0; i-- {
+		e := r.storage.events[ids[i]]
+		if filter.PhoneId != nil && (e.PhoneId == nil || *e.PhoneId != *filter.PhoneId) {
+			continue
+		}
+		if filter.Type != "" && e.Type != filter.Type {
+			continue
+		}
+
+		event := *e
+		event.PhoneId = copyIntPtr(e.PhoneId)
+		events = append(events, event)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+
+	return events, nil
+}
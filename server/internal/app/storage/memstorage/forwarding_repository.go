@@ -0,0 +1,171 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"time"
+)
+
+type ForwardingRepository struct {
+	storage *Storage
+}
+
+func (r *ForwardingRepository) CreateRule(rule *models.ForwardingRule) (*models.ForwardingRule, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	rule.Id = r.storage.nextId("forwarding_rules")
+	rule.CreatedAt = time.Now()
+	r.storage.forwardingRules[rule.Id] = copyRule(rule)
+
+	return rule, nil
+}
+
+func (r *ForwardingRepository) SelectRuleById(id int) (*models.ForwardingRule, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	rule, ok := r.storage.forwardingRules[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copyRule(rule), nil
+}
+
+func (r *ForwardingRepository) SelectRules() ([]models.ForwardingRule, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var rules []models.ForwardingRule
+
+	for _, id := range sortedKeys(r.storage.forwardingRules) {
+		rules = append(rules, *copyRule(r.storage.forwardingRules[id]))
+	}
+
+	return rules, nil
+}
+
+func (r *ForwardingRepository) UpdateRule(rule *models.ForwardingRule) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	existing, ok := r.storage.forwardingRules[rule.Id]
+	if !ok {
+		return storage.ErrRecordNotFound
+	}
+
+	updated := copyRule(rule)
+	updated.CreatedAt = existing.CreatedAt
+	r.storage.forwardingRules[rule.Id] = updated
+
+	return nil
+}
+
+func (r *ForwardingRepository) DeleteRule(id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	r.storage.deleteRule(id)
+
+	return nil
+}
+
+func (r *ForwardingRepository) CreateDelivery(d *models.Delivery) (*models.Delivery, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, ok := r.storage.forwardingRules[d.RuleId]; !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	if _, ok := r.storage.notifications[d.NotificationId]; !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	d.Id = r.storage.nextId("forwarding_deliveries")
+	d.CreatedAt = time.Now()
+	r.storage.deliveries[d.Id] = copyDelivery(d)
+
+	return d, nil
+}
+
+func (r *ForwardingRepository) SelectDueDeliveries(at time.Time, limit int) ([]models.Delivery, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var deliveries []models.Delivery
+
+	for _, id := range sortedKeys(r.storage.deliveries) {
+		d := r.storage.deliveries[id]
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(at) {
+			deliveries = append(deliveries, *copyDelivery(d))
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func (r *ForwardingRepository) SelectDeliveries(filter models.DeliveryFilter) ([]models.Delivery, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var deliveries []models.Delivery
+
+	ids := sortedKeys(r.storage.deliveries)
+	for i := len(ids) - 1; i >= 0; i-- {
+		d := r.storage.deliveries[ids[i]]
+		if filter.RuleId != 0 && d.RuleId != filter.RuleId {
+			continue
+		}
+		if filter.Status != "" && d.Status != filter.Status {
+			continue
+		}
+		deliveries = append(deliveries, *copyDelivery(d))
+		if filter.Limit > 0 && len(deliveries) == filter.Limit {
+			break
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *ForwardingRepository) UpdateDelivery(d *models.Delivery) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	existing, ok := r.storage.deliveries[d.Id]
+	if !ok {
+		return nil
+	}
+
+	existing.Status = d.Status
+	existing.Attempts = d.Attempts
+	existing.NextAttemptAt = d.NextAttemptAt
+	existing.LastStatusCode = d.LastStatusCode
+	existing.LastError = d.LastError
+	existing.DeliveredAt = copyTimePtr(d.DeliveredAt)
+
+	return nil
+}
+
+func copyRule(rule *models.ForwardingRule) *models.ForwardingRule {
+	c := *rule
+	c.PhoneId = copyIntPtr(rule.PhoneId)
+	c.SimCardId = copyIntPtr(rule.SimCardId)
+
+	return &c
+}
+
+func copyDelivery(d *models.Delivery) *models.Delivery {
+	c := *d
+	c.DeliveredAt = copyTimePtr(d.DeliveredAt)
+
+	return &c
+}
@@ -0,0 +1,51 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+)
+
+type HeartbeatRepository struct {
+	storage *Storage
+}
+
+func (r *HeartbeatRepository) Save(hb *models.Heartbeat) (string, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if !r.storage.markSeen(hb.PhoneId, hb.ReceivedAt) {
+		return "", storage.ErrRecordNotFound
+	}
+	previous := r.storage.swapIp(hb.PhoneId, hb.Ip)
+
+	stored := *hb
+	r.storage.heartbeats[hb.PhoneId] = &stored
+
+	return previous, nil
+}
+
+func (r *HeartbeatRepository) SelectByPhoneId(phoneId int) (*models.Heartbeat, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	hb, ok := r.storage.heartbeats[phoneId]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	c := *hb
+
+	return &c, nil
+}
+
+func (r *HeartbeatRepository) SelectAll() ([]models.Heartbeat, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var heartbeats []models.Heartbeat
+
+	for _, id := range sortedKeys(r.storage.heartbeats) {
+		heartbeats = append(heartbeats, *r.storage.heartbeats[id])
+	}
+
+	return heartbeats, nil
+}
@@ -0,0 +1,94 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+type LeaseRepository struct {
+	storage *Storage
+}
+
+func (r *LeaseRepository) Create(l *models.Lease) (*models.Lease, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for _, other := range r.storage.leases {
+		if other.Token == l.Token {
+			return nil, storage.ErrRecordExists
+		}
+	}
+
+	l.Id = r.storage.nextId("leases")
+	l.CreatedAt = time.Now()
+	r.storage.leases[l.Id] = copyLease(l)
+
+	return l, nil
+}
+
+func (r *LeaseRepository) SelectByToken(token string) (*models.Lease, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	for _, l := range r.storage.leases {
+		if l.Token == token {
+			return copyLease(l), nil
+		}
+	}
+
+	return nil, storage.ErrRecordNotFound
+}
+
+func (r *LeaseRepository) SelectOpen() ([]models.Lease, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var leases []models.Lease
+
+	for _, id := range sortedKeys(r.storage.leases) {
+		if l := r.storage.leases[id]; l.IsOpen() {
+			leases = append(leases, *copyLease(l))
+		}
+	}
+
+	return leases, nil
+}
+
+func (r *LeaseRepository) Update(l *models.Lease) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	stored, ok := r.storage.leases[l.Id]
+	if !ok {
+		return nil
+	}
+
+	updated := copyLease(l)
+	updated.Token = stored.Token
+	updated.UserId = stored.UserId
+	updated.Filter = stored.Filter
+	updated.CreatedAt = stored.CreatedAt
+	updated.QueuePosition = 0
+	r.storage.leases[l.Id] = updated
+
+	return nil
+}
+
+func copyLease(l *models.Lease) *models.Lease {
+	c := *l
+	c.PhoneId = copyIntPtr(l.PhoneId)
+	c.GrantedAt = copyTimePtr(l.GrantedAt)
+	c.ReleasedAt = copyTimePtr(l.ReleasedAt)
+
+	return &c
+}
+
+func copyTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	return &c
+}
@@ -0,0 +1,104 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+type LocationRepository struct {
+	storage *Storage
+}
+
+func (r *LocationRepository) Create(l *models.Location) (*models.Location, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if r.storage.locationNameTaken(l) {
+		return nil, storage.ErrRecordExists
+	}
+
+	l.Id = r.storage.nextId("locations")
+	l.CreatedAt = time.Now()
+	r.storage.locations[l.Id] = copyLocation(l)
+
+	return l, nil
+}
+
+func (r *LocationRepository) SelectById(id int) (*models.Location, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	l, ok := r.storage.locations[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copyLocation(l), nil
+}
+
+func (r *LocationRepository) SelectAll() ([]models.Location, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var locations []models.Location
+
+	for _, id := range sortedKeys(r.storage.locations) {
+		locations = append(locations, *copyLocation(r.storage.locations[id]))
+	}
+
+	return locations, nil
+}
+
+func (r *LocationRepository) Update(l *models.Location) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	stored, ok := r.storage.locations[l.Id]
+	if !ok {
+		return storage.ErrRecordNotFound
+	}
+	renamed := *stored
+	renamed.Name = l.Name
+	if r.storage.locationNameTaken(&renamed) {
+		return storage.ErrRecordExists
+	}
+
+	stored.Name = l.Name
+
+	return nil
+}
+
+func (r *LocationRepository) Delete(id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for _, p := range r.storage.phones {
+		if p.SlotId != nil && *p.SlotId == id {
+			p.SlotId = nil
+		}
+	}
+	for _, scan := range r.storage.auditScans {
+		if scan.SlotId != nil && *scan.SlotId == id {
+			scan.SlotId = nil
+		}
+	}
+	delete(r.storage.locations, id)
+
+	return nil
+}
+
+func copyLocation(l *models.Location) *models.Location {
+	c := *l
+	c.ParentId = copyIntPtr(l.ParentId)
+
+	return &c
+}
+
+func sameIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
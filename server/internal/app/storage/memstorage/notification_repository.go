@@ -0,0 +1,247 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type NotificationRepository struct {
+	storage *Storage
+}
+
+func (r *NotificationRepository) Create(n *models.Notification) (*models.Notification, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if r.storage.phoneByModelNumber(n.ModelNumber) == nil {
+		return nil, storage.ErrRecordNotFound
+	}
+	if n.PhoneId != nil {
+		if _, ok := r.storage.phones[*n.PhoneId]; !ok {
+			return nil, storage.ErrRecordNotFound
+		}
+	}
+	if n.SimCardId != nil {
+		if _, ok := r.storage.simCards[*n.SimCardId]; !ok {
+			return nil, storage.ErrRecordNotFound
+		}
+	}
+
+	if n.IdempotencyKey != "" && r.storage.notificationByKey(n.ModelNumber, n.IdempotencyKey) != nil {
+		return nil, storage.ErrRecordExists
+	}
+
+	n.Id = r.storage.nextId("notifications")
+	if n.ReceivedAt.IsZero() {
+		n.ReceivedAt = time.Now()
+	}
+	if n.Links == nil {
+		n.Links = []string{}
+	}
+	stored := *n
+	stored.Links = append([]string{}, n.Links...)
+	stored.PhoneId = copyIntPtr(n.PhoneId)
+	stored.SimCardId = copyIntPtr(n.SimCardId)
+	stored.PhoneNumber = ""
+	r.storage.notifications[n.Id] = &stored
+
+	return n, nil
+}
+
+func (r *NotificationRepository) SelectDuplicate(n *models.Notification, window time.Duration) (*models.Notification, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	if n.IdempotencyKey != "" {
+		if dup := r.storage.notificationByKey(n.ModelNumber, n.IdempotencyKey); dup != nil {
+			return r.storage.withSim(dup), nil
+		}
+		return nil, storage.ErrRecordNotFound
+	}
+
+	if n.Fingerprint == "" {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	for _, id := range sortedKeys(r.storage.notifications) {
+		dup := r.storage.notifications[id]
+		delta := dup.Timestamp - n.Timestamp
+		if delta < 0 {
+			delta = -delta
+		}
+		if dup.Fingerprint == n.Fingerprint && delta <= window.Milliseconds() {
+			return r.storage.withSim(dup), nil
+		}
+	}
+
+	return nil, storage.ErrRecordNotFound
+}
+
+func (r *NotificationRepository) SelectByModelTag(tag string) ([]models.Notification, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var notifications []models.Notification
+
+	for _, id := range sortedKeys(r.storage.notifications) {
+		if n := r.storage.notifications[id]; n.ModelNumber == tag {
+			notifications = append(notifications, *r.storage.withSim(n))
+		}
+	}
+
+	return notifications, nil
+}
+
+func (r *NotificationRepository) Search(filter models.NotificationFilter) ([]models.Notification, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var notifications []models.Notification
+
+	for _, id := range sortedKeys(r.storage.notifications) {
+		if n := r.storage.withSim(r.storage.notifications[id]); matchesFilter(n, &filter) {
+			notifications = append(notifications, *n)
+		}
+	}
+
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return before(&notifications[i], &notifications[j]) != filter.Descending
+	})
+	if filter.Limit > 0 && len(notifications) > filter.Limit {
+		notifications = notifications[:filter.Limit]
+	}
+
+	return notifications, nil
+}
+
+func (r *NotificationRepository) SelectLatestCode(phoneNumber string, since time.Time) (*models.Notification, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var latest *models.Notification
+
+	for _, id := range sortedKeys(r.storage.notifications) {
+		n := r.storage.withSim(r.storage.notifications[id])
+		if n.PhoneNumber != phoneNumber || n.Code == "" || !n.ReceivedAt.After(since) {
+			continue
+		}
+		if latest == nil || !n.ReceivedAt.Before(latest.ReceivedAt) {
+			latest = n
+		}
+	}
+	if latest == nil {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return latest, nil
+}
+
+func before(a, b *models.Notification) bool {
+	if a.Timestamp != b.Timestamp {
+		return a.Timestamp < b.Timestamp
+	}
+
+	return a.Id < b.Id
+}
+
+func matchesFilter(n *models.Notification, f *models.NotificationFilter) bool {
+	switch {
+	case f.ModelNumber != "" && n.ModelNumber != f.ModelNumber,
+		f.PhoneNumber != "" && n.PhoneNumber != f.PhoneNumber,
+		f.Sender != "" && !strings.EqualFold(n.Sender, f.Sender),
+		f.Source != "" && n.Source != f.Source,
+		!f.From.IsZero() && n.Timestamp < f.From.UnixMilli(),
+		!f.To.IsZero() && n.Timestamp >= f.To.UnixMilli(),
+		f.Query != "" && !matchesQuery(n.Body, f.Query):
+		return false
+	}
+	if f.After != nil {
+		after := &models.Notification{Timestamp: f.After.Timestamp, Id: f.After.Id}
+		if f.Descending {
+			return before(n, after)
+		}
+		return before(after, n)
+	}
+
+	return true
+}
+
+// matchesQuery approximates websearch_to_tsquery with the simple
+// configuration: every word must occur in body, words prefixed with "-" must
+// not.
+func matchesQuery(body, query string) bool {
+	words := make(map[string]bool)
+	for _, w := range splitWords(body) {
+		words[w] = true
+	}
+
+	for _, term := range strings.Fields(query) {
+		negated := strings.HasPrefix(term, "-")
+		for _, w := range splitWords(term) {
+			if words[w] == negated {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (r *NotificationRepository) Purge(rule models.RetentionRule, dryRun bool) (int, error) {
+	if rule.ReceivedBefore.IsZero() && rule.KeepPerDevice <= 0 {
+		return 0, nil
+	}
+
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	var expired []int
+	kept := make(map[string]int)
+
+	// Newest first, so the rank of a notification is the count of kept ones.
+	ids := sortedKeys(r.storage.notifications)
+	for i := len(ids) - 1; i >= 0; i-- {
+		n := r.storage.notifications[ids[i]]
+		if len(rule.Sources) > 0 && !contains(rule.Sources, n.Source) ||
+			contains(rule.ExcludeSources, n.Source) {
+			continue
+		}
+
+		kept[n.ModelNumber]++
+		if !rule.ReceivedBefore.IsZero() && n.ReceivedAt.Before(rule.ReceivedBefore) ||
+			rule.KeepPerDevice > 0 && kept[n.ModelNumber] > rule.KeepPerDevice {
+			expired = append(expired, n.Id)
+		}
+	}
+
+	if !dryRun {
+		for _, id := range expired {
+			if rule.Archive {
+				r.storage.archivedNotifications[id] = r.storage.notifications[id]
+			}
+			r.storage.deleteNotification(id)
+		}
+	}
+
+	return len(expired), nil
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,237 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"time"
+)
+
+type PhoneRepository struct {
+	storage *Storage
+}
+
+func (r *PhoneRepository) Create(p *models.Phone) (*models.Phone, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	r.storage.upsertPhone(p)
+
+	return p, nil
+}
+
+func (r *PhoneRepository) SelectById(id int) (*models.Phone, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	p, ok := r.storage.phones[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copyPhone(p), nil
+}
+
+func (r *PhoneRepository) SelectByModelNumber(modelNumber string) (*models.Phone, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	p := r.storage.phoneByModelNumber(modelNumber)
+	if p == nil {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copyPhone(p), nil
+}
+
+func (r *PhoneRepository) SelectAll() ([]models.Phone, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var phones []models.Phone
+
+	for _, id := range sortedKeys(r.storage.phones) {
+		phones = append(phones, *copyPhone(r.storage.phones[id]))
+	}
+
+	return phones, nil
+}
+
+func (r *PhoneRepository) Update(p *models.Phone) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	stored, ok := r.storage.phones[p.Id]
+	if !ok {
+		return storage.ErrRecordNotFound
+	}
+	if p.InventoryTag != "" {
+		for id, other := range r.storage.phones {
+			if id != p.Id && other.InventoryTag == p.InventoryTag {
+				return storage.ErrRecordExists
+			}
+		}
+	}
+
+	stored.InventoryTag = p.InventoryTag
+	stored.DisplayName = p.DisplayName
+	stored.Location = p.Location
+	stored.Notes = p.Notes
+
+	return nil
+}
+
+func (r *PhoneRepository) MarkOffline(seenBefore, at time.Time) ([]models.Phone, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	var phones []models.Phone
+
+	for _, id := range sortedKeys(r.storage.phones) {
+		p := r.storage.phones[id]
+		if p.LastSeenAt == nil || !p.LastSeenAt.Before(seenBefore) || r.storage.offlinePhones[id] {
+			continue
+		}
+		r.storage.offlinePhones[id] = true
+		phones = append(phones, *copyPhone(p))
+	}
+
+	return phones, nil
+}
+
+func (r *PhoneRepository) AssignSlot(phoneId int, slotId *int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	p, ok := r.storage.phones[phoneId]
+	if !ok {
+		return storage.ErrRecordNotFound
+	}
+	if slotId != nil {
+		for id, other := range r.storage.phones {
+			if id != phoneId && other.SlotId != nil && *other.SlotId == *slotId {
+				return storage.ErrRecordExists
+			}
+		}
+	}
+
+	p.SlotId = copyIntPtr(slotId)
+
+	return nil
+}
+
+func (r *PhoneRepository) Delete(id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	p, ok := r.storage.phones[id]
+	if !ok {
+		return nil
+	}
+
+	for _, sim := range r.storage.simCards {
+		if sim.PhoneId != nil && *sim.PhoneId == id {
+			sim.PhoneId = nil
+		}
+	}
+	for _, sd := range r.storage.sdCards {
+		if sd.PhoneId != nil && *sd.PhoneId == id {
+			sd.PhoneId = nil
+		}
+	}
+	for _, scan := range r.storage.auditScans {
+		if scan.PhoneId != nil && *scan.PhoneId == id {
+			scan.PhoneId = nil
+		}
+	}
+	for nId, n := range r.storage.notifications {
+		if n.ModelNumber == p.ModelNumber {
+			r.storage.deleteNotification(nId)
+		}
+	}
+	for ruleId, rule := range r.storage.forwardingRules {
+		if rule.PhoneId != nil && *rule.PhoneId == id {
+			r.storage.deleteRule(ruleId)
+		}
+	}
+	for cId, c := range r.storage.phoneHistory {
+		if c.PhoneId == id {
+			delete(r.storage.phoneHistory, cId)
+		}
+	}
+	for resId, res := range r.storage.reservations {
+		if res.PhoneId == id {
+			delete(r.storage.reservations, resId)
+		}
+	}
+	for _, l := range r.storage.leases {
+		if l.PhoneId != nil && *l.PhoneId == id {
+			l.PhoneId = nil
+		}
+	}
+	for _, c := range r.storage.deviceCredentials {
+		if c.PhoneId != nil && *c.PhoneId == id {
+			c.PhoneId = nil
+		}
+	}
+	for eventId, e := range r.storage.events {
+		if e.PhoneId != nil && *e.PhoneId == id {
+			delete(r.storage.events, eventId)
+		}
+	}
+	delete(r.storage.heartbeats, id)
+	delete(r.storage.lastIps, id)
+	delete(r.storage.batterySamples, id)
+	delete(r.storage.offlinePhones, id)
+	delete(r.storage.userPhones, id)
+	delete(r.storage.phones, id)
+
+	return nil
+}
+
+func (r *PhoneRepository) SelectHistory(phoneId int) ([]models.PhoneChange, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var changes []models.PhoneChange
+
+	for _, id := range sortedKeys(r.storage.phoneHistory) {
+		if c := r.storage.phoneHistory[id]; c.PhoneId == phoneId {
+			changes = append(changes, *c)
+		}
+	}
+
+	return changes, nil
+}
+
+func (r *PhoneRepository) CreateChange(c *models.PhoneChange) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	c.Id = r.storage.nextId("phone_history")
+	c.ChangedAt = time.Now()
+	stored := *c
+	r.storage.phoneHistory[c.Id] = &stored
+
+	return nil
+}
+
+func copyPhone(p *models.Phone) *models.Phone {
+	c := *p
+	if p.SupportedArchs != nil {
+		c.SupportedArchs = append([]string{}, p.SupportedArchs...)
+	}
+	c.SlotId = copyIntPtr(p.SlotId)
+
+	return &c
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,283 @@
+package memstorage_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"server/internal/app/storage/memstorage"
+	"testing"
+	"time"
+)
+
+func testPhone() *models.Phone {
+	return &models.Phone{
+		Manufacturer: "Samsung",
+		ModelTag:     "beyond1",
+		ModelNumber:  "SM-G973F/DS",
+		OsVersion:    "12",
+		ApiVersion:   "31",
+		Cpu:          "exynos9820",
+		Firmware:     "G9773FXXSGHWA1",
+		Bootloader:   "G9773FXXSGHWC3",
+		SupportedArchs: []string{
+			"arm64-v8a",
+			"armeabi-v7a",
+			"armeabi",
+		},
+		SimSlots: 1,
+		SdSlots:  0,
+	}
+}
+
+func TestPhoneRepository_Create(t *testing.T) {
+	s := memstorage.New()
+
+	p, err := s.Phone().Create(testPhone())
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+
+	again := testPhone()
+	again.Manufacturer = "Samsung Electronics"
+	again.Firmware = "G9773FXXSHHWB2"
+	p2, err := s.Phone().Create(again)
+	assert.NoError(t, err)
+	assert.Equal(t, p.Id, p2.Id)
+
+	stored, err := s.Phone().SelectByModelNumber("SM-G973F/DS")
+	assert.NoError(t, err)
+	assert.Equal(t, "Samsung Electronics", stored.Manufacturer)
+	assert.Equal(t, "G9773FXXSHHWB2", stored.Firmware)
+
+	history, err := s.Phone().SelectHistory(p.Id)
+	assert.NoError(t, err)
+
+	var firmware []models.PhoneChange
+	for _, c := range history {
+		if c.Field == "firmware" {
+			firmware = append(firmware, c)
+		}
+	}
+	assert.Len(t, firmware, 2)
+	assert.Equal(t, "", firmware[0].OldValue)
+	assert.Equal(t, "G9773FXXSGHWA1", firmware[0].NewValue)
+	assert.Equal(t, "G9773FXXSGHWA1", firmware[1].OldValue)
+	assert.Equal(t, "G9773FXXSHHWB2", firmware[1].NewValue)
+}
+
+func TestPhoneRepository_SelectByModelNumber(t *testing.T) {
+	s := memstorage.New()
+
+	_, err := s.Phone().SelectByModelNumber("SM-G973F/DS")
+	assert.ErrorIs(t, err, storage.ErrRecordNotFound)
+
+	s.Phone().Create(testPhone())
+
+	p, err := s.Phone().SelectByModelNumber("SM-G973F/DS")
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+}
+
+func TestPhoneRepository_Delete(t *testing.T) {
+	s := memstorage.New()
+
+	p, _ := s.Phone().Create(testPhone())
+	s.Sim().Create(&models.SimInfo{PhoneNumber: "79889484608", Operator: "MTS"}, p)
+	u, _ := s.User().Create(&models.User{Email: "user@example.org"})
+	s.UserPhone().CreateRelation(u.Id, p.Id)
+	s.Notification().Create(&models.Notification{ModelNumber: p.ModelNumber, Body: "hi"})
+
+	assert.NoError(t, s.Phone().Delete(p.Id))
+
+	sims, _ := s.Sim().SelectAll()
+	assert.Len(t, sims, 1)
+	assert.Nil(t, sims[0].PhoneId)
+
+	usersPhones, _ := s.UserPhone().SelectUsersWithPhones()
+	assert.Empty(t, usersPhones)
+
+	notifications, _ := s.Notification().SelectByModelTag(p.ModelNumber)
+	assert.Empty(t, notifications)
+}
+
+func TestSimRepository_Create(t *testing.T) {
+	s := memstorage.New()
+
+	p1, _ := s.Phone().Create(testPhone())
+	other := testPhone()
+	other.ModelNumber = "SM-A525F"
+	p2, _ := s.Phone().Create(other)
+
+	sim, err := s.Sim().Create(&models.SimInfo{}, p1)
+	assert.NoError(t, err)
+	assert.Nil(t, sim)
+
+	sim, err = s.Sim().Create(&models.SimInfo{
+		PhoneNumber: "79889484608",
+		Operator:    "MTS",
+	}, p1)
+	assert.NoError(t, err)
+	assert.NotNil(t, sim)
+
+	moved, err := s.Sim().Create(&models.SimInfo{
+		PhoneNumber: "79889484608",
+		Operator:    "Beeline",
+	}, p2)
+	assert.NoError(t, err)
+	assert.Equal(t, sim.Id, moved.Id)
+
+	sims, _ := s.Sim().SelectAll()
+	assert.Len(t, sims, 1)
+	assert.Equal(t, p2.Id, *sims[0].PhoneId)
+	assert.Equal(t, "MTS", sims[0].Operator)
+
+	s.Sim().RemovePhoneId(p2.Id)
+	sims, _ = s.Sim().SelectAll()
+	assert.Nil(t, sims[0].PhoneId)
+}
+
+func TestSdRepository_Create(t *testing.T) {
+	s := memstorage.New()
+
+	p, _ := s.Phone().Create(testPhone())
+
+	sd, err := s.SdCard().Create(&models.SdInfo{
+		SdManufacturerId: "0x00001b",
+		SerialNo:         "0x1a8ed52f",
+		TotalSpace:       60874,
+		UsedSpace:        16036,
+		FreeSpace:        44848,
+	}, p)
+	assert.NoError(t, err)
+	assert.NotNil(t, sd)
+
+	s.SdCard().RemovePhoneId(p.Id)
+	sdCards, _ := s.SdCard().SelectAll()
+	assert.Len(t, sdCards, 1)
+	assert.Nil(t, sdCards[0].PhoneId)
+}
+
+func TestUserRepository_Create(t *testing.T) {
+	s := memstorage.New()
+
+	u, err := s.User().Create(&models.User{Email: "user@example.org", Password: "hash"})
+	assert.NoError(t, err)
+	assert.NotNil(t, u)
+
+	_, err = s.User().Create(&models.User{Email: "user@example.org"})
+	assert.ErrorIs(t, err, storage.ErrRecordExists)
+
+	users, _ := s.User().SelectAll()
+	assert.Len(t, users, 1)
+	assert.Empty(t, users[0].Password)
+}
+
+func TestUserPhoneRepository_CreateRelation(t *testing.T) {
+	s := memstorage.New()
+
+	p, _ := s.Phone().Create(testPhone())
+	u1, _ := s.User().Create(&models.User{Email: "first@example.org"})
+	u2, _ := s.User().Create(&models.User{Email: "second@example.org"})
+
+	assert.NoError(t, s.UserPhone().CreateRelation(u1.Id, p.Id))
+	assert.NoError(t, s.UserPhone().CreateRelation(u2.Id, p.Id))
+	assert.NoError(t, s.UserPhone().CreateRelation(u2.Id, p.Id+1))
+
+	usersPhones, err := s.UserPhone().SelectUsersWithPhones()
+	assert.NoError(t, err)
+	assert.Len(t, usersPhones, 1)
+	assert.Equal(t, u2.Id, usersPhones[0].User.Id)
+	assert.Equal(t, []int{p.Id}, usersPhones[0].Phones)
+}
+
+func TestNotificationRepository_Create(t *testing.T) {
+	s := memstorage.New()
+
+	_, err := s.Notification().Create(&models.Notification{ModelNumber: "SM-G973F/DS"})
+	assert.Error(t, err)
+
+	s.Phone().Create(testPhone())
+	n, err := s.Notification().Create(&models.Notification{
+		ModelNumber: "SM-G973F/DS",
+		Source:      "com.google.android.apps.messaging",
+		Sender:      "900",
+		Body:        "Code: 1234",
+		Timestamp:   1681665083,
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, n)
+
+	notifications, err := s.Notification().SelectByModelTag("SM-G973F/DS")
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 1)
+}
+
+func TestDeviceReportRepository_Save(t *testing.T) {
+	s := memstorage.New()
+
+	_, err := s.DeviceReport().Save(&models.DeviceReport{
+		Phone:   *testPhone(),
+		SimInfo: []models.SimInfo{{PhoneNumber: "79889484608", Operator: "MTS"}},
+		UserId:  1,
+	})
+	assert.ErrorIs(t, err, storage.ErrRecordNotFound)
+
+	phones, _ := s.Phone().SelectAll()
+	assert.Empty(t, phones)
+	sims, _ := s.Sim().SelectAll()
+	assert.Empty(t, sims)
+
+	u, _ := s.User().Create(&models.User{Email: "user@example.org", Password: "hash"})
+	credential := func(hash string) *models.DeviceCredential {
+		s.Enrollment().CreateToken(&models.EnrollmentToken{UserId: u.Id, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)})
+		c, _ := s.Enrollment().Enroll(hash, &models.DeviceCredential{TokenHash: hash}, time.Now())
+		return c
+	}
+	c := credential("first")
+
+	res, err := s.DeviceReport().Save(&models.DeviceReport{
+		Phone:        *testPhone(),
+		SimInfo:      []models.SimInfo{{PhoneNumber: "79889484608", Operator: "MTS"}, {}},
+		SdInfo:       []models.SdInfo{{SerialNo: "0x1a8ed52f", TotalSpace: 60874}},
+		UserId:       u.Id,
+		CredentialId: c.Id,
+	})
+	assert.NoError(t, err)
+	assert.True(t, res.PhoneCreated)
+	assert.True(t, res.SimsChanged)
+	assert.True(t, res.SdCardsChanged)
+	assert.True(t, res.OwnerChanged)
+	assert.Equal(t, u.Id, res.User.Id)
+	assert.Empty(t, res.User.Password)
+
+	c, _ = s.Enrollment().SelectCredentialById(c.Id)
+	assert.Equal(t, res.Phone.Id, *c.PhoneId)
+
+	res, err = s.DeviceReport().Save(&models.DeviceReport{
+		Phone:        *testPhone(),
+		SimInfo:      []models.SimInfo{{PhoneNumber: "79889484608", Operator: "MTS"}},
+		UserId:       u.Id,
+		CredentialId: c.Id,
+	})
+	assert.NoError(t, err)
+	assert.False(t, res.PhoneCreated)
+	assert.False(t, res.PhoneUpdated)
+	assert.False(t, res.SimsChanged)
+	assert.True(t, res.SdCardsChanged)
+	assert.False(t, res.OwnerChanged)
+
+	sdCards, _ := s.SdCard().SelectAll()
+	assert.Len(t, sdCards, 1)
+	assert.Nil(t, sdCards[0].PhoneId)
+
+	// Another credential can't take over the phone while the first is active.
+	other := credential("second")
+	report := &models.DeviceReport{Phone: *testPhone(), UserId: u.Id, CredentialId: other.Id}
+	_, err = s.DeviceReport().Save(report)
+	assert.ErrorIs(t, err, storage.ErrPhoneBound)
+	sims, _ = s.Sim().SelectAll()
+	assert.NotNil(t, sims[0].PhoneId)
+
+	s.Enrollment().RevokeCredential(c.Id, time.Now())
+	_, err = s.DeviceReport().Save(report)
+	assert.NoError(t, err)
+}
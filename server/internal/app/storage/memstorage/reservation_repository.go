@@ -0,0 +1,104 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"time"
+)
+
+type ReservationRepository struct {
+	storage *Storage
+}
+
+func (r *ReservationRepository) Create(res *models.Reservation) (*models.Reservation, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, ok := r.storage.phones[res.PhoneId]; !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	if _, ok := r.storage.users[res.UserId]; !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	for _, other := range r.storage.reservations {
+		if other.PhoneId == res.PhoneId && other.CheckedInAt == nil &&
+			other.StartsAt.Before(res.EndsAt) && other.EndsAt.After(res.StartsAt) {
+			return nil, storage.ErrReservationConflict
+		}
+	}
+
+	res.Id = r.storage.nextId("reservations")
+	res.CreatedAt = time.Now()
+	stored := *res
+	r.storage.reservations[res.Id] = &stored
+
+	return r.storage.withUser(stored), nil
+}
+
+func (r *ReservationRepository) SelectById(id int) (*models.Reservation, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	res, ok := r.storage.reservations[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return r.storage.withUser(*res), nil
+}
+
+func (r *ReservationRepository) SelectByPhoneId(phoneId int, from, to time.Time) ([]models.Reservation, error) {
+	return r.selectMany(func(res *models.Reservation) bool {
+		return res.PhoneId == phoneId && res.StartsAt.Before(to) && res.EndsAt.After(from)
+	}, func(a, b *models.Reservation) bool {
+		return a.StartsAt.Before(b.StartsAt)
+	})
+}
+
+func (r *ReservationRepository) SelectActive(at time.Time) ([]models.Reservation, error) {
+	return r.selectMany(func(res *models.Reservation) bool {
+		return res.IsActive(at)
+	}, func(a, b *models.Reservation) bool {
+		return a.PhoneId < b.PhoneId
+	})
+}
+
+func (r *ReservationRepository) CheckIn(id int, at time.Time) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if res, ok := r.storage.reservations[id]; ok && res.CheckedInAt == nil {
+		res.CheckedInAt = &at
+	}
+
+	return nil
+}
+
+func (r *ReservationRepository) Delete(id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	delete(r.storage.reservations, id)
+
+	return nil
+}
+
+func (r *ReservationRepository) selectMany(match func(*models.Reservation) bool, less func(a, b *models.Reservation) bool) ([]models.Reservation, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var reservations []models.Reservation
+
+	for _, id := range sortedKeys(r.storage.reservations) {
+		if res := r.storage.reservations[id]; match(res) {
+			reservations = append(reservations, *r.storage.withUser(*res))
+		}
+	}
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return less(&reservations[i], &reservations[j])
+	})
+
+	return reservations, nil
+}
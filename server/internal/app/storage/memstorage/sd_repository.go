@@ -0,0 +1,120 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"time"
+)
+
+type SdRepository struct {
+	storage *Storage
+}
+
+func (r *SdRepository) Create(sd *models.SdInfo, p *models.Phone) (*models.SdInfo, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	return r.storage.createSdCard(sd, p), nil
+}
+
+func (r *SdRepository) RemovePhoneId(phoneId int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	r.storage.removeSdCardPhoneId(phoneId)
+
+	return nil
+}
+
+func (r *SdRepository) SelectAll() ([]models.SdInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var sdCards []models.SdInfo
+
+	for _, id := range sortedKeys(r.storage.sdCards) {
+		sdCards = append(sdCards, *copySd(r.storage.sdCards[id]))
+	}
+
+	return sdCards, nil
+}
+
+func (r *SdRepository) SelectById(id int) (*models.SdInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	sd, ok := r.storage.sdCards[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copySd(sd), nil
+}
+
+func (r *SdRepository) Select(filter models.SdFilter) ([]models.SdInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var sdCards []models.SdInfo
+
+	for _, id := range sortedKeys(r.storage.sdCards) {
+		sd := r.storage.sdCards[id]
+		switch {
+		case filter.PhoneId != nil && (sd.PhoneId == nil || *sd.PhoneId != *filter.PhoneId),
+			filter.SdManufacturerId != "" && sd.SdManufacturerId != filter.SdManufacturerId,
+			filter.Assigned != nil && *filter.Assigned != (sd.PhoneId != nil):
+			continue
+		}
+		sdCards = append(sdCards, *copySd(sd))
+	}
+
+	return sdCards, nil
+}
+
+func (r *SdRepository) SelectUsage(sdCardId int, from, to time.Time) ([]models.SdUsage, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var usage []models.SdUsage
+
+	for _, id := range sortedKeys(r.storage.sdUsage) {
+		u := r.storage.sdUsage[id]
+		if u.SdCardId != sdCardId ||
+			!from.IsZero() && u.ReportedAt.Before(from) ||
+			!to.IsZero() && !u.ReportedAt.Before(to) {
+			continue
+		}
+		usage = append(usage, *u)
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].ReportedAt.Before(usage[j].ReportedAt)
+	})
+
+	return usage, nil
+}
+
+func (r *SdRepository) Delete(id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, ok := r.storage.sdCards[id]; !ok {
+		return storage.ErrRecordNotFound
+	}
+
+	for uId, u := range r.storage.sdUsage {
+		if u.SdCardId == id {
+			delete(r.storage.sdUsage, uId)
+		}
+	}
+	delete(r.storage.sdCards, id)
+
+	return nil
+}
+
+func copySd(sd *models.SdInfo) *models.SdInfo {
+	c := *sd
+	c.PhoneId = copyIntPtr(sd.PhoneId)
+
+	return &c
+}
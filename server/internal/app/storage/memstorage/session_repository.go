@@ -0,0 +1,106 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"time"
+)
+
+type SessionRepository struct {
+	storage *Storage
+}
+
+func (r *SessionRepository) Create(s *models.Session) (*models.Session, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, ok := r.storage.users[s.UserId]; !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	s.Id = r.storage.nextId("sessions")
+	s.CreatedAt = time.Now()
+	s.LastUsedAt = s.CreatedAt
+	r.storage.sessions[s.Id] = copySession(s)
+
+	return s, nil
+}
+
+func (r *SessionRepository) SelectById(id int) (*models.Session, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	s, ok := r.storage.sessions[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copySession(s), nil
+}
+
+func (r *SessionRepository) SelectByTokenHash(hash string) (*models.Session, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	for _, id := range sortedKeys(r.storage.sessions) {
+		if s := r.storage.sessions[id]; s.TokenHash == hash || s.PreviousTokenHash == hash {
+			return copySession(s), nil
+		}
+	}
+
+	return nil, storage.ErrRecordNotFound
+}
+
+func (r *SessionRepository) SelectActiveByUserId(userId int, at time.Time) ([]models.Session, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var sessions []models.Session
+
+	for _, s := range r.storage.sessions {
+		if s.UserId == userId && s.IsActive(at) {
+			sessions = append(sessions, *copySession(s))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Rotate(id int, oldHash, newHash string, expiresAt, at time.Time) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	s, ok := r.storage.sessions[id]
+	if !ok || s.TokenHash != oldHash || s.RevokedAt != nil {
+		return storage.ErrRecordNotFound
+	}
+
+	s.PreviousTokenHash = s.TokenHash
+	s.TokenHash = newHash
+	s.ExpiresAt = expiresAt
+	s.LastUsedAt = at
+
+	return nil
+}
+
+func (r *SessionRepository) Revoke(id int, at time.Time) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if s, ok := r.storage.sessions[id]; ok && s.RevokedAt == nil {
+		s.RevokedAt = &at
+	}
+
+	return nil
+}
+
+func copySession(s *models.Session) *models.Session {
+	c := *s
+	c.RevokedAt = copyTimePtr(s.RevokedAt)
+
+	return &c
+}
@@ -0,0 +1,186 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+)
+
+type SimRepository struct {
+	storage *Storage
+}
+
+func (r *SimRepository) Create(sim *models.SimInfo, p *models.Phone) (*models.SimInfo, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	return r.storage.createSim(sim, p), nil
+}
+
+func (r *SimRepository) RemovePhoneId(phoneId int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	r.storage.removeSimPhoneId(phoneId)
+
+	return nil
+}
+
+func (r *SimRepository) SelectAll() ([]models.SimInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var simCards []models.SimInfo
+
+	for _, id := range sortedKeys(r.storage.simCards) {
+		simCards = append(simCards, *copySim(r.storage.simCards[id]))
+	}
+
+	return simCards, nil
+}
+
+func (r *SimRepository) SelectByPhoneId(phoneId int) ([]models.SimInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var simCards []models.SimInfo
+
+	for _, sim := range r.storage.simCards {
+		if sim.PhoneId != nil && *sim.PhoneId == phoneId {
+			simCards = append(simCards, *copySim(sim))
+		}
+	}
+	sort.Slice(simCards, func(i, j int) bool {
+		a, b := simCards[i].Slot, simCards[j].Slot
+		return b == nil || (a != nil && *a < *b)
+	})
+
+	return simCards, nil
+}
+
+func (r *SimRepository) SelectByPhoneNumber(number string) (*models.SimInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	for _, sim := range r.storage.simCards {
+		if sim.PhoneNumber == number {
+			return copySim(sim), nil
+		}
+	}
+
+	return nil, storage.ErrRecordNotFound
+}
+
+func (r *SimRepository) SelectById(id int) (*models.SimInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	sim, ok := r.storage.simCards[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	return copySim(sim), nil
+}
+
+func (r *SimRepository) Select(filter models.SimFilter) ([]models.SimInfo, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var simCards []models.SimInfo
+
+	for _, id := range sortedKeys(r.storage.simCards) {
+		sim := r.storage.simCards[id]
+		switch {
+		case filter.Operator != "" && sim.Operator != filter.Operator,
+			filter.Assigned != nil && *filter.Assigned != (sim.PhoneId != nil),
+			filter.Tag != "" && !contains(sim.Tags, filter.Tag):
+			continue
+		}
+		simCards = append(simCards, *copySim(sim))
+	}
+
+	return simCards, nil
+}
+
+func (r *SimRepository) Register(sim *models.SimInfo) (*models.SimInfo, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for _, existing := range r.storage.simCards {
+		if existing.PhoneNumber == sim.PhoneNumber {
+			return nil, storage.ErrRecordExists
+		}
+	}
+
+	sim.Id = r.storage.nextId("sim_cards")
+	sim.PhoneId, sim.Slot = nil, nil
+	r.storage.simCards[sim.Id] = copySim(sim)
+	if sim.Tags == nil {
+		sim.Tags = []string{}
+	}
+
+	return sim, nil
+}
+
+func (r *SimRepository) Update(sim *models.SimInfo) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	stored, ok := r.storage.simCards[sim.Id]
+	if !ok {
+		return storage.ErrRecordNotFound
+	}
+
+	updated := copySim(sim)
+	stored.Operator = updated.Operator
+	stored.Notes = updated.Notes
+	stored.Tags = updated.Tags
+	stored.Plan = updated.Plan
+
+	return nil
+}
+
+func (r *SimRepository) Delete(id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	if _, ok := r.storage.simCards[id]; !ok {
+		return storage.ErrRecordNotFound
+	}
+
+	for _, n := range r.storage.notifications {
+		if n.SimCardId != nil && *n.SimCardId == id {
+			n.SimCardId = nil
+		}
+	}
+	for ruleId, rule := range r.storage.forwardingRules {
+		if rule.SimCardId != nil && *rule.SimCardId == id {
+			r.storage.deleteRule(ruleId)
+		}
+	}
+	delete(r.storage.simCards, id)
+
+	return nil
+}
+
+func copySim(sim *models.SimInfo) *models.SimInfo {
+	c := *sim
+	c.PhoneId = copyIntPtr(sim.PhoneId)
+	c.Slot = copyIntPtr(sim.Slot)
+	c.Tags = append([]string{}, sim.Tags...)
+
+	return &c
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func copyIntPtr(v *int) *int {
+	if v == nil {
+		return nil
+	}
+
+	return intPtr(*v)
+}
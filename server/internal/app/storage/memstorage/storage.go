@@ -0,0 +1,296 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sync"
+)
+
+// Storage keeps every table in process memory. It mirrors the constraints
+// and upsert behaviour of the PostgreSQL schema, so handlers can be run
+// against it without a database.
+type Storage struct {
+	mu                     sync.RWMutex
+	phones                 map[int]*models.Phone
+	simCards               map[int]*models.SimInfo
+	sdCards                map[int]*models.SdInfo
+	sdUsage                map[int]*models.SdUsage
+	users                  map[int]*models.User
+	notifications          map[int]*models.Notification
+	archivedNotifications  map[int]*models.Notification
+	phoneHistory           map[int]*models.PhoneChange
+	reservations           map[int]*models.Reservation
+	leases                 map[int]*models.Lease
+	sessions               map[int]*models.Session
+	enrollmentTokens       map[int]*models.EnrollmentToken
+	deviceCredentials      map[int]*models.DeviceCredential
+	forwardingRules        map[int]*models.ForwardingRule
+	deliveries             map[int]*models.Delivery
+	heartbeats             map[int]*models.Heartbeat
+	lastIps                map[int]string
+	events                 map[int]*models.Event
+	offlinePhones          map[int]bool
+	batterySamples         map[int][]models.BatterySample
+	locations              map[int]*models.Location
+	audits                 map[int]*models.Audit
+	auditScans             map[int]*models.AuditScan
+	userPhones             map[int]int
+	lastId                 map[string]int
+	phoneRepository        *PhoneRepository
+	simRepository          *SimRepository
+	sdRepository           *SdRepository
+	userRepository         *UserRepository
+	notificationRepository *NotificationRepository
+	userPhoneRepository    *UserPhoneRepository
+	deviceReportRepository *DeviceReportRepository
+	leaseRepository        *LeaseRepository
+	reservationRepository  *ReservationRepository
+	enrollmentRepository   *EnrollmentRepository
+	sessionRepository      *SessionRepository
+	forwardingRepository   *ForwardingRepository
+	heartbeatRepository    *HeartbeatRepository
+	eventRepository        *EventRepository
+	telemetryRepository    *TelemetryRepository
+	locationRepository     *LocationRepository
+	auditRepository        *AuditRepository
+}
+
+func New() *Storage {
+	return &Storage{
+		phones:                make(map[int]*models.Phone),
+		simCards:              make(map[int]*models.SimInfo),
+		sdCards:               make(map[int]*models.SdInfo),
+		sdUsage:               make(map[int]*models.SdUsage),
+		users:                 make(map[int]*models.User),
+		notifications:         make(map[int]*models.Notification),
+		archivedNotifications: make(map[int]*models.Notification),
+		phoneHistory:          make(map[int]*models.PhoneChange),
+		reservations:          make(map[int]*models.Reservation),
+		leases:                make(map[int]*models.Lease),
+		sessions:              make(map[int]*models.Session),
+		enrollmentTokens:      make(map[int]*models.EnrollmentToken),
+		deviceCredentials:     make(map[int]*models.DeviceCredential),
+		forwardingRules:       make(map[int]*models.ForwardingRule),
+		deliveries:            make(map[int]*models.Delivery),
+		heartbeats:            make(map[int]*models.Heartbeat),
+		lastIps:               make(map[int]string),
+		events:                make(map[int]*models.Event),
+		offlinePhones:         make(map[int]bool),
+		batterySamples:        make(map[int][]models.BatterySample),
+		locations:             make(map[int]*models.Location),
+		audits:                make(map[int]*models.Audit),
+		auditScans:            make(map[int]*models.AuditScan),
+		userPhones:            make(map[int]int),
+		lastId:                make(map[string]int),
+	}
+}
+
+// nextId emulates a SERIAL column. Must be called with mu held.
+func (s *Storage) nextId(table string) int {
+	s.lastId[table]++
+	return s.lastId[table]
+}
+
+func (s *Storage) Phone() storage.PhoneRepository {
+	if s.phoneRepository != nil {
+		return s.phoneRepository
+	}
+
+	s.phoneRepository = &PhoneRepository{
+		storage: s,
+	}
+
+	return s.phoneRepository
+}
+
+func (s *Storage) User() storage.UserRepository {
+	if s.userRepository != nil {
+		return s.userRepository
+	}
+
+	s.userRepository = &UserRepository{
+		storage: s,
+	}
+
+	return s.userRepository
+}
+
+func (s *Storage) Sim() storage.SimRepository {
+	if s.simRepository != nil {
+		return s.simRepository
+	}
+
+	s.simRepository = &SimRepository{
+		storage: s,
+	}
+
+	return s.simRepository
+}
+
+func (s *Storage) SdCard() storage.SdRepository {
+	if s.sdRepository != nil {
+		return s.sdRepository
+	}
+
+	s.sdRepository = &SdRepository{
+		storage: s,
+	}
+
+	return s.sdRepository
+}
+
+func (s *Storage) Notification() storage.NotificationRepository {
+	if s.notificationRepository != nil {
+		return s.notificationRepository
+	}
+
+	s.notificationRepository = &NotificationRepository{
+		storage: s,
+	}
+
+	return s.notificationRepository
+}
+
+func (s *Storage) UserPhone() storage.UserPhoneRepository {
+	if s.userPhoneRepository != nil {
+		return s.userPhoneRepository
+	}
+
+	s.userPhoneRepository = &UserPhoneRepository{
+		storage: s,
+	}
+
+	return s.userPhoneRepository
+}
+
+func (s *Storage) DeviceReport() storage.DeviceReportRepository {
+	if s.deviceReportRepository != nil {
+		return s.deviceReportRepository
+	}
+
+	s.deviceReportRepository = &DeviceReportRepository{
+		storage: s,
+	}
+
+	return s.deviceReportRepository
+}
+
+func (s *Storage) Reservation() storage.ReservationRepository {
+	if s.reservationRepository != nil {
+		return s.reservationRepository
+	}
+
+	s.reservationRepository = &ReservationRepository{
+		storage: s,
+	}
+
+	return s.reservationRepository
+}
+
+func (s *Storage) Lease() storage.LeaseRepository {
+	if s.leaseRepository != nil {
+		return s.leaseRepository
+	}
+
+	s.leaseRepository = &LeaseRepository{
+		storage: s,
+	}
+
+	return s.leaseRepository
+}
+
+func (s *Storage) Session() storage.SessionRepository {
+	if s.sessionRepository != nil {
+		return s.sessionRepository
+	}
+
+	s.sessionRepository = &SessionRepository{
+		storage: s,
+	}
+
+	return s.sessionRepository
+}
+
+func (s *Storage) Enrollment() storage.EnrollmentRepository {
+	if s.enrollmentRepository != nil {
+		return s.enrollmentRepository
+	}
+
+	s.enrollmentRepository = &EnrollmentRepository{
+		storage: s,
+	}
+
+	return s.enrollmentRepository
+}
+
+func (s *Storage) Forwarding() storage.ForwardingRepository {
+	if s.forwardingRepository != nil {
+		return s.forwardingRepository
+	}
+
+	s.forwardingRepository = &ForwardingRepository{
+		storage: s,
+	}
+
+	return s.forwardingRepository
+}
+
+func (s *Storage) Heartbeat() storage.HeartbeatRepository {
+	if s.heartbeatRepository != nil {
+		return s.heartbeatRepository
+	}
+
+	s.heartbeatRepository = &HeartbeatRepository{
+		storage: s,
+	}
+
+	return s.heartbeatRepository
+}
+
+func (s *Storage) Event() storage.EventRepository {
+	if s.eventRepository != nil {
+		return s.eventRepository
+	}
+
+	s.eventRepository = &EventRepository{
+		storage: s,
+	}
+
+	return s.eventRepository
+}
+
+func (s *Storage) Telemetry() storage.TelemetryRepository {
+	if s.telemetryRepository != nil {
+		return s.telemetryRepository
+	}
+
+	s.telemetryRepository = &TelemetryRepository{
+		storage: s,
+	}
+
+	return s.telemetryRepository
+}
+
+func (s *Storage) Location() storage.LocationRepository {
+	if s.locationRepository != nil {
+		return s.locationRepository
+	}
+
+	s.locationRepository = &LocationRepository{
+		storage: s,
+	}
+
+	return s.locationRepository
+}
+
+func (s *Storage) Audit() storage.AuditRepository {
+	if s.auditRepository != nil {
+		return s.auditRepository
+	}
+
+	s.auditRepository = &AuditRepository{
+		storage: s,
+	}
+
+	return s.auditRepository
+}
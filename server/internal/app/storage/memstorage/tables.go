@@ -0,0 +1,246 @@
+package memstorage
+
+import (
+	"server/internal/app/helper"
+	"server/internal/app/models"
+	"time"
+)
+
+// The helpers below operate on the raw tables and must be called with mu
+// held. They are shared by the repositories and by multi-table operations
+// such as device reports.
+
+func (s *Storage) phoneByModelNumber(modelNumber string) *models.Phone {
+	for _, p := range s.phones {
+		if p.ModelNumber == modelNumber {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// phoneBound reports whether an active credential other than except is bound
+// to the phone.
+func (s *Storage) phoneBound(phoneId, except int) bool {
+	for id, c := range s.deviceCredentials {
+		if id != except && c.IsActive() && c.PhoneId != nil && *c.PhoneId == phoneId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Storage) upsertPhone(p *models.Phone) (bool, []models.PhoneChange) {
+	var old models.Phone
+
+	existing := s.phoneByModelNumber(p.ModelNumber)
+	if existing != nil {
+		old = *existing
+		p.Id = existing.Id
+	} else {
+		p.Id = s.nextId("phones")
+	}
+	p.KeepDetails(old)
+	s.phones[p.Id] = copyPhone(p)
+
+	changes := helper.DiffPhones(old, *p)
+	now := time.Now()
+	for i := range changes {
+		c := &changes[i]
+		c.Id = s.nextId("phone_history")
+		c.PhoneId = p.Id
+		c.ChangedAt = now
+		stored := *c
+		s.phoneHistory[c.Id] = &stored
+	}
+
+	return existing == nil, changes
+}
+
+// markSeen records a contact with the phone and clears its offline flag. It
+// reports whether the phone exists.
+func (s *Storage) markSeen(phoneId int, at time.Time) bool {
+	p, ok := s.phones[phoneId]
+	if !ok {
+		return false
+	}
+
+	seen := at
+	p.LastSeenAt = &seen
+	delete(s.offlinePhones, phoneId)
+
+	return true
+}
+
+// swapIp records the address a phone was seen from and returns the previous
+// one. An empty ip is not recorded.
+func (s *Storage) swapIp(phoneId int, ip string) string {
+	if ip == "" {
+		return ""
+	}
+
+	previous := s.lastIps[phoneId]
+	s.lastIps[phoneId] = ip
+
+	return previous
+}
+
+func (s *Storage) createSim(sim *models.SimInfo, p *models.Phone) *models.SimInfo {
+	if helper.IsEmptySimSlot(*sim) {
+		return nil
+	}
+
+	for _, existing := range s.simCards {
+		if existing.PhoneNumber == sim.PhoneNumber {
+			existing.PhoneId = intPtr(p.Id)
+			existing.Slot = copyIntPtr(sim.Slot)
+			sim.Id = existing.Id
+			return sim
+		}
+	}
+
+	sim.Id = s.nextId("sim_cards")
+	// Reports only carry the number, operator and slot.
+	s.simCards[sim.Id] = &models.SimInfo{
+		Id:          sim.Id,
+		PhoneId:     intPtr(p.Id),
+		PhoneNumber: sim.PhoneNumber,
+		Operator:    sim.Operator,
+		Slot:        copyIntPtr(sim.Slot),
+		Tags:        []string{},
+	}
+
+	return sim
+}
+
+func (s *Storage) removeSimPhoneId(phoneId int) {
+	for _, sim := range s.simCards {
+		if sim.PhoneId != nil && *sim.PhoneId == phoneId {
+			sim.PhoneId = nil
+			sim.Slot = nil
+		}
+	}
+}
+
+func (s *Storage) createSdCard(sd *models.SdInfo, p *models.Phone) *models.SdInfo {
+	if helper.IsEmptySdSlot(*sd) {
+		return nil
+	}
+
+	var stored *models.SdInfo
+	for _, existing := range s.sdCards {
+		if existing.SerialNo == sd.SerialNo {
+			stored = existing
+			break
+		}
+	}
+	if stored == nil {
+		sd.Id = s.nextId("sd_cards")
+		stored = &models.SdInfo{Id: sd.Id, SdManufacturerId: sd.SdManufacturerId, SerialNo: sd.SerialNo}
+		s.sdCards[sd.Id] = stored
+	}
+	sd.Id = stored.Id
+	stored.PhoneId = intPtr(p.Id)
+	stored.TotalSpace, stored.UsedSpace, stored.FreeSpace = sd.TotalSpace, sd.UsedSpace, sd.FreeSpace
+
+	s.sdUsage[s.nextId("sd_card_usage")] = &models.SdUsage{
+		SdCardId:   sd.Id,
+		TotalSpace: sd.TotalSpace,
+		UsedSpace:  sd.UsedSpace,
+		FreeSpace:  sd.FreeSpace,
+		ReportedAt: time.Now(),
+	}
+
+	return sd
+}
+
+func (s *Storage) removeSdCardPhoneId(phoneId int) {
+	for _, sd := range s.sdCards {
+		if sd.PhoneId != nil && *sd.PhoneId == phoneId {
+			sd.PhoneId = nil
+		}
+	}
+}
+
+func (s *Storage) createUserPhoneRelation(userId int, phoneId int) {
+	_, userOk := s.users[userId]
+	_, phoneOk := s.phones[phoneId]
+	if !userOk || !phoneOk {
+		return
+	}
+
+	s.userPhones[phoneId] = userId
+}
+
+// withUser fills in the joined user columns of a reservation.
+func (s *Storage) withUser(res models.Reservation) *models.Reservation {
+	if u, ok := s.users[res.UserId]; ok {
+		res.UserName = u.Name
+		res.UserEmail = u.Email
+	}
+	if res.CheckedInAt != nil {
+		at := *res.CheckedInAt
+		res.CheckedInAt = &at
+	}
+
+	return &res
+}
+
+// withSim fills in the joined SIM columns of a notification.
+func (s *Storage) withSim(n *models.Notification) *models.Notification {
+	c := *n
+	c.PhoneId = copyIntPtr(n.PhoneId)
+	c.SimCardId = copyIntPtr(n.SimCardId)
+	c.Links = append([]string{}, n.Links...)
+	if n.SimCardId != nil {
+		if sim, ok := s.simCards[*n.SimCardId]; ok {
+			c.PhoneNumber = sim.PhoneNumber
+		}
+	}
+
+	return &c
+}
+
+// deleteRule removes a forwarding rule together with its deliveries.
+func (s *Storage) deleteRule(id int) {
+	for dId, d := range s.deliveries {
+		if d.RuleId == id {
+			delete(s.deliveries, dId)
+		}
+	}
+	delete(s.forwardingRules, id)
+}
+
+// deleteNotification removes a notification together with its deliveries.
+func (s *Storage) deleteNotification(id int) {
+	for dId, d := range s.deliveries {
+		if d.NotificationId == id {
+			delete(s.deliveries, dId)
+		}
+	}
+	delete(s.notifications, id)
+}
+
+func (s *Storage) notificationByKey(modelNumber, key string) *models.Notification {
+	for _, n := range s.notifications {
+		if n.ModelNumber == modelNumber && n.IdempotencyKey == key {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// locationNameTaken tells whether the parent of l has another location
+// of the same name.
+func (s *Storage) locationNameTaken(l *models.Location) bool {
+	for id, other := range s.locations {
+		if id != l.Id && other.Name == l.Name && sameIntPtr(other.ParentId, l.ParentId) {
+			return true
+		}
+	}
+
+	return false
+}
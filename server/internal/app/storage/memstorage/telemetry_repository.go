@@ -0,0 +1,83 @@
+package memstorage
+
+import (
+	"fmt"
+	"math"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"sort"
+	"time"
+)
+
+type TelemetryRepository struct {
+	storage *Storage
+}
+
+func (r *TelemetryRepository) CreateBatterySample(sample *models.BatterySample) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	samples := r.storage.batterySamples[sample.PhoneId]
+	i := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].ReportedAt.Before(sample.ReportedAt)
+	})
+	if i < len(samples) && samples[i].ReportedAt.Equal(sample.ReportedAt) {
+		samples[i] = *sample
+		return nil
+	}
+
+	samples = append(samples, models.BatterySample{})
+	copy(samples[i+1:], samples[i:])
+	samples[i] = *sample
+	r.storage.batterySamples[sample.PhoneId] = samples
+
+	return nil
+}
+
+func (r *TelemetryRepository) SelectLastBatterySample(phoneId int) (*models.BatterySample, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	samples := r.storage.batterySamples[phoneId]
+	if len(samples) == 0 {
+		return nil, storage.ErrRecordNotFound
+	}
+	last := samples[len(samples)-1]
+
+	return &last, nil
+}
+
+func (r *TelemetryRepository) SelectSeries(phoneId int, metric string, from, to time.Time, step time.Duration) ([]models.TelemetryPoint, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	if _, ok := (&models.Battery{}).Metric(metric); !ok {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+	seconds := int64(step / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	var points []models.TelemetryPoint
+
+	for _, sample := range r.storage.batterySamples[phoneId] {
+		if sample.ReportedAt.Before(from) || !sample.ReportedAt.Before(to) {
+			continue
+		}
+		v, _ := sample.Metric(metric)
+		unix := sample.ReportedAt.Unix()
+		bucket := time.Unix(unix-unix%seconds, 0).UTC()
+
+		if n := len(points); n == 0 || !points[n-1].At.Equal(bucket) {
+			points = append(points, models.TelemetryPoint{At: bucket, Min: math.Inf(1), Max: math.Inf(-1)})
+		}
+		p := &points[len(points)-1]
+		p.Min = math.Min(p.Min, v)
+		p.Max = math.Max(p.Max, v)
+		p.Avg += (v - p.Avg) / float64(p.Samples+1)
+		p.Samples++
+	}
+
+	return points, nil
+}
@@ -0,0 +1,54 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+)
+
+type UserPhoneRepository struct {
+	storage *Storage
+}
+
+func (r *UserPhoneRepository) CreateRelation(userId int, phoneId int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	r.storage.createUserPhoneRelation(userId, phoneId)
+
+	return nil
+}
+
+func (r *UserPhoneRepository) SelectUsersWithPhones() ([]models.UserPhone, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var usersPhones []models.UserPhone
+
+	for _, phoneId := range sortedKeys(r.storage.userPhones) {
+		u := *r.storage.users[r.storage.userPhones[phoneId]]
+
+		usersPhones = append(usersPhones, models.UserPhone{
+			User: models.User{
+				Id:    u.Id,
+				Name:  u.Name,
+				Email: u.Email,
+			},
+			Phones: []int{phoneId},
+		})
+	}
+
+	return usersPhones, nil
+}
+
+func (r *UserPhoneRepository) SelectOwner(phoneId int) (*models.User, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	userId, ok := r.storage.userPhones[phoneId]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+	u := r.storage.users[userId]
+
+	return &models.User{Id: u.Id, Name: u.Name, Email: u.Email}, nil
+}
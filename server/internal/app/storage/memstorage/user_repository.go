@@ -0,0 +1,121 @@
+package memstorage
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage"
+)
+
+type UserRepository struct {
+	storage *Storage
+}
+
+func (r *UserRepository) Create(u *models.User) (*models.User, error) {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for _, existing := range r.storage.users {
+		if existing.Email == u.Email {
+			return nil, storage.ErrRecordExists
+		}
+	}
+
+	u.Id = r.storage.nextId("users")
+	stored := *u
+	r.storage.users[u.Id] = &stored
+
+	return u, nil
+}
+
+func (r *UserRepository) SelectById(id int) (*models.User, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	u, ok := r.storage.users[id]
+	if !ok {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	c := *u
+	return &c, nil
+}
+
+func (r *UserRepository) SelectByEmail(email string) (*models.User, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	for _, id := range sortedKeys(r.storage.users) {
+		if u := r.storage.users[id]; u.Email == email {
+			c := *u
+			return &c, nil
+		}
+	}
+
+	return nil, storage.ErrRecordNotFound
+}
+
+func (r *UserRepository) SelectAll() ([]models.User, error) {
+	r.storage.mu.RLock()
+	defer r.storage.mu.RUnlock()
+
+	var users []models.User
+
+	for _, id := range sortedKeys(r.storage.users) {
+		u := *r.storage.users[id]
+		u.Password = ""
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) UpdateRole(id int, role string) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	u, ok := r.storage.users[id]
+	if !ok {
+		return storage.ErrRecordNotFound
+	}
+	u.Role = role
+
+	return nil
+}
+
+func (r *UserRepository) Delete(id int) error {
+	r.storage.mu.Lock()
+	defer r.storage.mu.Unlock()
+
+	for phoneId, userId := range r.storage.userPhones {
+		if userId == id {
+			delete(r.storage.userPhones, phoneId)
+		}
+	}
+	for resId, res := range r.storage.reservations {
+		if res.UserId == id {
+			delete(r.storage.reservations, resId)
+		}
+	}
+	for lId, l := range r.storage.leases {
+		if l.UserId == id {
+			delete(r.storage.leases, lId)
+		}
+	}
+	for sId, s := range r.storage.sessions {
+		if s.UserId == id {
+			delete(r.storage.sessions, sId)
+		}
+	}
+	for tId, t := range r.storage.enrollmentTokens {
+		if t.UserId == id {
+			delete(r.storage.enrollmentTokens, tId)
+		}
+	}
+	for cId, c := range r.storage.deviceCredentials {
+		if c.UserId == id {
+			delete(r.storage.deviceCredentials, cId)
+		}
+	}
+	delete(r.storage.users, id)
+
+	return nil
+}
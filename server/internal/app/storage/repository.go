@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"server/internal/app/models"
+	"time"
+)
+
+type PhoneRepository interface {
+	Create(p *models.Phone) (*models.Phone, error)
+	SelectById(id int) (*models.Phone, error)
+	SelectByModelNumber(modelNumber string) (*models.Phone, error)
+	SelectAll() ([]models.Phone, error)
+	// Update writes the admin-editable fields only. It returns
+	// ErrRecordExists when another phone has the inventory tag.
+	Update(p *models.Phone) error
+	// MarkOffline flags the phones last seen before seenBefore that aren't
+	// flagged yet and returns them. The next contact clears the flag, so
+	// each silence is returned once.
+	MarkOffline(seenBefore, at time.Time) ([]models.Phone, error)
+	// AssignSlot puts the phone into the slot, or takes it out of its slot
+	// if slotId is nil. It returns ErrRecordExists if another phone is in
+	// the slot.
+	AssignSlot(phoneId int, slotId *int) error
+	Delete(id int) error
+	SelectHistory(phoneId int) ([]models.PhoneChange, error)
+	// CreateChange adds a history entry that doesn't come from a report.
+	CreateChange(c *models.PhoneChange) error
+}
+
+type UserRepository interface {
+	Create(u *models.User) (*models.User, error)
+	SelectById(id int) (*models.User, error)
+	SelectByEmail(email string) (*models.User, error)
+	SelectAll() ([]models.User, error)
+	UpdateRole(id int, role string) error
+	Delete(id int) error
+}
+
+type SimRepository interface {
+	Create(sim *models.SimInfo, p *models.Phone) (*models.SimInfo, error)
+	RemovePhoneId(phoneId int) error
+	SelectAll() ([]models.SimInfo, error)
+	SelectByPhoneId(phoneId int) ([]models.SimInfo, error)
+	SelectByPhoneNumber(number string) (*models.SimInfo, error)
+	SelectById(id int) (*models.SimInfo, error)
+	Select(filter models.SimFilter) ([]models.SimInfo, error)
+	// Register adds a SIM that is not in any phone. It returns
+	// ErrRecordExists if the phone number is taken.
+	Register(sim *models.SimInfo) (*models.SimInfo, error)
+	// Update saves the operator, notes, tags and plan of a SIM.
+	Update(sim *models.SimInfo) error
+	Delete(id int) error
+}
+
+type SdRepository interface {
+	// Create upserts a card by serial number and records its usage.
+	Create(sd *models.SdInfo, p *models.Phone) (*models.SdInfo, error)
+	RemovePhoneId(phoneId int) error
+	SelectAll() ([]models.SdInfo, error)
+	SelectById(id int) (*models.SdInfo, error)
+	Select(filter models.SdFilter) ([]models.SdInfo, error)
+	// SelectUsage returns the usage of a card reported in [from, to), oldest
+	// first. Zero times don't limit the range.
+	SelectUsage(sdCardId int, from, to time.Time) ([]models.SdUsage, error)
+	Delete(id int) error
+}
+
+type NotificationRepository interface {
+	// Create returns storage.ErrRecordExists if the device already sent a
+	// notification with n.IdempotencyKey.
+	Create(n *models.Notification) (*models.Notification, error)
+	// SelectDuplicate returns an earlier notification of the same device with
+	// n.IdempotencyKey or, if n has no key, with n.Fingerprint and a
+	// Timestamp at most window away.
+	SelectDuplicate(n *models.Notification, window time.Duration) (*models.Notification, error)
+	SelectByModelTag(tag string) ([]models.Notification, error)
+	// Search returns notifications matching filter ordered by Timestamp, then
+	// Id, starting after filter.After.
+	Search(filter models.NotificationFilter) ([]models.Notification, error)
+	SelectLatestCode(phoneNumber string, since time.Time) (*models.Notification, error)
+	// Purge deletes the notifications selected by rule and returns their
+	// number. With dryRun nothing is deleted.
+	Purge(rule models.RetentionRule, dryRun bool) (int, error)
+}
+
+type UserPhoneRepository interface {
+	CreateRelation(userId int, phoneId int) error
+	SelectUsersWithPhones() ([]models.UserPhone, error)
+	SelectOwner(phoneId int) (*models.User, error)
+}
+
+// DeviceReportRepository applies a whole phone report as one unit of work:
+// either the phone, its SIM and SD cards, the owner relation and the binding
+// of the credential are all updated, or nothing is written. A credential that
+// isn't bound yet can't take over a phone another active credential is bound
+// to; Save returns ErrPhoneBound then.
+type DeviceReportRepository interface {
+	Save(r *models.DeviceReport) (*models.DeviceReportResult, error)
+}
+
+type ReservationRepository interface {
+	// Create stores the reservation unless it overlaps another reservation
+	// of the same phone that has not been checked in, in which case
+	// ErrReservationConflict is returned.
+	Create(res *models.Reservation) (*models.Reservation, error)
+	SelectById(id int) (*models.Reservation, error)
+	SelectByPhoneId(phoneId int, from, to time.Time) ([]models.Reservation, error)
+	SelectActive(at time.Time) ([]models.Reservation, error)
+	CheckIn(id int, at time.Time) error
+	Delete(id int) error
+}
+
+type LeaseRepository interface {
+	Create(l *models.Lease) (*models.Lease, error)
+	SelectByToken(token string) (*models.Lease, error)
+	// SelectOpen returns queued and active leases in the order they were
+	// requested.
+	SelectOpen() ([]models.Lease, error)
+	Update(l *models.Lease) error
+}
+
+type SessionRepository interface {
+	Create(s *models.Session) (*models.Session, error)
+	SelectById(id int) (*models.Session, error)
+	// SelectByTokenHash finds the session whose current or previous refresh
+	// token has the given hash.
+	SelectByTokenHash(hash string) (*models.Session, error)
+	SelectActiveByUserId(userId int, at time.Time) ([]models.Session, error)
+	// Rotate replaces the refresh token of a session, provided its current
+	// hash is still oldHash. Otherwise ErrRecordNotFound is returned.
+	Rotate(id int, oldHash, newHash string, expiresAt, at time.Time) error
+	Revoke(id int, at time.Time) error
+}
+
+type EnrollmentRepository interface {
+	CreateToken(t *models.EnrollmentToken) (*models.EnrollmentToken, error)
+	Enroll(tokenHash string, c *models.DeviceCredential, at time.Time) (*models.DeviceCredential, error)
+	SelectCredentialById(id int) (*models.DeviceCredential, error)
+	SelectCredentialByTokenHash(hash string) (*models.DeviceCredential, error)
+	SelectCredentialsByUserId(userId int) ([]models.DeviceCredential, error)
+	BindPhone(id, phoneId int) error
+	RevokeCredential(id int, at time.Time) error
+}
+
+type ForwardingRepository interface {
+	CreateRule(rule *models.ForwardingRule) (*models.ForwardingRule, error)
+	SelectRuleById(id int) (*models.ForwardingRule, error)
+	SelectRules() ([]models.ForwardingRule, error)
+	UpdateRule(rule *models.ForwardingRule) error
+	DeleteRule(id int) error
+	CreateDelivery(d *models.Delivery) (*models.Delivery, error)
+	SelectDueDeliveries(at time.Time, limit int) ([]models.Delivery, error)
+	SelectDeliveries(filter models.DeliveryFilter) ([]models.Delivery, error)
+	UpdateDelivery(d *models.Delivery) error
+}
+
+// HeartbeatRepository keeps the latest heartbeat of every phone.
+type HeartbeatRepository interface {
+	// Save replaces the heartbeat of the phone and marks the phone seen at
+	// ReceivedAt from Ip. It returns the address the phone was seen from
+	// before, by a heartbeat or a report, and ErrRecordNotFound for an
+	// unknown phone.
+	Save(hb *models.Heartbeat) (string, error)
+	SelectByPhoneId(phoneId int) (*models.Heartbeat, error)
+	SelectAll() ([]models.Heartbeat, error)
+}
+
+type EventRepository interface {
+	Create(e *models.Event) (*models.Event, error)
+	Select(filter models.EventFilter) ([]models.Event, error)
+}
+
+type TelemetryRepository interface {
+	CreateBatterySample(sample *models.BatterySample) error
+	// SelectLastBatterySample returns ErrRecordNotFound if the phone has no
+	// samples.
+	SelectLastBatterySample(phoneId int) (*models.BatterySample, error)
+	// SelectSeries aggregates a metric over buckets of step in [from, to).
+	// Buckets start at multiples of step since the Unix epoch.
+	SelectSeries(phoneId int, metric string, from, to time.Time, step time.Duration) ([]models.TelemetryPoint, error)
+}
+
+type LocationRepository interface {
+	// Create returns ErrRecordExists if the parent already has a location
+	// of that name.
+	Create(l *models.Location) (*models.Location, error)
+	SelectById(id int) (*models.Location, error)
+	SelectAll() ([]models.Location, error)
+	// Update renames the location; see Create.
+	Update(l *models.Location) error
+	Delete(id int) error
+}
+
+type AuditRepository interface {
+	Create(a *models.Audit) (*models.Audit, error)
+	SelectById(id int) (*models.Audit, error)
+	// SelectAll returns the audits, the latest first.
+	SelectAll() ([]models.Audit, error)
+	// Finish returns ErrRecordNotFound if there is no unfinished audit with
+	// the id.
+	Finish(id int, at time.Time) error
+	// CreateScan returns ErrRecordNotFound for an unknown audit.
+	CreateScan(scan *models.AuditScan) (*models.AuditScan, error)
+	// SelectScans returns the scans of an audit, oldest first.
+	SelectScans(auditId int) ([]models.AuditScan, error)
+}
@@ -0,0 +1,143 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const auditColumns = `audit_id, name, started_by, started_at, finished_at`
+
+const scanColumns = `scan_id, audit_id, code, kind, item_id, slot_id, phone_id, scanned_by, scanned_at`
+
+type AuditRepository struct {
+	storage *Storage
+}
+
+func scanAudit(row scanner, a *models.Audit) error {
+	return row.Scan(
+		&a.Id,
+		&a.Name,
+		&a.StartedBy,
+		&a.StartedAt,
+		&a.FinishedAt,
+	)
+}
+
+func scanAuditScan(row scanner, scan *models.AuditScan) error {
+	return row.Scan(
+		&scan.Id,
+		&scan.AuditId,
+		&scan.Code,
+		&scan.Kind,
+		&scan.ItemId,
+		&scan.SlotId,
+		&scan.PhoneId,
+		&scan.ScannedBy,
+		&scan.ScannedAt,
+	)
+}
+
+func (r *AuditRepository) Create(a *models.Audit) (*models.Audit, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO audits (name, started_by)
+										VALUES ($1, $2) RETURNING audit_id, started_at`,
+		a.Name, a.StartedBy).Scan(&a.Id, &a.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	a.FinishedAt = nil
+
+	return a, nil
+}
+
+func (r *AuditRepository) SelectById(id int) (*models.Audit, error) {
+	a := &models.Audit{}
+
+	err := scanAudit(r.storage.db.QueryRow(`SELECT `+auditColumns+` FROM audits WHERE audit_id = $1`, id), a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (r *AuditRepository) SelectAll() ([]models.Audit, error) {
+	rows, err := r.storage.db.Query(`SELECT ` + auditColumns + ` FROM audits ORDER BY audit_id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var audits []models.Audit
+
+	for rows.Next() {
+		var a models.Audit
+
+		if err := scanAudit(rows, &a); err != nil {
+			return nil, err
+		}
+
+		audits = append(audits, a)
+	}
+
+	return audits, rows.Err()
+}
+
+func (r *AuditRepository) Finish(id int, at time.Time) error {
+	res, err := r.storage.db.Exec(`UPDATE audits SET finished_at = $2 WHERE audit_id = $1 AND finished_at IS NULL`, id, at)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *AuditRepository) CreateScan(scan *models.AuditScan) (*models.AuditScan, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO audit_scans (audit_id, code, kind, item_id, slot_id, phone_id, scanned_by)
+										VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING scan_id, scanned_at`,
+		scan.AuditId, scan.Code, scan.Kind, scan.ItemId, scan.SlotId, scan.PhoneId, scan.ScannedBy).Scan(&scan.Id, &scan.ScannedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+func (r *AuditRepository) SelectScans(auditId int) ([]models.AuditScan, error) {
+	rows, err := r.storage.db.Query(`SELECT `+scanColumns+` FROM audit_scans WHERE audit_id = $1 ORDER BY scan_id`, auditId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []models.AuditScan
+
+	for rows.Next() {
+		var scan models.AuditScan
+
+		if err := scanAuditScan(rows, &scan); err != nil {
+			return nil, err
+		}
+
+		scans = append(scans, scan)
+	}
+
+	return scans, rows.Err()
+}
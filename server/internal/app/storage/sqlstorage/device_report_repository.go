@@ -0,0 +1,159 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"sort"
+	"time"
+)
+
+type DeviceReportRepository struct {
+	storage *Storage
+}
+
+func (r *DeviceReportRepository) Save(report *models.DeviceReport) (*models.DeviceReportResult, error) {
+	tx, err := r.storage.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user, err := selectUserById(tx, report.UserId)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+
+	if err := checkPhoneClaim(tx, report.CredentialId, report.Phone.ModelNumber); err != nil {
+		return nil, err
+	}
+
+	result := &models.DeviceReportResult{
+		User: user,
+	}
+
+	created, changes, err := upsertPhone(tx, &report.Phone)
+	if err != nil {
+		return nil, err
+	}
+	phone := &report.Phone
+	now := time.Now()
+	if _, err := markSeen(tx, phone.Id, now); err != nil {
+		return nil, err
+	}
+	if result.PreviousIp, err = swapIp(tx, phone.Id, report.Ip); err != nil {
+		return nil, err
+	}
+	phone.LastSeenAt = &now
+	result.Phone = phone
+	result.PhoneCreated = created
+	result.PhoneUpdated = !created && len(changes) > 0
+	result.PhoneChanges = changes
+
+	oldSims, err := selectIds(tx, `SELECT sim_card_id FROM sim_cards WHERE phone_id = $1`, phone.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeSimPhoneId(tx, phone.Id); err != nil {
+		return nil, err
+	}
+	var newSims []int
+	for i := range report.SimInfo {
+		sim, err := createSim(tx, &report.SimInfo[i], phone)
+		if err != nil {
+			return nil, err
+		}
+		if sim != nil {
+			newSims = append(newSims, sim.Id)
+		}
+	}
+	result.SimsChanged = !sameIds(oldSims, newSims)
+
+	oldSdCards, err := selectIds(tx, `SELECT sd_card_id FROM sd_cards WHERE phone_id = $1`, phone.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeSdCardPhoneId(tx, phone.Id); err != nil {
+		return nil, err
+	}
+	var newSdCards []int
+	for i := range report.SdInfo {
+		sd, err := createSdCard(tx, &report.SdInfo[i], phone)
+		if err != nil {
+			return nil, err
+		}
+		if sd != nil {
+			newSdCards = append(newSdCards, sd.Id)
+		}
+	}
+	result.SdCardsChanged = !sameIds(oldSdCards, newSdCards)
+
+	var ownerId int
+	err = tx.QueryRow(`SELECT user_id FROM user_phone WHERE phone_id = $1`, phone.Id).Scan(&ownerId)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	result.OwnerChanged = err == sql.ErrNoRows || ownerId != user.Id
+
+	if err := createUserPhoneRelation(tx, user.Id, phone.Id); err != nil {
+		return nil, err
+	}
+	if err := bindCredential(tx, report.CredentialId, phone.Id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func selectIds(q querier, query string, args ...any) ([]int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func sameIds(a, b []int) bool {
+	a = uniqueSorted(a)
+	b = uniqueSorted(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func uniqueSorted(ids []int) []int {
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+
+	var res []int
+	for i, id := range sorted {
+		if i == 0 || sorted[i-1] != id {
+			res = append(res, id)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,172 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+const credentialColumns = `device_credential_id, user_id, phone_id, token_hash, created_at, revoked_at`
+
+type EnrollmentRepository struct {
+	storage *Storage
+}
+
+func scanCredential(row scanner, c *models.DeviceCredential) error {
+	return row.Scan(
+		&c.Id,
+		&c.UserId,
+		&c.PhoneId,
+		&c.TokenHash,
+		&c.CreatedAt,
+		&c.RevokedAt,
+	)
+}
+
+func (r *EnrollmentRepository) CreateToken(t *models.EnrollmentToken) (*models.EnrollmentToken, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO enrollment_tokens (user_id, token_hash, expires_at)
+										VALUES ($1, $2, $3) RETURNING enrollment_token_id, created_at`,
+		t.UserId, t.TokenHash, t.ExpiresAt).Scan(&t.Id, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Enroll uses up the enrollment token and creates a credential for its
+// owner in one transaction, so a token can't be redeemed twice.
+func (r *EnrollmentRepository) Enroll(tokenHash string, c *models.DeviceCredential, at time.Time) (*models.DeviceCredential, error) {
+	tx, err := r.storage.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`UPDATE enrollment_tokens SET used_at = $2
+							 WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+							 RETURNING user_id`, tokenHash, at).Scan(&c.UserId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	err = tx.QueryRow(`INSERT INTO device_credentials (user_id, token_hash, created_at)
+							 VALUES ($1, $2, $3) RETURNING device_credential_id`,
+		c.UserId, c.TokenHash, at).Scan(&c.Id)
+	if err != nil {
+		return nil, err
+	}
+	c.CreatedAt = at
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *EnrollmentRepository) SelectCredentialById(id int) (*models.DeviceCredential, error) {
+	return r.selectOne(`SELECT `+credentialColumns+` FROM device_credentials WHERE device_credential_id = $1`, id)
+}
+
+func (r *EnrollmentRepository) SelectCredentialByTokenHash(hash string) (*models.DeviceCredential, error) {
+	return r.selectOne(`SELECT `+credentialColumns+` FROM device_credentials WHERE token_hash = $1`, hash)
+}
+
+func (r *EnrollmentRepository) SelectCredentialsByUserId(userId int) ([]models.DeviceCredential, error) {
+	rows, err := r.storage.db.Query(`SELECT `+credentialColumns+` FROM device_credentials
+										   WHERE user_id = $1 ORDER BY device_credential_id`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []models.DeviceCredential
+
+	for rows.Next() {
+		var c models.DeviceCredential
+
+		if err := scanCredential(rows, &c); err != nil {
+			return nil, err
+		}
+
+		credentials = append(credentials, c)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (r *EnrollmentRepository) BindPhone(id, phoneId int) error {
+	return bindCredential(r.storage.db, id, phoneId)
+}
+
+func bindCredential(q querier, id, phoneId int) error {
+	_, err := q.Exec(`UPDATE device_credentials SET phone_id = $2
+						WHERE device_credential_id = $1`, id, phoneId)
+
+	return err
+}
+
+// checkPhoneClaim returns ErrPhoneBound if the credential isn't bound yet and
+// the phone with modelNumber is bound to another active credential. The
+// phone row stays locked, so two new credentials can't both claim it.
+func checkPhoneClaim(q querier, credentialId int, modelNumber string) error {
+	var boundTo sql.NullInt64
+	err := q.QueryRow(`SELECT phone_id FROM device_credentials WHERE device_credential_id = $1 FOR UPDATE`,
+		credentialId).Scan(&boundTo)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return storage.ErrRecordNotFound
+		}
+		return err
+	}
+	if boundTo.Valid {
+		return nil
+	}
+
+	var phoneId int
+	err = q.QueryRow(`SELECT phone_id FROM phones WHERE model_number = $1 FOR UPDATE`, modelNumber).Scan(&phoneId)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var bound bool
+	err = q.QueryRow(`SELECT EXISTS (SELECT 1 FROM device_credentials
+									 WHERE phone_id = $1 AND revoked_at IS NULL AND device_credential_id <> $2)`,
+		phoneId, credentialId).Scan(&bound)
+	if err != nil {
+		return err
+	}
+	if bound {
+		return storage.ErrPhoneBound
+	}
+
+	return nil
+}
+
+func (r *EnrollmentRepository) RevokeCredential(id int, at time.Time) error {
+	_, err := r.storage.db.Exec(`UPDATE device_credentials SET revoked_at = $2
+									   WHERE device_credential_id = $1 AND revoked_at IS NULL`, id, at)
+
+	return err
+}
+
+func (r *EnrollmentRepository) selectOne(query string, args ...any) (*models.DeviceCredential, error) {
+	c := &models.DeviceCredential{}
+
+	if err := scanCredential(r.storage.db.QueryRow(query, args...), c); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
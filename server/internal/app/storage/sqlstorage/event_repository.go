@@ -0,0 +1,78 @@
+package sqlstorage
+
+import (
+	"fmt"
+	"server/internal/app/models"
+	"strings"
+)
+
+const eventColumns = `event_id, phone_id, type, message, created_at`
+
+type EventRepository struct {
+	storage *Storage
+}
+
+func scanEvent(row scanner, e *models.Event) error {
+	return row.Scan(
+		&e.Id,
+		&e.PhoneId,
+		&e.Type,
+		&e.Message,
+		&e.CreatedAt,
+	)
+}
+
+func (r *EventRepository) Create(e *models.Event) (*models.Event, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO events (phone_id, type, message)
+										VALUES ($1, $2, $3) RETURNING event_id, created_at`,
+		e.PhoneId, e.Type, e.Message).Scan(&e.Id, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (r *EventRepository) Select(filter models.EventFilter) ([]models.Event, error) {
+	var where []string
+	var args []any
+
+	if filter.PhoneId != nil {
+		args = append(args, *filter.PhoneId)
+		where = append(where, fmt.Sprintf(`phone_id = $%d`, len(args)))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		where = append(where, fmt.Sprintf(`type = $%d`, len(args)))
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY event_id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := r.storage.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+
+	for rows.Next() {
+		var e models.Event
+
+		if err := scanEvent(rows, &e); err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
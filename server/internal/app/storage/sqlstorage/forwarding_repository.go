@@ -0,0 +1,202 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strings"
+	"time"
+)
+
+const ruleColumns = `rule_id, name, enabled, notification_source, sender, body_pattern, phone_id, sim_card_id, url, format, chat_id, secret, created_at`
+
+const deliveryColumns = `delivery_id, rule_id, notification_id, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+type ForwardingRepository struct {
+	storage *Storage
+}
+
+func scanRule(row scanner, rule *models.ForwardingRule) error {
+	return row.Scan(
+		&rule.Id,
+		&rule.Name,
+		&rule.Enabled,
+		&rule.Source,
+		&rule.Sender,
+		&rule.BodyPattern,
+		&rule.PhoneId,
+		&rule.SimCardId,
+		&rule.Url,
+		&rule.Format,
+		&rule.ChatId,
+		&rule.Secret,
+		&rule.CreatedAt,
+	)
+}
+
+func scanDelivery(row scanner, d *models.Delivery) error {
+	return row.Scan(
+		&d.Id,
+		&d.RuleId,
+		&d.NotificationId,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	)
+}
+
+func (r *ForwardingRepository) CreateRule(rule *models.ForwardingRule) (*models.ForwardingRule, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO forwarding_rules (name, enabled, notification_source, sender, body_pattern, phone_id, sim_card_id, url, format, chat_id, secret)
+										VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING rule_id, created_at`,
+		rule.Name, rule.Enabled, rule.Source, rule.Sender, rule.BodyPattern, rule.PhoneId, rule.SimCardId,
+		rule.Url, rule.Format, rule.ChatId, rule.Secret).Scan(&rule.Id, &rule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *ForwardingRepository) SelectRuleById(id int) (*models.ForwardingRule, error) {
+	rule := &models.ForwardingRule{}
+
+	err := scanRule(r.storage.db.QueryRow(`SELECT `+ruleColumns+` FROM forwarding_rules WHERE rule_id = $1`, id), rule)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *ForwardingRepository) SelectRules() ([]models.ForwardingRule, error) {
+	rows, err := r.storage.db.Query(`SELECT ` + ruleColumns + ` FROM forwarding_rules ORDER BY rule_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.ForwardingRule
+
+	for rows.Next() {
+		var rule models.ForwardingRule
+
+		if err := scanRule(rows, &rule); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *ForwardingRepository) UpdateRule(rule *models.ForwardingRule) error {
+	res, err := r.storage.db.Exec(`UPDATE forwarding_rules
+										 SET name = $2, enabled = $3, notification_source = $4, sender = $5, body_pattern = $6,
+										     phone_id = $7, sim_card_id = $8, url = $9, format = $10, chat_id = $11, secret = $12
+										 WHERE rule_id = $1`,
+		rule.Id, rule.Name, rule.Enabled, rule.Source, rule.Sender, rule.BodyPattern, rule.PhoneId, rule.SimCardId,
+		rule.Url, rule.Format, rule.ChatId, rule.Secret)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *ForwardingRepository) DeleteRule(id int) error {
+	_, err := r.storage.db.Exec(`DELETE FROM forwarding_rules WHERE rule_id = $1`, id)
+
+	return err
+}
+
+func (r *ForwardingRepository) CreateDelivery(d *models.Delivery) (*models.Delivery, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO forwarding_deliveries (rule_id, notification_id, payload, status, next_attempt_at)
+										VALUES ($1, $2, $3, $4, $5) RETURNING delivery_id, created_at`,
+		d.RuleId, d.NotificationId, d.Payload, d.Status, d.NextAttemptAt).Scan(&d.Id, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (r *ForwardingRepository) SelectDueDeliveries(at time.Time, limit int) ([]models.Delivery, error) {
+	return r.selectDeliveries(`SELECT `+deliveryColumns+` FROM forwarding_deliveries
+									 WHERE status = $1 AND next_attempt_at <= $2
+									 ORDER BY next_attempt_at, delivery_id LIMIT $3`, models.DeliveryPending, at, limit)
+}
+
+func (r *ForwardingRepository) SelectDeliveries(filter models.DeliveryFilter) ([]models.Delivery, error) {
+	var where []string
+	var args []any
+
+	if filter.RuleId != 0 {
+		args = append(args, filter.RuleId)
+		where = append(where, fmt.Sprintf(`rule_id = $%d`, len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where = append(where, fmt.Sprintf(`status = $%d`, len(args)))
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM forwarding_deliveries`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY delivery_id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	return r.selectDeliveries(query, args...)
+}
+
+func (r *ForwardingRepository) UpdateDelivery(d *models.Delivery) error {
+	_, err := r.storage.db.Exec(`UPDATE forwarding_deliveries
+									   SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+									   WHERE delivery_id = $1`,
+		d.Id, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt)
+
+	return err
+}
+
+func (r *ForwardingRepository) selectDeliveries(query string, args ...any) ([]models.Delivery, error) {
+	rows, err := r.storage.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.Delivery
+
+	for rows.Next() {
+		var d models.Delivery
+
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
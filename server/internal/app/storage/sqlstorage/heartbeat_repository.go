@@ -0,0 +1,97 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+)
+
+const heartbeatColumns = `phone_id, battery_level, charging, network_type, ip, uptime, received_at`
+
+type HeartbeatRepository struct {
+	storage *Storage
+}
+
+func scanHeartbeat(row scanner, hb *models.Heartbeat) error {
+	return row.Scan(
+		&hb.PhoneId,
+		&hb.BatteryLevel,
+		&hb.Charging,
+		&hb.NetworkType,
+		&hb.Ip,
+		&hb.Uptime,
+		&hb.ReceivedAt,
+	)
+}
+
+func (r *HeartbeatRepository) Save(hb *models.Heartbeat) (string, error) {
+	tx, err := r.storage.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	found, err := markSeen(tx, hb.PhoneId, hb.ReceivedAt)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", storage.ErrRecordNotFound
+	}
+	previous, err := swapIp(tx, hb.PhoneId, hb.Ip)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`INSERT INTO device_heartbeats (phone_id, battery_level, charging, network_type, ip, uptime, received_at)
+							VALUES ($1, $2, $3, $4, $5, $6, $7)
+							ON CONFLICT (phone_id) DO UPDATE
+							SET battery_level = EXCLUDED.battery_level,
+							    charging = EXCLUDED.charging,
+							    network_type = EXCLUDED.network_type,
+							    ip = EXCLUDED.ip,
+							    uptime = EXCLUDED.uptime,
+							    received_at = EXCLUDED.received_at`,
+		hb.PhoneId, hb.BatteryLevel, hb.Charging, hb.NetworkType, hb.Ip, hb.Uptime, hb.ReceivedAt)
+	if err != nil {
+		return "", err
+	}
+
+	return previous, tx.Commit()
+}
+
+func (r *HeartbeatRepository) SelectByPhoneId(phoneId int) (*models.Heartbeat, error) {
+	hb := &models.Heartbeat{}
+
+	err := scanHeartbeat(r.storage.db.QueryRow(`SELECT `+heartbeatColumns+` FROM device_heartbeats WHERE phone_id = $1`, phoneId), hb)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return hb, nil
+}
+
+func (r *HeartbeatRepository) SelectAll() ([]models.Heartbeat, error) {
+	rows, err := r.storage.db.Query(`SELECT ` + heartbeatColumns + ` FROM device_heartbeats ORDER BY phone_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heartbeats []models.Heartbeat
+
+	for rows.Next() {
+		var hb models.Heartbeat
+
+		if err := scanHeartbeat(rows, &hb); err != nil {
+			return nil, err
+		}
+
+		heartbeats = append(heartbeats, hb)
+	}
+
+	return heartbeats, rows.Err()
+}
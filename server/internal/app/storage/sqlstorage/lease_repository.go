@@ -0,0 +1,100 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+)
+
+const leaseColumns = `lease_id, token, user_id, phone_id, filter, status, ttl_seconds, created_at, granted_at, expires_at, released_at`
+
+type LeaseRepository struct {
+	storage *Storage
+}
+
+func scanLease(row scanner, l *models.Lease) error {
+	var filter []byte
+
+	err := row.Scan(
+		&l.Id,
+		&l.Token,
+		&l.UserId,
+		&l.PhoneId,
+		&filter,
+		&l.Status,
+		&l.TtlSeconds,
+		&l.CreatedAt,
+		&l.GrantedAt,
+		&l.ExpiresAt,
+		&l.ReleasedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(filter, &l.Filter)
+}
+
+func (r *LeaseRepository) Create(l *models.Lease) (*models.Lease, error) {
+	filter, err := json.Marshal(l.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.storage.db.QueryRow(`INSERT INTO leases (token, user_id, phone_id, filter, status, ttl_seconds, granted_at, expires_at)
+										VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING lease_id, created_at`,
+		l.Token, l.UserId, l.PhoneId, filter, l.Status, l.TtlSeconds, l.GrantedAt, l.ExpiresAt).Scan(&l.Id, &l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *LeaseRepository) SelectByToken(token string) (*models.Lease, error) {
+	l := &models.Lease{}
+
+	err := scanLease(r.storage.db.QueryRow(`SELECT `+leaseColumns+` FROM leases WHERE token = $1`, token), l)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *LeaseRepository) SelectOpen() ([]models.Lease, error) {
+	rows, err := r.storage.db.Query(`SELECT ` + leaseColumns + ` FROM leases
+										   WHERE status IN ('queued', 'active')
+										   ORDER BY lease_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leases []models.Lease
+
+	for rows.Next() {
+		var l models.Lease
+
+		if err := scanLease(rows, &l); err != nil {
+			return nil, err
+		}
+
+		leases = append(leases, l)
+	}
+
+	return leases, rows.Err()
+}
+
+func (r *LeaseRepository) Update(l *models.Lease) error {
+	_, err := r.storage.db.Exec(`UPDATE leases
+									   SET phone_id = $2, status = $3, ttl_seconds = $4, granted_at = $5, expires_at = $6, released_at = $7
+									   WHERE lease_id = $1`,
+		l.Id, l.PhoneId, l.Status, l.TtlSeconds, l.GrantedAt, l.ExpiresAt, l.ReleasedAt)
+
+	return err
+}
@@ -0,0 +1,101 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+
+	"github.com/lib/pq"
+)
+
+const locationColumns = `location_id, parent_id, kind, name, created_at`
+
+type LocationRepository struct {
+	storage *Storage
+}
+
+func scanLocation(row scanner, l *models.Location) error {
+	return row.Scan(
+		&l.Id,
+		&l.ParentId,
+		&l.Kind,
+		&l.Name,
+		&l.CreatedAt,
+	)
+}
+
+func (r *LocationRepository) Create(l *models.Location) (*models.Location, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO locations (parent_id, kind, name)
+										VALUES ($1, $2, $3) RETURNING location_id, created_at`,
+		l.ParentId, l.Kind, l.Name).Scan(&l.Id, &l.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, storage.ErrRecordExists
+		}
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *LocationRepository) SelectById(id int) (*models.Location, error) {
+	l := &models.Location{}
+
+	err := scanLocation(r.storage.db.QueryRow(`SELECT `+locationColumns+` FROM locations WHERE location_id = $1`, id), l)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *LocationRepository) SelectAll() ([]models.Location, error) {
+	rows, err := r.storage.db.Query(`SELECT ` + locationColumns + ` FROM locations ORDER BY location_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locations []models.Location
+
+	for rows.Next() {
+		var l models.Location
+
+		if err := scanLocation(rows, &l); err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+func (r *LocationRepository) Update(l *models.Location) error {
+	res, err := r.storage.db.Exec(`UPDATE locations SET name = $2 WHERE location_id = $1`, l.Id, l.Name)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return storage.ErrRecordExists
+		}
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *LocationRepository) Delete(id int) error {
+	_, err := r.storage.db.Exec(`DELETE FROM locations WHERE location_id = $1`, id)
+
+	return err
+}
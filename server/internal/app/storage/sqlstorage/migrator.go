@@ -0,0 +1,205 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	up        string
+	down      string
+}
+
+// Migrator applies the <version>_<name>.up.sql / .down.sql files found in
+// source and records applied versions in the schema_versions table.
+type Migrator struct {
+	db     *sql.DB
+	source fs.FS
+}
+
+func (s *Storage) Migrator(source fs.FS) *Migrator {
+	return &Migrator{
+		db:     s.db,
+		source: source,
+	}
+}
+
+// Up applies every pending migration in version order and returns the ones
+// that were applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	migrations, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+
+	for _, mg := range migrations {
+		if mg.AppliedAt != nil {
+			continue
+		}
+		if err := m.apply(mg.up, `INSERT INTO schema_versions (version, name) VALUES ($1, $2)`, mg.Version, mg.Name); err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", mg.Version, mg.Name, err)
+		}
+		applied = append(applied, mg)
+	}
+
+	return applied, nil
+}
+
+// Down reverts the n most recently applied migrations and returns the ones
+// that were reverted.
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	migrations, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+		mg := migrations[i]
+		if mg.AppliedAt == nil {
+			continue
+		}
+		if mg.down == "" {
+			return reverted, fmt.Errorf("migration %d_%s has no down script", mg.Version, mg.Name)
+		}
+		if err := m.apply(mg.down, `DELETE FROM schema_versions WHERE version = $1`, mg.Version); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %w", mg.Version, mg.Name, err)
+		}
+		reverted = append(reverted, mg)
+	}
+
+	return reverted, nil
+}
+
+// Status returns every known migration in version order, with AppliedAt set
+// for the ones recorded in schema_versions.
+func (m *Migrator) Status() ([]Migration, error) {
+	migrations, err := loadMigrations(m.source)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.ensureVersionTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_versions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		if at, ok := applied[migrations[i].Version]; ok {
+			migrations[i].AppliedAt = &at
+		}
+	}
+
+	return migrations, nil
+}
+
+func (m *Migrator) ensureVersionTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_versions (
+								version BIGINT PRIMARY KEY,
+								name VARCHAR(255) NOT NULL,
+								applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+							)`)
+
+	return err
+}
+
+func (m *Migrator) apply(script string, record string, args ...any) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func loadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, e := range entries {
+		match := migrationFileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		script, err := fs.ReadFile(source, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+		} else if mg.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, mg.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			mg.up = string(script)
+		} else {
+			mg.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mg.Version, mg.Name)
+		}
+		migrations = append(migrations, *mg)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
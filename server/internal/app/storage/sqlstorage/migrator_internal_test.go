@@ -0,0 +1,44 @@
+package sqlstorage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"server/migrations"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	source := fstest.MapFS{
+		"2_add_index.up.sql":       {Data: []byte("CREATE INDEX a ON b (c);")},
+		"2_add_index.down.sql":     {Data: []byte("DROP INDEX a;")},
+		"1_create_tables.up.sql":   {Data: []byte("CREATE TABLE b (c INT);")},
+		"1_create_tables.down.sql": {Data: []byte("DROP TABLE b;")},
+		"README.md":                {Data: []byte("not a migration")},
+		"10_without_down.up.sql":   {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := loadMigrations(source)
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 3)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_tables", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE b;", migrations[0].down)
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, int64(10), migrations[2].Version)
+	assert.Empty(t, migrations[2].down)
+
+	_, err = loadMigrations(fstest.MapFS{
+		"1_only_down.down.sql": {Data: []byte("DROP TABLE b;")},
+	})
+	assert.Error(t, err)
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	all, err := loadMigrations(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, all)
+	for _, mg := range all {
+		assert.NotEmpty(t, mg.up)
+		assert.NotEmpty(t, mg.down)
+	}
+}
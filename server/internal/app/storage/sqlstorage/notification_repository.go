@@ -0,0 +1,266 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// notificationTimestamp is n.timestamp, which may be NULL, with NULL read as 0.
+// Must match the expression of the timestamp indexes.
+const notificationTimestamp = `COALESCE(n.timestamp, 0)`
+
+const notificationColumns = `n.notification_id, n.model_number, n.notification_source, n.sender, n.body, ` + notificationTimestamp + `,
+							 n.phone_id, n.sim_card_id, s.phone_number, n.otp_code, n.links, n.received_at,
+							 n.idempotency_key, n.fingerprint`
+
+// archivedColumns are copied from notifications to notifications_archive by
+// name, so unlike the SELECT purged.* the archive was first filled with, the
+// column order of the two tables doesn't matter. Columns added to
+// notifications must be added here and, in a new migration, to the archive.
+const archivedColumns = `notification_id, model_number, notification_source, sender, body, timestamp,
+						 phone_id, sim_card_id, otp_code, links, received_at, idempotency_key, fingerprint`
+
+type NotificationRepository struct {
+	storage *Storage
+}
+
+func scanNotification(row scanner, n *models.Notification) error {
+	var phoneNumber sql.NullString
+
+	err := row.Scan(
+		&n.Id,
+		&n.ModelNumber,
+		&n.Source,
+		&n.Sender,
+		&n.Body,
+		&n.Timestamp,
+		&n.PhoneId,
+		&n.SimCardId,
+		&phoneNumber,
+		&n.Code,
+		pq.Array(&n.Links),
+		&n.ReceivedAt,
+		&n.IdempotencyKey,
+		&n.Fingerprint,
+	)
+	n.PhoneNumber = phoneNumber.String
+
+	return err
+}
+
+func (r *NotificationRepository) Create(n *models.Notification) (*models.Notification, error) {
+	links := n.Links
+	if links == nil {
+		links = []string{}
+	}
+	if n.ReceivedAt.IsZero() {
+		n.ReceivedAt = time.Now()
+	}
+
+	err := r.storage.db.QueryRow(`INSERT INTO notifications (model_number,notification_source,sender,body,timestamp,phone_id,sim_card_id,otp_code,links,received_at,idempotency_key,fingerprint)
+										VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING notification_id`,
+		n.ModelNumber, n.Source, n.Sender, n.Body, n.Timestamp, n.PhoneId, n.SimCardId, n.Code, pq.StringArray(links), n.ReceivedAt,
+		n.IdempotencyKey, n.Fingerprint).Scan(&n.Id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, storage.ErrRecordExists
+		}
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (r *NotificationRepository) SelectDuplicate(n *models.Notification, window time.Duration) (*models.Notification, error) {
+	if n.IdempotencyKey == "" && n.Fingerprint == "" {
+		return nil, storage.ErrRecordNotFound
+	}
+
+	dup := &models.Notification{}
+	var row *sql.Row
+	if n.IdempotencyKey != "" {
+		row = r.storage.db.QueryRow(`SELECT `+notificationColumns+` FROM notifications n
+										   LEFT JOIN sim_cards s ON s.sim_card_id = n.sim_card_id
+										   WHERE n.model_number = $1 AND n.idempotency_key = $2`, n.ModelNumber, n.IdempotencyKey)
+	} else {
+		row = r.storage.db.QueryRow(`SELECT `+notificationColumns+` FROM notifications n
+										   LEFT JOIN sim_cards s ON s.sim_card_id = n.sim_card_id
+										   WHERE n.fingerprint = $1 AND n.timestamp BETWEEN $2 AND $3
+										   ORDER BY n.notification_id LIMIT 1`,
+			n.Fingerprint, n.Timestamp-window.Milliseconds(), n.Timestamp+window.Milliseconds())
+	}
+
+	if err := scanNotification(row, dup); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return dup, nil
+}
+
+func (r *NotificationRepository) SelectByModelTag(tag string) ([]models.Notification, error) {
+	return r.selectMany(`SELECT `+notificationColumns+` FROM notifications n
+							   LEFT JOIN sim_cards s ON s.sim_card_id = n.sim_card_id
+							   WHERE n.model_number = $1
+							   ORDER BY n.notification_id`, tag)
+}
+
+func (r *NotificationRepository) Search(filter models.NotificationFilter) ([]models.Notification, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf(`$%d`, len(args))
+	}
+
+	if filter.ModelNumber != "" {
+		where = append(where, `n.model_number = `+arg(filter.ModelNumber))
+	}
+	if filter.PhoneNumber != "" {
+		where = append(where, `s.phone_number = `+arg(filter.PhoneNumber))
+	}
+	if filter.Sender != "" {
+		where = append(where, `lower(n.sender) = lower(`+arg(filter.Sender)+`)`)
+	}
+	if filter.Source != "" {
+		where = append(where, `n.notification_source = `+arg(filter.Source))
+	}
+	if !filter.From.IsZero() {
+		where = append(where, notificationTimestamp+` >= `+arg(filter.From.UnixMilli()))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, notificationTimestamp+` < `+arg(filter.To.UnixMilli()))
+	}
+	if filter.Query != "" {
+		// Must match the expression of notifications_body_fts_idx.
+		where = append(where, `to_tsvector('simple', n.body) @@ websearch_to_tsquery('simple', `+arg(filter.Query)+`)`)
+	}
+
+	order := `ASC`
+	if filter.Descending {
+		order = `DESC`
+	}
+	if filter.After != nil {
+		cmp := `>`
+		if filter.Descending {
+			cmp = `<`
+		}
+		where = append(where, fmt.Sprintf(`(%s, n.notification_id) %s (%s, %s)`,
+			notificationTimestamp, cmp, arg(filter.After.Timestamp), arg(filter.After.Id)))
+	}
+
+	query := `SELECT ` + notificationColumns + ` FROM notifications n
+			  LEFT JOIN sim_cards s ON s.sim_card_id = n.sim_card_id`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, n.notification_id %s`, notificationTimestamp, order, order)
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + arg(filter.Limit)
+	}
+
+	return r.selectMany(query, args...)
+}
+
+func (r *NotificationRepository) SelectLatestCode(phoneNumber string, since time.Time) (*models.Notification, error) {
+	n := &models.Notification{}
+
+	err := scanNotification(r.storage.db.QueryRow(`SELECT `+notificationColumns+` FROM notifications n
+														 JOIN sim_cards s ON s.sim_card_id = n.sim_card_id
+														 WHERE s.phone_number = $1 AND n.otp_code <> '' AND n.received_at > $2
+														 ORDER BY n.received_at DESC, n.notification_id DESC LIMIT 1`, phoneNumber, since), n)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (r *NotificationRepository) selectMany(query string, args ...any) ([]models.Notification, error) {
+	rows, err := r.storage.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+
+	for rows.Next() {
+		var n models.Notification
+
+		if err := scanNotification(rows, &n); err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (r *NotificationRepository) Purge(rule models.RetentionRule, dryRun bool) (int, error) {
+	if rule.ReceivedBefore.IsZero() && rule.KeepPerDevice <= 0 {
+		return 0, nil
+	}
+
+	var where, expired []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf(`$%d`, len(args))
+	}
+
+	if len(rule.Sources) > 0 {
+		where = append(where, `notification_source = ANY(`+arg(pq.StringArray(rule.Sources))+`)`)
+	}
+	if len(rule.ExcludeSources) > 0 {
+		where = append(where, `NOT notification_source = ANY(`+arg(pq.StringArray(rule.ExcludeSources))+`)`)
+	}
+	if !rule.ReceivedBefore.IsZero() {
+		expired = append(expired, `received_at < `+arg(rule.ReceivedBefore))
+	}
+	if rule.KeepPerDevice > 0 {
+		expired = append(expired, `rank > `+arg(rule.KeepPerDevice))
+	}
+
+	selected := `SELECT notification_id, received_at,
+					    row_number() OVER (PARTITION BY model_number ORDER BY notification_id DESC) AS rank
+				 FROM notifications`
+	if len(where) > 0 {
+		selected += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	expiredIds := `SELECT notification_id FROM (` + selected + `) ranked WHERE ` + strings.Join(expired, ` OR `)
+
+	if dryRun {
+		var count int
+		err := r.storage.db.QueryRow(`SELECT count(*) FROM (`+expiredIds+`) expired`, args...).Scan(&count)
+
+		return count, err
+	}
+
+	query := `DELETE FROM notifications WHERE notification_id IN (` + expiredIds + `)`
+	if rule.Archive {
+		query = `WITH purged AS (` + query + ` RETURNING *)
+				 INSERT INTO notifications_archive (` + archivedColumns + `)
+				 SELECT ` + archivedColumns + ` FROM purged`
+	}
+
+	res, err := r.storage.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+
+	return int(count), err
+}
@@ -0,0 +1,308 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+	"server/internal/app/helper"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+const phoneColumns = `phone_id, manufacturer, model_tag, model_number, os_version, api_version, cpu, firmware, bootloader, supported_archs, sim_slots, sd_slots, inventory_tag, display_name, location, notes, slot_id, last_seen_at`
+
+type PhoneRepository struct {
+	storage *Storage
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPhone(row scanner, p *models.Phone) error {
+	return row.Scan(
+		&p.Id,
+		&p.Manufacturer,
+		&p.ModelTag,
+		&p.ModelNumber,
+		&p.OsVersion,
+		&p.ApiVersion,
+		&p.Cpu,
+		&p.Firmware,
+		&p.Bootloader,
+		pq.Array(&p.SupportedArchs),
+		&p.SimSlots,
+		&p.SdSlots,
+		&p.InventoryTag,
+		&p.DisplayName,
+		&p.Location,
+		&p.Notes,
+		&p.SlotId,
+		&p.LastSeenAt,
+	)
+}
+
+func (r *PhoneRepository) Create(p *models.Phone) (*models.Phone, error) {
+	tx, err := r.storage.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, _, err := upsertPhone(tx, p); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// upsertPhone stores the reported phone, keyed by model number, and writes
+// every field that differs from the stored row to phone_history.
+func upsertPhone(q querier, p *models.Phone) (bool, []models.PhoneChange, error) {
+	var old models.Phone
+
+	err := scanPhone(q.QueryRow(`SELECT `+phoneColumns+` FROM phones WHERE model_number = $1 FOR UPDATE`, p.ModelNumber), &old)
+	if err != nil && err != sql.ErrNoRows {
+		return false, nil, err
+	}
+	created := err == sql.ErrNoRows
+
+	err = q.QueryRow(`INSERT INTO phones (manufacturer, model_tag, model_number, os_version, api_version, cpu, firmware, bootloader, supported_archs, sim_slots, sd_slots) 
+										VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) 
+										ON CONFLICT (model_number) DO UPDATE
+										SET manufacturer = EXCLUDED.manufacturer,
+										    model_tag = EXCLUDED.model_tag,
+										    os_version = EXCLUDED.os_version,
+										    api_version = EXCLUDED.api_version,
+										    cpu = EXCLUDED.cpu,
+										    firmware = EXCLUDED.firmware,
+										    bootloader = EXCLUDED.bootloader,
+										    supported_archs = EXCLUDED.supported_archs,
+										    sim_slots = EXCLUDED.sim_slots,
+										    sd_slots = EXCLUDED.sd_slots
+										RETURNING phone_id`,
+		p.Manufacturer, p.ModelTag, p.ModelNumber, p.OsVersion, p.ApiVersion, p.Cpu, p.Firmware, p.Bootloader, pq.StringArray(p.SupportedArchs), p.SimSlots, p.SdSlots).Scan(&p.Id)
+	if err != nil {
+		return false, nil, err
+	}
+
+	p.KeepDetails(old)
+
+	changes := helper.DiffPhones(old, *p)
+	for i := range changes {
+		c := &changes[i]
+		c.PhoneId = p.Id
+		err := q.QueryRow(`INSERT INTO phone_history (phone_id, field, old_value, new_value)
+									VALUES ($1, $2, $3, $4) RETURNING change_id, changed_at`,
+			c.PhoneId, c.Field, c.OldValue, c.NewValue).Scan(&c.Id, &c.ChangedAt)
+		if err != nil {
+			return false, nil, err
+		}
+	}
+
+	return created, changes, nil
+}
+
+func (r *PhoneRepository) SelectById(id int) (*models.Phone, error) {
+	p := &models.Phone{}
+
+	err := scanPhone(r.storage.db.QueryRow(`SELECT `+phoneColumns+` FROM phones WHERE phone_id = $1`, id), p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *PhoneRepository) SelectByModelNumber(modelNumber string) (*models.Phone, error) {
+	p := &models.Phone{}
+
+	err := scanPhone(r.storage.db.QueryRow(`SELECT `+phoneColumns+` FROM phones WHERE model_number = $1 LIMIT 1`, modelNumber), p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *PhoneRepository) SelectAll() ([]models.Phone, error) {
+	rows, err := r.storage.db.Query(`SELECT ` + phoneColumns + ` FROM phones`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var phones []models.Phone
+
+	for rows.Next() {
+		var p models.Phone
+
+		if err := scanPhone(rows, &p); err != nil {
+			return nil, err
+		}
+
+		phones = append(phones, p)
+	}
+
+	return phones, nil
+}
+
+func (r *PhoneRepository) Update(p *models.Phone) error {
+	res, err := r.storage.db.Exec(`UPDATE phones
+										 SET inventory_tag = $2, display_name = $3, location = $4, notes = $5
+										 WHERE phone_id = $1`,
+		p.Id, p.InventoryTag, p.DisplayName, p.Location, p.Notes)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return storage.ErrRecordExists
+		}
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *PhoneRepository) MarkOffline(seenBefore, at time.Time) ([]models.Phone, error) {
+	rows, err := r.storage.db.Query(`UPDATE phones SET offline_since = $2
+										   WHERE last_seen_at < $1 AND offline_since IS NULL
+										   RETURNING `+phoneColumns, seenBefore, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var phones []models.Phone
+
+	for rows.Next() {
+		var p models.Phone
+
+		if err := scanPhone(rows, &p); err != nil {
+			return nil, err
+		}
+
+		phones = append(phones, p)
+	}
+
+	return phones, rows.Err()
+}
+
+func (r *PhoneRepository) AssignSlot(phoneId int, slotId *int) error {
+	res, err := r.storage.db.Exec(`UPDATE phones SET slot_id = $2 WHERE phone_id = $1`, phoneId, slotId)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return storage.ErrRecordExists
+		}
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// markSeen records a contact with the phone and clears its offline flag. It
+// reports whether the phone exists.
+func markSeen(q querier, phoneId int, at time.Time) (bool, error) {
+	res, err := q.Exec(`UPDATE phones SET last_seen_at = $2, offline_since = NULL WHERE phone_id = $1`, phoneId, at)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// swapIp records the address a phone was seen from and returns the previous
+// one. An empty ip is not recorded. The phone row must be locked, as markSeen
+// does.
+func swapIp(q querier, phoneId int, ip string) (string, error) {
+	if ip == "" {
+		return "", nil
+	}
+
+	var previous string
+	if err := q.QueryRow(`SELECT last_ip FROM phones WHERE phone_id = $1`, phoneId).Scan(&previous); err != nil {
+		return "", err
+	}
+	if _, err := q.Exec(`UPDATE phones SET last_ip = $2 WHERE phone_id = $1`, phoneId, ip); err != nil {
+		return "", err
+	}
+
+	return previous, nil
+}
+
+func (r *PhoneRepository) Delete(id int) error {
+	err := r.storage.db.QueryRow(`DELETE FROM phones WHERE phone_id = $1`, id).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PhoneRepository) SelectHistory(phoneId int) ([]models.PhoneChange, error) {
+	rows, err := r.storage.db.Query(`SELECT change_id, phone_id, field, old_value, new_value, changed_at
+										   FROM phone_history
+										   WHERE phone_id = $1
+										   ORDER BY changed_at, change_id`, phoneId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.PhoneChange
+
+	for rows.Next() {
+		var c models.PhoneChange
+
+		err := rows.Scan(
+			&c.Id,
+			&c.PhoneId,
+			&c.Field,
+			&c.OldValue,
+			&c.NewValue,
+			&c.ChangedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}
+
+func (r *PhoneRepository) CreateChange(c *models.PhoneChange) error {
+	return r.storage.db.QueryRow(`INSERT INTO phone_history (phone_id, field, old_value, new_value)
+										VALUES ($1, $2, $3, $4) RETURNING change_id, changed_at`,
+		c.PhoneId, c.Field, c.OldValue, c.NewValue).Scan(&c.Id, &c.ChangedAt)
+}
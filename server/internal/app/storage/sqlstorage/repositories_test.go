@@ -1,14 +1,14 @@
-package storage_test
+package sqlstorage_test
 
 import (
 	"github.com/stretchr/testify/assert"
 	"server/internal/app/models"
-	"server/internal/app/storage"
+	"server/internal/app/storage/sqlstorage"
 	"testing"
 )
 
 func TestPhoneRepository_Create(t *testing.T) {
-	s, teardown := storage.TestStorage(t, dbUrl)
+	s, teardown := sqlstorage.TestStorage(t, dbUrl)
 	defer teardown("phones")
 
 	p, err := s.Phone().Create(&models.Phone{
@@ -33,7 +33,7 @@ func TestPhoneRepository_Create(t *testing.T) {
 }
 
 func TestSimRepository_Create(t *testing.T) {
-	s, teardown := storage.TestStorage(t, dbUrl)
+	s, teardown := sqlstorage.TestStorage(t, dbUrl)
 	defer teardown("phones", "sim_cards")
 
 	p, err := s.Phone().Create(&models.Phone{
@@ -65,7 +65,7 @@ func TestSimRepository_Create(t *testing.T) {
 }
 
 func TestSdRepository_Create(t *testing.T) {
-	s, teardown := storage.TestStorage(t, dbUrl)
+	s, teardown := sqlstorage.TestStorage(t, dbUrl)
 	defer teardown("phones", "sd_cards")
 
 	p, err := s.Phone().Create(&models.Phone{
@@ -100,7 +100,7 @@ func TestSdRepository_Create(t *testing.T) {
 }
 
 func TestPhoneRepository_SelectByModelNumber(t *testing.T) {
-	s, teardown := storage.TestStorage(t, dbUrl)
+	s, teardown := sqlstorage.TestStorage(t, dbUrl)
 	defer teardown("phones")
 
 	_, err := s.Phone().SelectByModelNumber("SM-G973F/DS")
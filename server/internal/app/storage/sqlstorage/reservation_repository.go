@@ -0,0 +1,137 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+const reservationColumns = `r.reservation_id, r.phone_id, r.user_id, u.name, u.email, r.starts_at, r.ends_at, r.checked_in_at, r.note, r.created_at`
+
+type ReservationRepository struct {
+	storage *Storage
+}
+
+func scanReservation(row scanner, res *models.Reservation) error {
+	return row.Scan(
+		&res.Id,
+		&res.PhoneId,
+		&res.UserId,
+		&res.UserName,
+		&res.UserEmail,
+		&res.StartsAt,
+		&res.EndsAt,
+		&res.CheckedInAt,
+		&res.Note,
+		&res.CreatedAt,
+	)
+}
+
+func (r *ReservationRepository) Create(res *models.Reservation) (*models.Reservation, error) {
+	tx, err := r.storage.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the phone row serialises concurrent checkouts of one device.
+	var phoneId int
+	err = tx.QueryRow(`SELECT phone_id FROM phones WHERE phone_id = $1 FOR UPDATE`, res.PhoneId).Scan(&phoneId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	var conflicts bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM reservations
+										   WHERE phone_id = $1 AND checked_in_at IS NULL
+										   AND starts_at < $3 AND ends_at > $2)`,
+		res.PhoneId, res.StartsAt, res.EndsAt).Scan(&conflicts)
+	if err != nil {
+		return nil, err
+	}
+	if conflicts {
+		return nil, storage.ErrReservationConflict
+	}
+
+	err = tx.QueryRow(`INSERT INTO reservations (phone_id, user_id, starts_at, ends_at, note)
+									VALUES ($1, $2, $3, $4, $5) RETURNING reservation_id, created_at`,
+		res.PhoneId, res.UserId, res.StartsAt, res.EndsAt, res.Note).Scan(&res.Id, &res.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *ReservationRepository) SelectById(id int) (*models.Reservation, error) {
+	res := &models.Reservation{}
+
+	err := scanReservation(r.storage.db.QueryRow(`SELECT `+reservationColumns+`
+														FROM reservations r JOIN users u ON u.user_id = r.user_id
+														WHERE r.reservation_id = $1`, id), res)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *ReservationRepository) SelectByPhoneId(phoneId int, from, to time.Time) ([]models.Reservation, error) {
+	return r.selectMany(`SELECT `+reservationColumns+`
+							   FROM reservations r JOIN users u ON u.user_id = r.user_id
+							   WHERE r.phone_id = $1 AND r.starts_at < $3 AND r.ends_at > $2
+							   ORDER BY r.starts_at`, phoneId, from, to)
+}
+
+func (r *ReservationRepository) SelectActive(at time.Time) ([]models.Reservation, error) {
+	return r.selectMany(`SELECT `+reservationColumns+`
+							   FROM reservations r JOIN users u ON u.user_id = r.user_id
+							   WHERE r.checked_in_at IS NULL AND r.starts_at <= $1 AND r.ends_at > $1
+							   ORDER BY r.phone_id`, at)
+}
+
+func (r *ReservationRepository) CheckIn(id int, at time.Time) error {
+	_, err := r.storage.db.Exec(`UPDATE reservations SET checked_in_at = $2
+									   WHERE reservation_id = $1 AND checked_in_at IS NULL`, id, at)
+
+	return err
+}
+
+func (r *ReservationRepository) Delete(id int) error {
+	_, err := r.storage.db.Exec(`DELETE FROM reservations WHERE reservation_id = $1`, id)
+
+	return err
+}
+
+func (r *ReservationRepository) selectMany(query string, args ...any) ([]models.Reservation, error) {
+	rows, err := r.storage.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []models.Reservation
+
+	for rows.Next() {
+		var res models.Reservation
+
+		if err := scanReservation(rows, &res); err != nil {
+			return nil, err
+		}
+
+		reservations = append(reservations, res)
+	}
+
+	return reservations, rows.Err()
+}
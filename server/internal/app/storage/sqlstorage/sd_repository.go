@@ -0,0 +1,199 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"server/internal/app/helper"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strings"
+	"time"
+)
+
+const sdColumns = `sd_card_id, phone_id, sd_manufacturer_id, serial_no, total_space, used_space, free_space`
+
+type SdRepository struct {
+	storage *Storage
+}
+
+func scanSd(row scanner, sd *models.SdInfo) error {
+	return row.Scan(
+		&sd.Id,
+		&sd.PhoneId,
+		&sd.SdManufacturerId,
+		&sd.SerialNo,
+		&sd.TotalSpace,
+		&sd.UsedSpace,
+		&sd.FreeSpace,
+	)
+}
+
+func (r *SdRepository) Create(sd *models.SdInfo, p *models.Phone) (*models.SdInfo, error) {
+	tx, err := r.storage.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if sd, err = createSdCard(tx, sd, p); err != nil {
+		return nil, err
+	}
+
+	return sd, tx.Commit()
+}
+
+func createSdCard(q querier, sd *models.SdInfo, p *models.Phone) (*models.SdInfo, error) {
+	if helper.IsEmptySdSlot(*sd) {
+		return nil, nil
+	}
+
+	err := q.QueryRow(`INSERT INTO sd_cards (phone_id, sd_manufacturer_id, serial_no, total_space, used_space, free_space) 
+										VALUES ($1, $2, $3, $4, $5, $6) 
+										ON CONFLICT (serial_no) DO UPDATE
+										SET phone_id = $1, total_space = $4, used_space = $5, free_space = $6
+										RETURNING sd_card_id`,
+		p.Id, sd.SdManufacturerId, sd.SerialNo, sd.TotalSpace, sd.UsedSpace, sd.FreeSpace).Scan(&sd.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = q.Exec(`INSERT INTO sd_card_usage (sd_card_id, total_space, used_space, free_space)
+						   VALUES ($1, $2, $3, $4)`,
+		sd.Id, sd.TotalSpace, sd.UsedSpace, sd.FreeSpace)
+	if err != nil {
+		return nil, err
+	}
+
+	return sd, nil
+}
+
+func (r *SdRepository) RemovePhoneId(phoneId int) error {
+	return removeSdCardPhoneId(r.storage.db, phoneId)
+}
+
+func removeSdCardPhoneId(q querier, phoneId int) error {
+	_, err := q.Exec(`UPDATE sd_cards
+										SET phone_id = null
+										WHERE phone_id = $1`, phoneId)
+
+	return err
+}
+
+func (r *SdRepository) SelectAll() ([]models.SdInfo, error) {
+	return r.selectMany(`SELECT ` + sdColumns + ` FROM sd_cards`)
+}
+
+func (r *SdRepository) SelectById(id int) (*models.SdInfo, error) {
+	sd := &models.SdInfo{}
+
+	err := scanSd(r.storage.db.QueryRow(`SELECT `+sdColumns+` FROM sd_cards WHERE sd_card_id = $1`, id), sd)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return sd, nil
+}
+
+func (r *SdRepository) Select(filter models.SdFilter) ([]models.SdInfo, error) {
+	var where []string
+	var args []any
+
+	if filter.PhoneId != nil {
+		args = append(args, *filter.PhoneId)
+		where = append(where, fmt.Sprintf(`phone_id = $%d`, len(args)))
+	}
+	if filter.SdManufacturerId != "" {
+		args = append(args, filter.SdManufacturerId)
+		where = append(where, fmt.Sprintf(`sd_manufacturer_id = $%d`, len(args)))
+	}
+	if filter.Assigned != nil {
+		if *filter.Assigned {
+			where = append(where, `phone_id IS NOT NULL`)
+		} else {
+			where = append(where, `phone_id IS NULL`)
+		}
+	}
+
+	query := `SELECT ` + sdColumns + ` FROM sd_cards`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+
+	return r.selectMany(query+` ORDER BY sd_card_id`, args...)
+}
+
+func (r *SdRepository) SelectUsage(sdCardId int, from, to time.Time) ([]models.SdUsage, error) {
+	args := []any{sdCardId}
+	query := `SELECT sd_card_id, total_space, used_space, free_space, reported_at
+			  FROM sd_card_usage WHERE sd_card_id = $1`
+	if !from.IsZero() {
+		args = append(args, from)
+		query += fmt.Sprintf(` AND reported_at >= $%d`, len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		query += fmt.Sprintf(` AND reported_at < $%d`, len(args))
+	}
+
+	rows, err := r.storage.db.Query(query+` ORDER BY reported_at, sample_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []models.SdUsage
+
+	for rows.Next() {
+		var u models.SdUsage
+
+		if err := rows.Scan(&u.SdCardId, &u.TotalSpace, &u.UsedSpace, &u.FreeSpace, &u.ReportedAt); err != nil {
+			return nil, err
+		}
+
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
+
+func (r *SdRepository) Delete(id int) error {
+	res, err := r.storage.db.Exec(`DELETE FROM sd_cards WHERE sd_card_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *SdRepository) selectMany(query string, args ...any) ([]models.SdInfo, error) {
+	rows, err := r.storage.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sdCards []models.SdInfo
+
+	for rows.Next() {
+		var sd models.SdInfo
+
+		if err := scanSd(rows, &sd); err != nil {
+			return nil, err
+		}
+
+		sdCards = append(sdCards, sd)
+	}
+
+	return sdCards, rows.Err()
+}
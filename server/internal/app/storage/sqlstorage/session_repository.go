@@ -0,0 +1,113 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+const sessionColumns = `session_id, user_id, token_hash, previous_token_hash, user_agent, ip, created_at, last_used_at, expires_at, revoked_at`
+
+type SessionRepository struct {
+	storage *Storage
+}
+
+func scanSession(row scanner, s *models.Session) error {
+	return row.Scan(
+		&s.Id,
+		&s.UserId,
+		&s.TokenHash,
+		&s.PreviousTokenHash,
+		&s.UserAgent,
+		&s.Ip,
+		&s.CreatedAt,
+		&s.LastUsedAt,
+		&s.ExpiresAt,
+		&s.RevokedAt,
+	)
+}
+
+func (r *SessionRepository) Create(s *models.Session) (*models.Session, error) {
+	err := r.storage.db.QueryRow(`INSERT INTO sessions (user_id, token_hash, user_agent, ip, expires_at)
+										VALUES ($1, $2, $3, $4, $5) RETURNING session_id, created_at, last_used_at`,
+		s.UserId, s.TokenHash, s.UserAgent, s.Ip, s.ExpiresAt).Scan(&s.Id, &s.CreatedAt, &s.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *SessionRepository) SelectById(id int) (*models.Session, error) {
+	return r.selectOne(`SELECT `+sessionColumns+` FROM sessions WHERE session_id = $1`, id)
+}
+
+func (r *SessionRepository) SelectByTokenHash(hash string) (*models.Session, error) {
+	return r.selectOne(`SELECT `+sessionColumns+` FROM sessions
+							  WHERE token_hash = $1 OR previous_token_hash = $1 LIMIT 1`, hash)
+}
+
+func (r *SessionRepository) SelectActiveByUserId(userId int, at time.Time) ([]models.Session, error) {
+	rows, err := r.storage.db.Query(`SELECT `+sessionColumns+` FROM sessions
+										   WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+										   ORDER BY last_used_at DESC`, userId, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+
+	for rows.Next() {
+		var s models.Session
+
+		if err := scanSession(rows, &s); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *SessionRepository) Rotate(id int, oldHash, newHash string, expiresAt, at time.Time) error {
+	res, err := r.storage.db.Exec(`UPDATE sessions
+										 SET previous_token_hash = token_hash, token_hash = $3, expires_at = $4, last_used_at = $5
+										 WHERE session_id = $1 AND token_hash = $2 AND revoked_at IS NULL`,
+		id, oldHash, newHash, expiresAt, at)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) Revoke(id int, at time.Time) error {
+	_, err := r.storage.db.Exec(`UPDATE sessions SET revoked_at = $2
+									   WHERE session_id = $1 AND revoked_at IS NULL`, id, at)
+
+	return err
+}
+
+func (r *SessionRepository) selectOne(query string, args ...any) (*models.Session, error) {
+	s := &models.Session{}
+
+	if err := scanSession(r.storage.db.QueryRow(query, args...), s); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,226 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"server/internal/app/helper"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const simColumns = `sim_card_id, phone_id, phone_number, operator, slot, notes, tags,
+					plan_name, plan_contract_owner, plan_monthly_limit`
+
+type SimRepository struct {
+	storage *Storage
+}
+
+func scanSim(row scanner, sim *models.SimInfo) error {
+	err := row.Scan(
+		&sim.Id,
+		&sim.PhoneId,
+		&sim.PhoneNumber,
+		&sim.Operator,
+		&sim.Slot,
+		&sim.Notes,
+		pq.Array(&sim.Tags),
+		&sim.Plan.Name,
+		&sim.Plan.ContractOwner,
+		&sim.Plan.MonthlyLimit,
+	)
+	if sim.Tags == nil {
+		sim.Tags = []string{}
+	}
+
+	return err
+}
+
+func (r *SimRepository) Create(sim *models.SimInfo, p *models.Phone) (*models.SimInfo, error) {
+	return createSim(r.storage.db, sim, p)
+}
+
+func createSim(q querier, sim *models.SimInfo, p *models.Phone) (*models.SimInfo, error) {
+	if helper.IsEmptySimSlot(*sim) {
+		return nil, nil
+	}
+
+	err := q.QueryRow(`INSERT INTO sim_cards (phone_id, phone_number, operator, slot) 
+										VALUES ($1, $2, $3, $4) 
+										ON CONFLICT (phone_number) DO UPDATE
+		                    			SET phone_id = $1, slot = $4
+		                    			RETURNING sim_card_id`,
+		p.Id, sim.PhoneNumber, sim.Operator, sim.Slot).Scan(&sim.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return sim, nil
+}
+
+func (r *SimRepository) RemovePhoneId(phoneId int) error {
+	return removeSimPhoneId(r.storage.db, phoneId)
+}
+
+func removeSimPhoneId(q querier, phoneId int) error {
+	_, err := q.Exec(`UPDATE sim_cards
+									SET phone_id = null, slot = null
+									WHERE phone_id = $1`, phoneId)
+
+	return err
+}
+
+func (r *SimRepository) SelectAll() ([]models.SimInfo, error) {
+	return r.selectMany(`SELECT ` + simColumns + ` FROM sim_cards`)
+}
+
+func (r *SimRepository) SelectByPhoneId(phoneId int) ([]models.SimInfo, error) {
+	return r.selectMany(`SELECT `+simColumns+` FROM sim_cards WHERE phone_id = $1 ORDER BY slot`, phoneId)
+}
+
+func (r *SimRepository) SelectByPhoneNumber(number string) (*models.SimInfo, error) {
+	sim := &models.SimInfo{}
+
+	err := scanSim(r.storage.db.QueryRow(`SELECT `+simColumns+` FROM sim_cards WHERE phone_number = $1`, number), sim)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return sim, nil
+}
+
+func (r *SimRepository) SelectById(id int) (*models.SimInfo, error) {
+	sim := &models.SimInfo{}
+
+	err := scanSim(r.storage.db.QueryRow(`SELECT `+simColumns+` FROM sim_cards WHERE sim_card_id = $1`, id), sim)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return sim, nil
+}
+
+func (r *SimRepository) Select(filter models.SimFilter) ([]models.SimInfo, error) {
+	var where []string
+	var args []any
+
+	if filter.Operator != "" {
+		args = append(args, filter.Operator)
+		where = append(where, fmt.Sprintf(`operator = $%d`, len(args)))
+	}
+	if filter.Assigned != nil {
+		if *filter.Assigned {
+			where = append(where, `phone_id IS NOT NULL`)
+		} else {
+			where = append(where, `phone_id IS NULL`)
+		}
+	}
+	if filter.Tag != "" {
+		args = append(args, pq.StringArray{filter.Tag})
+		where = append(where, fmt.Sprintf(`tags @> $%d`, len(args)))
+	}
+
+	query := `SELECT ` + simColumns + ` FROM sim_cards`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+
+	return r.selectMany(query+` ORDER BY sim_card_id`, args...)
+}
+
+func (r *SimRepository) Register(sim *models.SimInfo) (*models.SimInfo, error) {
+	tags := sim.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	err := r.storage.db.QueryRow(`INSERT INTO sim_cards (phone_number, operator, notes, tags, plan_name, plan_contract_owner, plan_monthly_limit)
+										VALUES ($1, $2, $3, $4, $5, $6, $7)
+										ON CONFLICT (phone_number) DO NOTHING
+										RETURNING sim_card_id`,
+		sim.PhoneNumber, sim.Operator, sim.Notes, pq.StringArray(tags),
+		sim.Plan.Name, sim.Plan.ContractOwner, sim.Plan.MonthlyLimit).Scan(&sim.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordExists
+		}
+		return nil, err
+	}
+	sim.PhoneId, sim.Slot, sim.Tags = nil, nil, tags
+
+	return sim, nil
+}
+
+func (r *SimRepository) Update(sim *models.SimInfo) error {
+	tags := sim.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	res, err := r.storage.db.Exec(`UPDATE sim_cards
+										 SET operator = $2, notes = $3, tags = $4,
+										     plan_name = $5, plan_contract_owner = $6, plan_monthly_limit = $7
+										 WHERE sim_card_id = $1`,
+		sim.Id, sim.Operator, sim.Notes, pq.StringArray(tags),
+		sim.Plan.Name, sim.Plan.ContractOwner, sim.Plan.MonthlyLimit)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *SimRepository) Delete(id int) error {
+	res, err := r.storage.db.Exec(`DELETE FROM sim_cards WHERE sim_card_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *SimRepository) selectMany(query string, args ...any) ([]models.SimInfo, error) {
+	rows, err := r.storage.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var simCards []models.SimInfo
+
+	for rows.Next() {
+		var sim models.SimInfo
+
+		if err := scanSim(rows, &sim); err != nil {
+			return nil, err
+		}
+
+		simCards = append(simCards, sim)
+	}
+
+	return simCards, rows.Err()
+}
@@ -0,0 +1,267 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"server/internal/app/storage"
+
+	_ "github.com/lib/pq"
+)
+
+type Storage struct {
+	config                 *storage.DbConfig
+	db                     *sql.DB
+	phoneRepository        *PhoneRepository
+	simRepository          *SimRepository
+	sdRepository           *SdRepository
+	userRepository         *UserRepository
+	notificationRepository *NotificationRepository
+	userPhoneRepository    *UserPhoneRepository
+	deviceReportRepository *DeviceReportRepository
+	leaseRepository        *LeaseRepository
+	reservationRepository  *ReservationRepository
+	enrollmentRepository   *EnrollmentRepository
+	sessionRepository      *SessionRepository
+	forwardingRepository   *ForwardingRepository
+	heartbeatRepository    *HeartbeatRepository
+	eventRepository        *EventRepository
+	telemetryRepository    *TelemetryRepository
+	locationRepository     *LocationRepository
+	auditRepository        *AuditRepository
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx, so statements can be
+// shared between plain repository calls and transactions.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func New(config *storage.DbConfig) *Storage {
+	return &Storage{
+		config: config,
+	}
+}
+
+func (s *Storage) Open() error {
+	db, err := sql.Open("postgres", s.config.DbURL)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Ping(); err != nil {
+		return err
+	}
+
+	s.db = db
+
+	return nil
+}
+
+func (s *Storage) Close() {
+	s.db.Close()
+}
+
+func (s *Storage) Phone() storage.PhoneRepository {
+	if s.phoneRepository != nil {
+		return s.phoneRepository
+	}
+
+	s.phoneRepository = &PhoneRepository{
+		storage: s,
+	}
+
+	return s.phoneRepository
+}
+
+func (s *Storage) User() storage.UserRepository {
+	if s.userRepository != nil {
+		return s.userRepository
+	}
+
+	s.userRepository = &UserRepository{
+		storage: s,
+	}
+
+	return s.userRepository
+}
+
+func (s *Storage) Sim() storage.SimRepository {
+	if s.simRepository != nil {
+		return s.simRepository
+	}
+
+	s.simRepository = &SimRepository{
+		storage: s,
+	}
+
+	return s.simRepository
+}
+
+func (s *Storage) SdCard() storage.SdRepository {
+	if s.sdRepository != nil {
+		return s.sdRepository
+	}
+
+	s.sdRepository = &SdRepository{
+		storage: s,
+	}
+
+	return s.sdRepository
+}
+
+func (s *Storage) Notification() storage.NotificationRepository {
+	if s.notificationRepository != nil {
+		return s.notificationRepository
+	}
+
+	s.notificationRepository = &NotificationRepository{
+		storage: s,
+	}
+
+	return s.notificationRepository
+}
+
+func (s *Storage) UserPhone() storage.UserPhoneRepository {
+	if s.userPhoneRepository != nil {
+		return s.userPhoneRepository
+	}
+
+	s.userPhoneRepository = &UserPhoneRepository{
+		storage: s,
+	}
+
+	return s.userPhoneRepository
+}
+
+func (s *Storage) DeviceReport() storage.DeviceReportRepository {
+	if s.deviceReportRepository != nil {
+		return s.deviceReportRepository
+	}
+
+	s.deviceReportRepository = &DeviceReportRepository{
+		storage: s,
+	}
+
+	return s.deviceReportRepository
+}
+
+func (s *Storage) Reservation() storage.ReservationRepository {
+	if s.reservationRepository != nil {
+		return s.reservationRepository
+	}
+
+	s.reservationRepository = &ReservationRepository{
+		storage: s,
+	}
+
+	return s.reservationRepository
+}
+
+func (s *Storage) Lease() storage.LeaseRepository {
+	if s.leaseRepository != nil {
+		return s.leaseRepository
+	}
+
+	s.leaseRepository = &LeaseRepository{
+		storage: s,
+	}
+
+	return s.leaseRepository
+}
+
+func (s *Storage) Session() storage.SessionRepository {
+	if s.sessionRepository != nil {
+		return s.sessionRepository
+	}
+
+	s.sessionRepository = &SessionRepository{
+		storage: s,
+	}
+
+	return s.sessionRepository
+}
+
+func (s *Storage) Enrollment() storage.EnrollmentRepository {
+	if s.enrollmentRepository != nil {
+		return s.enrollmentRepository
+	}
+
+	s.enrollmentRepository = &EnrollmentRepository{
+		storage: s,
+	}
+
+	return s.enrollmentRepository
+}
+
+func (s *Storage) Forwarding() storage.ForwardingRepository {
+	if s.forwardingRepository != nil {
+		return s.forwardingRepository
+	}
+
+	s.forwardingRepository = &ForwardingRepository{
+		storage: s,
+	}
+
+	return s.forwardingRepository
+}
+
+func (s *Storage) Heartbeat() storage.HeartbeatRepository {
+	if s.heartbeatRepository != nil {
+		return s.heartbeatRepository
+	}
+
+	s.heartbeatRepository = &HeartbeatRepository{
+		storage: s,
+	}
+
+	return s.heartbeatRepository
+}
+
+func (s *Storage) Event() storage.EventRepository {
+	if s.eventRepository != nil {
+		return s.eventRepository
+	}
+
+	s.eventRepository = &EventRepository{
+		storage: s,
+	}
+
+	return s.eventRepository
+}
+
+func (s *Storage) Telemetry() storage.TelemetryRepository {
+	if s.telemetryRepository != nil {
+		return s.telemetryRepository
+	}
+
+	s.telemetryRepository = &TelemetryRepository{
+		storage: s,
+	}
+
+	return s.telemetryRepository
+}
+
+func (s *Storage) Location() storage.LocationRepository {
+	if s.locationRepository != nil {
+		return s.locationRepository
+	}
+
+	s.locationRepository = &LocationRepository{
+		storage: s,
+	}
+
+	return s.locationRepository
+}
+
+func (s *Storage) Audit() storage.AuditRepository {
+	if s.auditRepository != nil {
+		return s.auditRepository
+	}
+
+	s.auditRepository = &AuditRepository{
+		storage: s,
+	}
+
+	return s.auditRepository
+}
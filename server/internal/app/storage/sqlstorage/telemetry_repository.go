@@ -0,0 +1,89 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+const batterySampleColumns = `phone_id, level, temperature, health, cycle_count, reported_at`
+
+// metricColumns maps the telemetry metrics to the columns storing them.
+var metricColumns = map[string]string{
+	models.MetricBatteryLevel:       "level",
+	models.MetricBatteryTemperature: "temperature",
+	models.MetricBatteryCycleCount:  "cycle_count",
+}
+
+type TelemetryRepository struct {
+	storage *Storage
+}
+
+func (r *TelemetryRepository) CreateBatterySample(sample *models.BatterySample) error {
+	_, err := r.storage.db.Exec(`INSERT INTO battery_samples (phone_id, level, temperature, health, cycle_count, reported_at)
+									   VALUES ($1, $2, $3, $4, $5, $6)
+									   ON CONFLICT (phone_id, reported_at) DO UPDATE
+									   SET level = EXCLUDED.level,
+									       temperature = EXCLUDED.temperature,
+									       health = EXCLUDED.health,
+									       cycle_count = EXCLUDED.cycle_count`,
+		sample.PhoneId, sample.Level, sample.Temperature, sample.Health, sample.CycleCount, sample.ReportedAt)
+
+	return err
+}
+
+func (r *TelemetryRepository) SelectLastBatterySample(phoneId int) (*models.BatterySample, error) {
+	sample := &models.BatterySample{}
+
+	err := r.storage.db.QueryRow(`SELECT `+batterySampleColumns+` FROM battery_samples
+										WHERE phone_id = $1 ORDER BY reported_at DESC LIMIT 1`, phoneId).Scan(
+		&sample.PhoneId,
+		&sample.Level,
+		&sample.Temperature,
+		&sample.Health,
+		&sample.CycleCount,
+		&sample.ReportedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return sample, nil
+}
+
+func (r *TelemetryRepository) SelectSeries(phoneId int, metric string, from, to time.Time, step time.Duration) ([]models.TelemetryPoint, error) {
+	column, ok := metricColumns[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+
+	rows, err := r.storage.db.Query(`SELECT to_timestamp(floor(extract(epoch FROM reported_at)::float8 / $4::float8) * $4::float8) AS bucket,
+										   min(`+column+`)::float8, max(`+column+`)::float8, avg(`+column+`)::float8, count(*)
+										   FROM battery_samples
+										   WHERE phone_id = $1 AND reported_at >= $2 AND reported_at < $3
+										   GROUP BY bucket
+										   ORDER BY bucket`, phoneId, from, to, step.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []models.TelemetryPoint
+
+	for rows.Next() {
+		var p models.TelemetryPoint
+
+		if err := rows.Scan(&p.At, &p.Min, &p.Max, &p.Avg, &p.Samples); err != nil {
+			return nil, err
+		}
+
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
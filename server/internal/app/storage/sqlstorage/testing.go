@@ -1,7 +1,8 @@
-package storage
+package sqlstorage
 
 import (
 	"fmt"
+	"server/internal/app/storage"
 	"strings"
 	"testing"
 )
@@ -9,7 +10,7 @@ import (
 func TestStorage(t *testing.T, dbUrl string) (*Storage, func(...string)) {
 	t.Helper()
 
-	config := NewConfig()
+	config := storage.NewConfig()
 	config.DbURL = dbUrl
 	s := New(config)
 	if err := s.Open(); err != nil {
@@ -1,26 +1,32 @@
-package storage
+package sqlstorage
 
-import "server/internal/app/models"
+import (
+	"database/sql"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+)
 
 type UserPhoneRepository struct {
 	storage *Storage
 }
 
 func (r *UserPhoneRepository) CreateRelation(userId int, phoneId int) error {
-	row := r.storage.db.QueryRow(`INSERT INTO user_phone (user_id, phone_id) 
+	return createUserPhoneRelation(r.storage.db, userId, phoneId)
+}
+
+func createUserPhoneRelation(q querier, userId int, phoneId int) error {
+	_, err := q.Exec(`INSERT INTO user_phone (user_id, phone_id) 
 								 SELECT u.user_id, p.phone_id
 								 FROM users u, phones p
 								 WHERE u.user_id = $1 AND p.phone_id = $2
 								 ON CONFLICT (phone_id) DO UPDATE
 								 SET user_id = $1;`, userId, phoneId)
-	if err := row.Err(); err != nil {
-		return err
-	}
-	return nil
+
+	return err
 }
 
 func (r *UserPhoneRepository) SelectUsersWithPhones() ([]models.UserPhone, error) {
-	rows, err := r.storage.db.Query(`SELECT u.user_id, u.name, u.email, u.code, p.phone_id
+	rows, err := r.storage.db.Query(`SELECT u.user_id, u.name, u.email, p.phone_id
 										   FROM users u
 										   JOIN user_phone up ON u.user_id = up.user_id
 										   JOIN phones p ON up.phone_id = p.phone_id `)
@@ -39,7 +45,6 @@ func (r *UserPhoneRepository) SelectUsersWithPhones() ([]models.UserPhone, error
 			&u.Id,
 			&u.Name,
 			&u.Email,
-			&u.Code,
 			&p,
 		)
 		if err != nil {
@@ -53,3 +58,20 @@ func (r *UserPhoneRepository) SelectUsersWithPhones() ([]models.UserPhone, error
 
 	return usersPhones, nil
 }
+
+func (r *UserPhoneRepository) SelectOwner(phoneId int) (*models.User, error) {
+	u := &models.User{}
+
+	err := r.storage.db.QueryRow(`SELECT u.user_id, u.name, u.email
+										FROM users u
+										JOIN user_phone up ON u.user_id = up.user_id
+										WHERE up.phone_id = $1`, phoneId).Scan(&u.Id, &u.Name, &u.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
@@ -1,7 +1,9 @@
-package storage
+package sqlstorage
 
 import (
+	"database/sql"
 	"server/internal/app/models"
+	"server/internal/app/storage"
 )
 
 type UserRepository struct {
@@ -9,9 +11,9 @@ type UserRepository struct {
 }
 
 func (r *UserRepository) Create(u *models.User) (*models.User, error) {
-	err := r.storage.db.QueryRow(`INSERT INTO users (email,name,code,password,role)
-										VALUES ($1, $2, $3, $4, $5) RETURNING user_id`,
-		u.Email, u.Name, u.Code, u.Password, u.Role).Scan(&u.Id)
+	err := r.storage.db.QueryRow(`INSERT INTO users (email,name,password,role)
+										VALUES ($1, $2, $3, $4) RETURNING user_id`,
+		u.Email, u.Name, u.Password, u.Role).Scan(&u.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -19,58 +21,52 @@ func (r *UserRepository) Create(u *models.User) (*models.User, error) {
 	return u, nil
 }
 
-func (r *UserRepository) SelectByEmail(email string) (*models.User, error) {
+func (r *UserRepository) SelectById(id int) (*models.User, error) {
+	return selectUserById(r.storage.db, id)
+}
+
+func selectUserById(q querier, id int) (*models.User, error) {
 	u := &models.User{}
 
-	err := r.storage.db.QueryRow("SELECT * FROM users WHERE email = $1 LIMIT 1",
-		email).Scan(
+	err := q.QueryRow("SELECT * FROM users WHERE user_id = $1",
+		id).Scan(
 		&u.Id,
 		&u.Name,
-		&u.Code,
 		&u.Email,
 		&u.Password,
 		&u.Role)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
 		return nil, err
 	}
 
 	return u, nil
 }
 
-func (r *UserRepository) SelectByCode(code int) (*models.User, error) {
+func (r *UserRepository) SelectByEmail(email string) (*models.User, error) {
 	u := &models.User{}
 
-	err := r.storage.db.QueryRow("SELECT * FROM users WHERE code = $1 LIMIT 1",
-		code).Scan(
+	err := r.storage.db.QueryRow("SELECT * FROM users WHERE email = $1 LIMIT 1",
+		email).Scan(
 		&u.Id,
 		&u.Name,
-		&u.Code,
 		&u.Email,
 		&u.Password,
 		&u.Role)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, storage.ErrRecordNotFound
+		}
 		return nil, err
 	}
 
 	return u, nil
 }
 
-func (r *UserRepository) CheckCodeExists(code int) bool {
-	res := false
-
-	err := r.storage.db.QueryRow("select exists(select 1 from users where code = $1)",
-		code).Scan(
-		&res)
-
-	if err != nil {
-		return false
-	}
-
-	return res
-}
-
 func (r *UserRepository) SelectAll() ([]models.User, error) {
 	rows, err := r.storage.db.Query(`SELECT * FROM users ORDER BY user_id`)
 	if err != nil {
@@ -86,7 +82,6 @@ func (r *UserRepository) SelectAll() ([]models.User, error) {
 		err := rows.Scan(
 			&u.Id,
 			&u.Name,
-			&u.Code,
 			&u.Email,
 			&u.Password,
 			&u.Role,
@@ -101,6 +96,23 @@ func (r *UserRepository) SelectAll() ([]models.User, error) {
 	return users, nil
 }
 
+func (r *UserRepository) UpdateRole(id int, role string) error {
+	res, err := r.storage.db.Exec(`UPDATE users SET role = $1 WHERE user_id = $2`, role, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (r *UserRepository) Delete(id int) error {
 	err := r.storage.db.QueryRow(`DELETE FROM users WHERE user_id = $1`, id).Err()
 	if err != nil {
@@ -0,0 +1,36 @@
+package telemetry
+
+import (
+	"server/internal/app/models"
+	"time"
+)
+
+// AlertRule raises an event when a metric of a phone crosses the threshold.
+type AlertRule struct {
+	Name   string `toml:"name"`
+	Metric string `toml:"metric"`
+	// Op is one of >, >=, < and <=.
+	Op        string  `toml:"op"`
+	Threshold float64 `toml:"threshold"`
+}
+
+type Config struct {
+	// MinInterval is the resolution samples are stored at. A reading taken
+	// sooner after the last stored one is dropped unless it changes the
+	// state of an alert.
+	MinInterval time.Duration `toml:"min_interval"`
+	// MaxPoints bounds the points of a series; longer ranges are
+	// aggregated into wider buckets.
+	MaxPoints int         `toml:"max_points"`
+	Alerts    []AlertRule `toml:"alerts"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		MinInterval: 5 * time.Minute,
+		MaxPoints:   500,
+		Alerts: []AlertRule{
+			{Name: "overheating", Metric: models.MetricBatteryTemperature, Op: ">", Threshold: 45},
+		},
+	}
+}
@@ -0,0 +1,137 @@
+// Package telemetry stores the battery readings of phone reports as time
+// series and raises events when they cross the configured thresholds.
+package telemetry
+
+import (
+	"fmt"
+	"server/internal/app/models"
+	"server/internal/app/storage"
+	"time"
+)
+
+// Metrics lists the metrics series can be requested for.
+var Metrics = []string{
+	models.MetricBatteryLevel,
+	models.MetricBatteryTemperature,
+	models.MetricBatteryCycleCount,
+}
+
+func IsMetric(name string) bool {
+	_, ok := (&models.Battery{}).Metric(name)
+	return ok
+}
+
+type Recorder struct {
+	config *Config
+	repo   storage.TelemetryRepository
+	events storage.EventRepository
+}
+
+func NewRecorder(config *Config, repo storage.TelemetryRepository, events storage.EventRepository) (*Recorder, error) {
+	for i, a := range config.Alerts {
+		if !IsMetric(a.Metric) {
+			return nil, fmt.Errorf("telemetry: alert %d: unknown metric %q", i, a.Metric)
+		}
+		switch a.Op {
+		case ">", ">=", "<", "<=":
+		default:
+			return nil, fmt.Errorf("telemetry: alert %d: unknown op %q", i, a.Op)
+		}
+	}
+
+	return &Recorder{config: config, repo: repo, events: events}, nil
+}
+
+// Record stores the battery reading of a phone and returns the events of
+// the alerts it set off. An alert that keeps firing is raised once.
+func (r *Recorder) Record(p *models.Phone, b models.Battery, at time.Time) ([]models.Event, error) {
+	last, err := r.repo.SelectLastBatterySample(p.Id)
+	if err != nil && err != storage.ErrRecordNotFound {
+		return nil, err
+	}
+
+	var fired []AlertRule
+	changed := false
+	for _, a := range r.config.Alerts {
+		firing := a.matches(&b)
+		wasFiring := last != nil && a.matches(&last.Battery)
+		if firing != wasFiring {
+			changed = true
+		}
+		if firing && !wasFiring {
+			fired = append(fired, a)
+		}
+	}
+
+	// Keeping the readings that change an alert's state means the last
+	// stored sample always tells which alerts are firing.
+	if last != nil && at.Sub(last.ReportedAt) < r.config.MinInterval && !changed {
+		return nil, nil
+	}
+	if err := r.repo.CreateBatterySample(&models.BatterySample{PhoneId: p.Id, Battery: b, ReportedAt: at}); err != nil {
+		return nil, err
+	}
+
+	var events []models.Event
+
+	for _, a := range fired {
+		v, _ := b.Metric(a.Metric)
+		e, err := r.events.Create(&models.Event{
+			PhoneId: &p.Id,
+			Type:    models.EventTelemetryAlert,
+			Message: fmt.Sprintf("%s: %s is %g, %s %g (%s)", p.Name(), a.Metric, v, a.Op, a.Threshold, a.Name),
+		})
+		if err != nil {
+			return events, err
+		}
+		events = append(events, *e)
+	}
+
+	return events, nil
+}
+
+// Series returns a metric of a phone over [from, to), aggregated into
+// buckets of the returned step so that there are at most MaxPoints.
+func (r *Recorder) Series(phoneId int, metric string, from, to time.Time) (time.Duration, []models.TelemetryPoint, error) {
+	step := r.step(from, to)
+
+	points, err := r.repo.SelectSeries(phoneId, metric, from, to, step)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return step, points, nil
+}
+
+// step is the whole number of seconds that fits the range into MaxPoints
+// buckets, but no finer than the stored resolution.
+func (r *Recorder) step(from, to time.Time) time.Duration {
+	step := r.config.MinInterval
+	if r.config.MaxPoints > 0 {
+		if fit := to.Sub(from) / time.Duration(r.config.MaxPoints); fit > step {
+			step = fit
+		}
+	}
+	if step < time.Second {
+		return time.Second
+	}
+
+	return (step + time.Second - 1).Truncate(time.Second)
+}
+
+func (a AlertRule) matches(b *models.Battery) bool {
+	v, _ := b.Metric(a.Metric)
+
+	switch a.Op {
+	case ">":
+		return v > a.Threshold
+	case ">=":
+		return v >= a.Threshold
+	case "<":
+		return v < a.Threshold
+	case "<=":
+		return v <= a.Threshold
+	}
+
+	return false
+}
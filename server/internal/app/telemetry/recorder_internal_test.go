@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"server/internal/app/models"
+	"server/internal/app/storage/memstorage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRecorder_Validates(t *testing.T) {
+	st := memstorage.New()
+
+	_, err := NewRecorder(&Config{Alerts: []AlertRule{{Metric: "voltage", Op: ">"}}}, st.Telemetry(), st.Event())
+	assert.Error(t, err)
+	_, err = NewRecorder(&Config{Alerts: []AlertRule{{Metric: models.MetricBatteryLevel, Op: "=="}}}, st.Telemetry(), st.Event())
+	assert.Error(t, err)
+}
+
+func TestRecorder_Record(t *testing.T) {
+	st := memstorage.New()
+	p, _ := st.Phone().Create(&models.Phone{ModelNumber: "SM-G973F/DS"})
+	r, err := NewRecorder(NewConfig(), st.Telemetry(), st.Event())
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 8, 20, 12, 0, 0, 0, time.UTC)
+	record := func(after time.Duration, temperature float64) []models.Event {
+		t.Helper()
+		events, err := r.Record(p, models.Battery{Level: 80, Temperature: temperature, Health: "good"}, now.Add(after))
+		assert.NoError(t, err)
+		return events
+	}
+
+	assert.Empty(t, record(0, 30))
+	// Too soon after the last sample, dropped.
+	assert.Empty(t, record(time.Minute, 31))
+	// Too soon as well, but overheating is news.
+	events := record(2*time.Minute, 47.5)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, models.EventTelemetryAlert, events[0].Type)
+		assert.Equal(t, "SM-G973F/DS: battery_temperature is 47.5, > 45 (overheating)", events[0].Message)
+	}
+	// Still overheating: stored, but not raised again.
+	assert.Empty(t, record(10*time.Minute, 48))
+	assert.Empty(t, record(11*time.Minute, 35))
+	assert.Len(t, record(12*time.Minute, 46), 1)
+
+	step, points, err := r.Series(p.Id, models.MetricBatteryTemperature, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, step)
+	if assert.Len(t, points, 2) {
+		assert.Equal(t, now, points[0].At)
+		assert.Equal(t, 2, points[0].Samples)
+		assert.Equal(t, 30.0, points[0].Min)
+		assert.Equal(t, 47.5, points[0].Max)
+		assert.Equal(t, 3, points[1].Samples)
+		assert.Equal(t, 43.0, points[1].Avg)
+	}
+}
+
+func TestRecorder_Step(t *testing.T) {
+	r := &Recorder{config: &Config{MinInterval: time.Minute, MaxPoints: 500}}
+	now := time.Now()
+
+	assert.Equal(t, time.Minute, r.step(now, now.Add(time.Hour)))
+	assert.Equal(t, 173*time.Second, r.step(now, now.Add(24*time.Hour)))
+}
@@ -0,0 +1,231 @@
+// Package websocket implements the server side of RFC 6455, as far as it is
+// needed to push messages to browsers: text messages out, control frames in.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const acceptGuid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// maxControlPayload is the limit RFC 6455 puts on control frames. Data frames
+// sent by clients are discarded, so larger ones are refused as well.
+const maxControlPayload = 125
+
+var ErrClosed = errors.New("websocket: connection closed")
+
+type Conn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Upgrade performs the opening handshake. Browsers send cookies along with
+// cross-site handshakes, so requests from pages other than the server's own
+// or one of allowedOrigins are refused. On error a response has already been
+// written.
+func Upgrade(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "Expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("websocket: not an upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+	if !originAllowed(r, allowedOrigins) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return nil, errors.New("websocket: origin not allowed")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing key")
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Websocket is not supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\n")
+	rw.WriteString("Connection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: conn, rw: rw}, nil
+}
+
+// originAllowed reports whether the page that opened the connection is the
+// server's own or one of allowed. Clients other than browsers send no Origin.
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, o := range allowed {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// AcceptKey computes Sec-WebSocket-Accept for a Sec-WebSocket-Key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGuid))
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *Conn) WriteText(msg []byte) error {
+	return c.writeFrame(opText, msg)
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	return c.writeFrameLocked(op, payload)
+}
+
+func (c *Conn) writeFrameLocked(op byte, payload []byte) error {
+	header := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+
+	return c.rw.Flush()
+}
+
+// ReadLoop answers pings and returns when the client closes the connection
+// or sends something invalid. Messages from the client are discarded.
+func (c *Conn) ReadLoop() error {
+	for {
+		op, payload, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return err
+			}
+		case opClose:
+			return ErrClosed
+		}
+	}
+}
+
+func (c *Conn) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+		return 0, nil, err
+	}
+
+	op := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	if !masked {
+		return 0, nil, errors.New("websocket: client frame is not masked")
+	}
+	if length > maxControlPayload {
+		return 0, nil, errors.New("websocket: frame too large")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return op, payload, nil
+}
+
+// Close sends a close frame and closes the connection.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.writeFrameLocked(opClose, nil)
+
+	return c.conn.Close()
+}
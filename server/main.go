@@ -6,6 +6,8 @@ import (
 	"log"
 	"server/internal/app/api"
 	"server/internal/app/config"
+	"server/internal/app/storage/sqlstorage"
+	"server/migrations"
 )
 
 var (
@@ -14,18 +16,47 @@ var (
 
 func init() {
 	flag.StringVar(&configPath, "config-path", "configs/api.toml", "Path to config")
+	flag.Usage = usage
 }
 
 func main() {
 	flag.Parse()
 
+	if flag.NArg() > 0 && flag.Arg(0) != "migrate" {
+		flag.Usage()
+		log.Fatalf("unknown command %q", flag.Arg(0))
+	}
+
 	config := config.NewConfig()
 	_, err := toml.DecodeFile(configPath, config)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	s := api.New(config)
+	st := sqlstorage.New(config.Storage)
+	if err := st.Open(); err != nil {
+		log.Fatal(err)
+	}
+	defer st.Close()
+
+	if flag.NArg() > 0 {
+		if err := runMigrate(st.Migrator(migrations.FS), flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if config.AutoMigrate {
+		applied, err := st.Migrator(migrations.FS).Up()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, mg := range applied {
+			log.Printf("Applied migration %d_%s", mg.Version, mg.Name)
+		}
+	}
+
+	s := api.New(config, st)
 
 	if err := s.Start(); err != nil {
 		log.Fatal(err)
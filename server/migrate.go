@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"server/internal/app/storage/sqlstorage"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [migrate up | migrate down N | migrate status]\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func runMigrate(m *sqlstorage.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate: expected up, down N or status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		for _, mg := range applied {
+			fmt.Printf("Applied %d_%s\n", mg.Version, mg.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Nothing to apply")
+		}
+	case "down":
+		if len(args) != 2 {
+			return fmt.Errorf("migrate down: expected number of migrations to revert")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("migrate down: invalid number %q", args[1])
+		}
+		reverted, err := m.Down(n)
+		for _, mg := range reverted {
+			fmt.Printf("Reverted %d_%s\n", mg.Version, mg.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		migrations, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, mg := range migrations {
+			appliedAt := "pending"
+			if mg.AppliedAt != nil {
+				appliedAt = mg.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", mg.Version, mg.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("migrate: unknown command %q", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS phone_history (
+    change_id SERIAL PRIMARY KEY,
+    phone_id INT NOT NULL REFERENCES phones (phone_id) ON DELETE CASCADE,
+    field VARCHAR(255) NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS phone_history_phone_id_changed_at_idx ON phone_history (phone_id, changed_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS reservations (
+    reservation_id SERIAL PRIMARY KEY,
+    phone_id INT NOT NULL REFERENCES phones (phone_id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    checked_in_at TIMESTAMPTZ,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS reservations_phone_id_starts_at_idx ON reservations (phone_id, starts_at);
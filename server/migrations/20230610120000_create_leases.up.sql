@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS leases (
+    lease_id SERIAL PRIMARY KEY,
+    token VARCHAR(64) UNIQUE NOT NULL,
+    user_id INT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    phone_id INT REFERENCES phones (phone_id) ON DELETE SET NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL,
+    ttl_seconds INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    granted_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    released_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS leases_open_idx ON leases (lease_id) WHERE status IN ('queued', 'active');
@@ -0,0 +1 @@
+UPDATE users SET role = 'user' WHERE role IN ('viewer', 'tester', 'lab-manager');
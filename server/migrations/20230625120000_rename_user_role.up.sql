@@ -0,0 +1 @@
+UPDATE users SET role = 'tester' WHERE role = 'user';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS device_credentials;
+DROP TABLE IF EXISTS enrollment_tokens;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS notifications_sim_card_id_idx;
+DROP INDEX IF EXISTS notifications_phone_id_idx;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS sim_card_id,
+    DROP COLUMN IF EXISTS phone_id;
+
+ALTER TABLE sim_cards DROP COLUMN IF EXISTS slot;
@@ -0,0 +1,12 @@
+ALTER TABLE sim_cards ADD COLUMN IF NOT EXISTS slot INT;
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS phone_id INT REFERENCES phones (phone_id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS sim_card_id INT REFERENCES sim_cards (sim_card_id) ON DELETE SET NULL;
+
+UPDATE notifications n SET phone_id = p.phone_id
+FROM phones p
+WHERE p.model_number = n.model_number AND n.phone_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS notifications_phone_id_idx ON notifications (phone_id);
+CREATE INDEX IF NOT EXISTS notifications_sim_card_id_idx ON notifications (sim_card_id);
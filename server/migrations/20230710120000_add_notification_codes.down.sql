@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS notifications_codes_idx;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS received_at,
+    DROP COLUMN IF EXISTS links,
+    DROP COLUMN IF EXISTS otp_code;
@@ -0,0 +1,6 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS otp_code VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS links TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS received_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS notifications_codes_idx ON notifications (sim_card_id, received_at DESC) WHERE otp_code <> '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS forwarding_deliveries;
+DROP TABLE IF EXISTS forwarding_rules;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS forwarding_rules (
+    rule_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    notification_source VARCHAR(255) NOT NULL DEFAULT '',
+    sender VARCHAR(255) NOT NULL DEFAULT '',
+    body_pattern TEXT NOT NULL DEFAULT '',
+    phone_id INT REFERENCES phones (phone_id) ON DELETE CASCADE,
+    sim_card_id INT REFERENCES sim_cards (sim_card_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    format VARCHAR(32) NOT NULL DEFAULT 'generic',
+    chat_id VARCHAR(255) NOT NULL DEFAULT '',
+    secret VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS forwarding_deliveries (
+    delivery_id SERIAL PRIMARY KEY,
+    rule_id INT NOT NULL REFERENCES forwarding_rules (rule_id) ON DELETE CASCADE,
+    notification_id INT NOT NULL REFERENCES notifications (notification_id) ON DELETE CASCADE,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS forwarding_deliveries_due_idx ON forwarding_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS forwarding_deliveries_rule_id_idx ON forwarding_deliveries (rule_id, delivery_id DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS notifications_body_fts_idx;
+DROP INDEX IF EXISTS notifications_sender_idx;
+DROP INDEX IF EXISTS notifications_sim_timestamp_idx;
+DROP INDEX IF EXISTS notifications_model_timestamp_idx;
+DROP INDEX IF EXISTS notifications_timestamp_idx;
+
+ALTER TABLE notifications ALTER COLUMN timestamp DROP NOT NULL;
@@ -0,0 +1,8 @@
+UPDATE notifications SET timestamp = 0 WHERE timestamp IS NULL;
+ALTER TABLE notifications ALTER COLUMN timestamp SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS notifications_timestamp_idx ON notifications (timestamp, notification_id);
+CREATE INDEX IF NOT EXISTS notifications_model_timestamp_idx ON notifications (model_number, timestamp, notification_id);
+CREATE INDEX IF NOT EXISTS notifications_sim_timestamp_idx ON notifications (sim_card_id, timestamp, notification_id);
+CREATE INDEX IF NOT EXISTS notifications_sender_idx ON notifications (lower(sender));
+CREATE INDEX IF NOT EXISTS notifications_body_fts_idx ON notifications USING GIN (to_tsvector('simple', body));
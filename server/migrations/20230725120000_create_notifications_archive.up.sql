@@ -0,0 +1,8 @@
+-- Rows are copied with SELECT notifications.*, so columns added to
+-- notifications must be added here too, in the same order, before archived_at.
+CREATE TABLE IF NOT EXISTS notifications_archive (
+    LIKE notifications,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_archive_model_number_idx ON notifications_archive (model_number, notification_id);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS notifications_fingerprint_idx;
+DROP INDEX IF EXISTS notifications_idempotency_key_idx;
+
+ALTER TABLE notifications_archive
+    DROP COLUMN IF EXISTS fingerprint,
+    DROP COLUMN IF EXISTS idempotency_key;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS fingerprint,
+    DROP COLUMN IF EXISTS idempotency_key;
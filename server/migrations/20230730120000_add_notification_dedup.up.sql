@@ -0,0 +1,10 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS fingerprint VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE notifications_archive
+    ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS fingerprint VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_idempotency_key_idx ON notifications (model_number, idempotency_key) WHERE idempotency_key <> '';
+CREATE INDEX IF NOT EXISTS notifications_fingerprint_idx ON notifications (fingerprint, timestamp) WHERE fingerprint <> '';
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS sim_cards_tags_idx;
+DROP INDEX IF EXISTS sim_cards_operator_idx;
+
+ALTER TABLE sim_cards
+    DROP COLUMN IF EXISTS plan_monthly_limit,
+    DROP COLUMN IF EXISTS plan_contract_owner,
+    DROP COLUMN IF EXISTS plan_name,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS notes,
+    ALTER COLUMN operator DROP NOT NULL,
+    ALTER COLUMN operator DROP DEFAULT;
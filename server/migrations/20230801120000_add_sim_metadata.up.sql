@@ -0,0 +1,13 @@
+UPDATE sim_cards SET operator = '' WHERE operator IS NULL;
+
+ALTER TABLE sim_cards
+    ALTER COLUMN operator SET DEFAULT '',
+    ALTER COLUMN operator SET NOT NULL,
+    ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS plan_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS plan_contract_owner VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS plan_monthly_limit VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS sim_cards_operator_idx ON sim_cards (operator);
+CREATE INDEX IF NOT EXISTS sim_cards_tags_idx ON sim_cards USING GIN (tags);
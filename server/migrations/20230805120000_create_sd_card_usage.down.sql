@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sd_card_usage;
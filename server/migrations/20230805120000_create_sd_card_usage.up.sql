@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS sd_card_usage (
+    sample_id SERIAL PRIMARY KEY,
+    sd_card_id INT NOT NULL REFERENCES sd_cards (sd_card_id) ON DELETE CASCADE,
+    total_space INT NOT NULL,
+    used_space INT NOT NULL,
+    free_space INT NOT NULL,
+    reported_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sd_card_usage_sd_card_id_idx ON sd_card_usage (sd_card_id, reported_at);
+
+INSERT INTO sd_card_usage (sd_card_id, total_space, used_space, free_space)
+SELECT sd_card_id, total_space, used_space, free_space FROM sd_cards;
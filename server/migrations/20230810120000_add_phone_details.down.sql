@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS phones_inventory_tag_key;
+
+ALTER TABLE phones
+    DROP COLUMN IF EXISTS notes,
+    DROP COLUMN IF EXISTS location,
+    DROP COLUMN IF EXISTS display_name,
+    DROP COLUMN IF EXISTS inventory_tag;
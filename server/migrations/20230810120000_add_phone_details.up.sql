@@ -0,0 +1,7 @@
+ALTER TABLE phones
+    ADD COLUMN IF NOT EXISTS inventory_tag VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS display_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS location VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS phones_inventory_tag_key ON phones (inventory_tag) WHERE inventory_tag <> '';
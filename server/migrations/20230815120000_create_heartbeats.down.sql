@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS device_heartbeats;
+
+DROP INDEX IF EXISTS phones_last_seen_at_idx;
+
+ALTER TABLE phones
+    DROP COLUMN IF EXISTS offline_since,
+    DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,28 @@
+ALTER TABLE phones
+    ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ,
+    -- offline_since is set once the silence checker has raised the event for
+    -- the current silence, and cleared by the next contact.
+    ADD COLUMN IF NOT EXISTS offline_since TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS phones_last_seen_at_idx ON phones (last_seen_at) WHERE offline_since IS NULL;
+
+CREATE TABLE IF NOT EXISTS device_heartbeats (
+    phone_id INT PRIMARY KEY REFERENCES phones (phone_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    battery_level INT NOT NULL,
+    charging BOOLEAN NOT NULL,
+    network_type VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(255) NOT NULL DEFAULT '',
+    uptime BIGINT NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    event_id SERIAL PRIMARY KEY,
+    phone_id INT REFERENCES phones (phone_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    type VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS events_phone_id_idx ON events (phone_id, event_id);
+CREATE INDEX IF NOT EXISTS events_type_idx ON events (type, event_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS battery_samples;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS battery_samples (
+    phone_id INT NOT NULL REFERENCES phones (phone_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    level INT NOT NULL,
+    temperature DOUBLE PRECISION NOT NULL,
+    health VARCHAR(255) NOT NULL DEFAULT '',
+    cycle_count INT NOT NULL,
+    reported_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (phone_id, reported_at)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS phones_slot_id_key;
+
+ALTER TABLE phones
+    DROP COLUMN IF EXISTS slot_id;
+
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS locations (
+    location_id SERIAL PRIMARY KEY,
+    parent_id INT REFERENCES locations (location_id) ON DELETE RESTRICT,
+    kind VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS locations_parent_name_key ON locations (COALESCE(parent_id, 0), name);
+
+ALTER TABLE phones
+    ADD COLUMN IF NOT EXISTS slot_id INT REFERENCES locations (location_id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS phones_slot_id_key ON phones (slot_id);
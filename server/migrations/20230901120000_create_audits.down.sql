@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS audit_scans;
+DROP TABLE IF EXISTS audits;
@@ -0,0 +1,25 @@
+-- started_by and scanned_by aren't foreign keys: an audit stays on record
+-- after the users who did it are deleted.
+CREATE TABLE IF NOT EXISTS audits (
+    audit_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    started_by INT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+-- item_id points to phones, sim_cards or sd_cards depending on kind; kind is
+-- empty for codes that matched nothing.
+CREATE TABLE IF NOT EXISTS audit_scans (
+    scan_id SERIAL PRIMARY KEY,
+    audit_id INT NOT NULL REFERENCES audits (audit_id) ON DELETE CASCADE,
+    code VARCHAR(255) NOT NULL,
+    kind VARCHAR(16) NOT NULL DEFAULT '',
+    item_id INT,
+    slot_id INT REFERENCES locations (location_id) ON DELETE SET NULL,
+    phone_id INT REFERENCES phones (phone_id) ON DELETE SET NULL,
+    scanned_by INT NOT NULL,
+    scanned_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_scans_audit_id_idx ON audit_scans (audit_id, scan_id);
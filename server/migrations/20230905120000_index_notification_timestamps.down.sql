@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS notifications_sim_timestamp_idx;
+DROP INDEX IF EXISTS notifications_model_timestamp_idx;
+DROP INDEX IF EXISTS notifications_timestamp_idx;
+
+CREATE INDEX IF NOT EXISTS notifications_timestamp_idx ON notifications (timestamp, notification_id);
+CREATE INDEX IF NOT EXISTS notifications_model_timestamp_idx ON notifications (model_number, timestamp, notification_id);
+CREATE INDEX IF NOT EXISTS notifications_sim_timestamp_idx ON notifications (sim_card_id, timestamp, notification_id);
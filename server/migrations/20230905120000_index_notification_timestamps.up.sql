@@ -0,0 +1,11 @@
+-- timestamp is nullable again; rows without one sort as 0, as the search
+-- query reads them.
+ALTER TABLE notifications ALTER COLUMN timestamp DROP NOT NULL;
+
+DROP INDEX IF EXISTS notifications_timestamp_idx;
+DROP INDEX IF EXISTS notifications_model_timestamp_idx;
+DROP INDEX IF EXISTS notifications_sim_timestamp_idx;
+
+CREATE INDEX IF NOT EXISTS notifications_timestamp_idx ON notifications (COALESCE(timestamp, 0), notification_id);
+CREATE INDEX IF NOT EXISTS notifications_model_timestamp_idx ON notifications (model_number, COALESCE(timestamp, 0), notification_id);
+CREATE INDEX IF NOT EXISTS notifications_sim_timestamp_idx ON notifications (sim_card_id, COALESCE(timestamp, 0), notification_id);
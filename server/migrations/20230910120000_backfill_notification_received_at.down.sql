@@ -0,0 +1 @@
+-- The times received_at was stamped with before are not kept.
@@ -0,0 +1,8 @@
+-- received_at was added with DEFAULT now(), which stamped every notification
+-- stored before it with the time of that migration, the earliest received_at
+-- there is. Retention ages notifications by received_at, so those get the
+-- time the phone reported instead, where there is one. timestamp is in
+-- milliseconds.
+UPDATE notifications
+SET received_at = to_timestamp(timestamp / 1000.0)
+WHERE timestamp > 0 AND received_at = (SELECT min(received_at) FROM notifications);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS code INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Codes identified users to the agent before device credentials replaced them.
+ALTER TABLE users DROP COLUMN IF EXISTS code;
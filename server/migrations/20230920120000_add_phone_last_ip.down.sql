@@ -0,0 +1 @@
+ALTER TABLE phones DROP COLUMN IF EXISTS last_ip;
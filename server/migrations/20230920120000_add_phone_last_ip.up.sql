@@ -0,0 +1,8 @@
+-- The address a phone last reported or sent a heartbeat from, to notice it
+-- moving to another network.
+ALTER TABLE phones ADD COLUMN IF NOT EXISTS last_ip VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE phones p
+SET last_ip = h.ip
+FROM device_heartbeats h
+WHERE h.phone_id = p.phone_id;
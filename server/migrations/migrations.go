@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL schema migrations so the server binary
+// can apply them without the source tree being present.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS